@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agent-yandex/dating-bot/internal/config"
+	"github.com/agent-yandex/dating-bot/internal/deps"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/agent-yandex/dating-bot/internal/outbound"
+	"github.com/agent-yandex/dating-bot/internal/scheduler"
+	"github.com/agent-yandex/dating-bot/internal/scoring"
+	"github.com/agent-yandex/dating-bot/internal/tg/likescache"
+	"github.com/agent-yandex/dating-bot/internal/tg/reminders"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// newScheduler собирает фоновые задачи обслуживания. Выполняет их только
+// экземпляр бота, удерживающий блокировку лидера.
+func newScheduler(sender notify.Sender, notifier *notify.Service, redisClient *redis.Client, depends *deps.Dependencies, likes *likescache.Cache, cfg config.AppConfig) *scheduler.Scheduler {
+	logger := depends.Logger
+	sched := scheduler.New(scheduler.NewRedisLocker(redisClient, "maintenance"), scheduler.RealClock, cfg.Jobs.LeaseTTL, logger)
+	scoreJob := scoring.NewJob(depends.DB.ProfileStats, scoring.LikeCount{}, scoring.DefaultWeighted(), cfg.Rating.BatchSize, logger)
+
+	sched.Add(scheduler.Job{
+		Name:     "expired-likes",
+		Interval: cfg.Jobs.LikesCleanupInterval,
+		Run: func(ctx context.Context) error {
+			recipients, err := depends.DB.Likes.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if len(recipients) == 0 {
+				return nil
+			}
+			logger.Info("Expired likes deleted", zap.Int("recipients", len(recipients)))
+			if err := likes.Invalidate(ctx, recipients...); err != nil {
+				return fmt.Errorf("failed to prune likes cache: %w", err)
+			}
+			// Рейтинг считается по действующим лайкам, поэтому пересчитываем
+			// его сразу, не дожидаясь очередного прохода
+			return scoreJob.Run(ctx)
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "expired-passes",
+		Interval: cfg.Jobs.PassesCleanupInterval,
+		Run: func(ctx context.Context) error {
+			deleted, err := depends.DB.Passes.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			logger.Info("Expired passes deleted", zap.Int64("count", deleted))
+			return nil
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "expired-views",
+		Interval: cfg.Jobs.ViewsCleanupInterval,
+		Run: func(ctx context.Context) error {
+			deleted, err := depends.DB.Views.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			logger.Info("Expired views deleted", zap.Int64("count", deleted))
+			return nil
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "user-stats",
+		Interval: cfg.Jobs.StatsRefreshInterval,
+		Run:      depends.DB.UserStats.Refresh,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "premium-expiry",
+		Interval: cfg.Jobs.PremiumExpiryInterval,
+		Run: func(ctx context.Context) error {
+			_, err := depends.Premium.ExpireStale(ctx)
+			return err
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "like-reminders",
+		Interval: cfg.Jobs.LikeRemindersInterval,
+		Run:      reminders.NewLikeExpiry(sender, depends.DB.Likes, cfg.LikeReminderWindow, logger).Run,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "like-digest",
+		Interval: cfg.Jobs.LikeDigestInterval,
+		Run:      notifier.Flush,
+	})
+	sched.Add(scheduler.Job{
+		Name:     "scores",
+		Interval: cfg.Rating.RecomputeInterval,
+		Run:      scoreJob.Run,
+	})
+	return sched
+}
+
+// newOutboundBroker подключается к RabbitMQ или, если адрес не задан,
+// создаёт очередь в памяти процесса.
+func newOutboundBroker(cfg config.OutboundConfig) (outbound.Broker, error) {
+	if cfg.RabbitURL == "" {
+		return outbound.NewMemoryBroker(1000), nil
+	}
+	return outbound.NewRabbitBroker(cfg.RabbitURL, cfg.Queue)
+}
@@ -7,24 +7,36 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // часовой пояс тихих часов не должен зависеть от образа
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/agent-yandex/dating-bot/internal/config"
 	"github.com/agent-yandex/dating-bot/internal/deps"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/agent-yandex/dating-bot/internal/outbound"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"github.com/agent-yandex/dating-bot/internal/scoring"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/feed"
 	"github.com/agent-yandex/dating-bot/internal/tg/handlers"
+	"github.com/agent-yandex/dating-bot/internal/tg/likescache"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"github.com/go-redis/redis/v8"
 )
 
 func main() {
 	ctx := context.Background()
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6378",
-		Password: "",
-		DB:       0,
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
 	})
 
 	if _, err := redisClient.Ping(ctx).Result(); err != nil {
@@ -53,27 +65,91 @@ func main() {
 
 	updater := ext.NewUpdater(dp, nil)
 
-	stateMgr := states.NewManager(redisClient)
+	stateStore := states.NewLRUStore(states.NewRedisStore(redisClient), 10000, 5*time.Second)
+	stateMgr := states.NewManager(redisClient, stateStore, depends.Logger)
+	draftStore := drafts.New(drafts.NewRedisStore(redisClient))
+	searchFeed := feed.New(redisClient, depends.DB.Users, depends.DB.Views, scoring.NewExperiment(cfg.Rating.NewFormulaPercent), cfg.Search, depends.Logger)
+
+	likesCache := likescache.New(redisClient, cfg.Search.ResultsTTL, depends.Logger)
+	limiter := ratelimit.NewRedisLimiter(redisClient)
+	broker, err := newOutboundBroker(cfg.Outbound)
+	if err != nil {
+		log.Fatalf("Failed to connect to message broker: %v", err)
+	}
+	defer broker.Close()
+	// Уведомления отправляются через очередь; воркер доставляет их с
+	// повторами и соблюдением лимитов Telegram
+	sender := outbound.NewPublisher(broker)
+	outboundWorker := outbound.NewWorker(broker, notify.NewTelegramSender(b), limiter, outbound.WorkerConfig{
+		RatePerSecond: cfg.Outbound.RatePerSecond,
+		MaxAttempts:   cfg.Outbound.MaxAttempts,
+		BaseBackoff:   cfg.Outbound.BaseBackoff,
+		MaxBackoff:    cfg.Outbound.MaxBackoff,
+	}, depends.Logger)
+	quietZone, _ := time.LoadLocation(cfg.Notify.Timezone) // проверен при загрузке конфигурации
+	notifier := notify.NewService(sender, notify.NewRedisStore(redisClient), depends.DB.UserPreferences,
+		notify.QuietHours{From: cfg.Notify.QuietFrom, To: cfg.Notify.QuietTo, Location: quietZone},
+		cfg.Notify.DigestInterval, depends.Logger)
+	sched := newScheduler(sender, notifier, redisClient, depends, likesCache, cfg)
 
-	callbackHandler := handlers.NewCallbackHandler(stateMgr, &depends.DB, redisClient, depends.Logger)
-	messageHandler := handlers.NewMessageHandler(stateMgr, &depends.DB, redisClient, callbackHandler, depends.Logger)
-	commandHandler := handlers.NewCommandHandler(stateMgr, &depends.DB, depends.Logger)
+	// Кэши сбрасываются синхронно, чтобы пользователь сразу увидел
+	// изменения; остальные подписчики при EVENTS_ASYNC работают через очередь
+	syncBus := events.NewSyncBus(depends.Logger)
+	likesCache.Subscribe(syncBus)
+	searchFeed.Subscribe(syncBus)
+	var (
+		asyncBus  events.Bus = syncBus
+		brokerBus *events.BrokerBus
+		publisher events.Publisher = syncBus
+	)
+	if cfg.Events.Async {
+		eventsBroker, err := newOutboundBroker(config.OutboundConfig{RabbitURL: cfg.Outbound.RabbitURL, Queue: cfg.Events.Queue})
+		if err != nil {
+			log.Fatalf("Failed to connect to events broker: %v", err)
+		}
+		defer eventsBroker.Close()
+		brokerBus = events.NewBrokerBus(eventsBroker, depends.Logger)
+		asyncBus = brokerBus
+		publisher = events.Multi{syncBus, brokerBus}
+	}
 
+	callbackHandler := handlers.NewCallbackHandler(stateMgr, &depends.DB, redisClient, depends.Storage, draftStore, depends.Matching, depends.Premium, searchFeed, likesCache, notifier, sender, publisher, limiter, cfg.Search, cfg.RateLimit, depends.Logger)
+	scoring.SubscribeStats(asyncBus, depends.DB.ProfileStats)
+	callbackHandler.SubscribeNotifications(asyncBus)
+	events.SubscribeAnalytics(asyncBus, depends.Logger)
+	messageHandler := handlers.NewMessageHandler(stateMgr, &depends.DB, redisClient, depends.Storage, draftStore, callbackHandler, depends.Logger)
+	commandHandler := handlers.NewCommandHandler(stateMgr, &depends.DB, sched, cfg.AdminUserIDs, depends.Logger)
+	paymentHandler := handlers.NewPaymentHandler(depends.DB.Users, depends.Premium, depends.Logger)
+
+	// Платежи регистрируются первыми: служебное сообщение об оплате не должно
+	// попасть в общие обработчики сообщений
+	paymentHandler.RegisterPayments(dp)
 	messageHandler.RegisterMessages(dp)
 	callbackHandler.RegisterCallbacks(dp)
 	commandHandler.RegisterCommands(dp)
 
-	err = updater.StartPolling(b, &ext.PollingOpts{
-		DropPendingUpdates: true,
-		GetUpdatesOpts: &gotgbot.GetUpdatesOpts{
-			Timeout: 9,
-			RequestOpts: &gotgbot.RequestOpts{
-				Timeout: time.Second * 10,
-			},
-		},
-	})
-	if err != nil {
-		log.Fatalf("Failed to start polling: %v", err)
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	schedDone := make(chan struct{})
+	go func() {
+		defer close(schedDone)
+		sched.Run(jobsCtx)
+	}()
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		outboundWorker.Run(jobsCtx)
+	}()
+	eventsDone := make(chan struct{})
+	go func() {
+		defer close(eventsDone)
+		if brokerBus != nil {
+			brokerBus.Run(jobsCtx)
+		}
+	}()
+
+	if err := startUpdates(updater, b, cfg); err != nil {
+		log.Fatalf("Failed to start bot: %v", err)
 	}
 	log.Printf("%s is up and running...\n", b.User.Username)
 
@@ -81,6 +157,12 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	updater.Stop()
+	// Сначала дожидаемся обработчиков: они ещё публикуют уведомления и
+	// события, которые должны успеть забрать воркеры
+	stopUpdates(updater)
+	stopJobs()
+	<-schedDone
+	<-workerDone
+	<-eventsDone
 	log.Println("Bot stopped")
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/config"
+)
+
+const shutdownTimeout = 30 * time.Second
+
+// startUpdates запускает получение обновлений в режиме, выбранном в конфиге.
+func startUpdates(updater *ext.Updater, b *gotgbot.Bot, cfg config.AppConfig) error {
+	switch cfg.BotMode {
+	case config.ModePolling:
+		return startPolling(updater, b, cfg.Polling)
+	case config.ModeWebhook:
+		return startWebhook(updater, b, cfg.Webhook)
+	default:
+		return fmt.Errorf("unknown bot mode %q", cfg.BotMode)
+	}
+}
+
+func startPolling(updater *ext.Updater, b *gotgbot.Bot, cfg config.PollingConfig) error {
+	err := updater.StartPolling(b, &ext.PollingOpts{
+		DropPendingUpdates: cfg.DropPendingUpdates,
+		GetUpdatesOpts: &gotgbot.GetUpdatesOpts{
+			Timeout: int64(cfg.Timeout / time.Second),
+			RequestOpts: &gotgbot.RequestOpts{
+				Timeout: cfg.RequestTimeout,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start polling: %w", err)
+	}
+	log.Println("Receiving updates via long polling")
+	return nil
+}
+
+// startWebhook поднимает HTTP-сервер для вебхука и регистрирует его в Telegram.
+// Проверка секретного токена выполняется самим updater'ом по заголовку
+// X-Telegram-Bot-Api-Secret-Token.
+func startWebhook(updater *ext.Updater, b *gotgbot.Bot, cfg config.WebhookConfig) error {
+	err := updater.StartWebhook(b, cfg.Path, ext.WebhookOpts{
+		ListenAddr:        cfg.ListenAddr,
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		CertFile:          cfg.CertFile,
+		KeyFile:           cfg.KeyFile,
+		SecretToken:       cfg.SecretToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start webhook server: %w", err)
+	}
+
+	err = updater.SetAllBotWebhooks(cfg.URL, &gotgbot.SetWebhookOpts{
+		DropPendingUpdates: true,
+		SecretToken:        cfg.SecretToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	log.Printf("Receiving updates via webhook on %s\n", cfg.ListenAddr)
+	return nil
+}
+
+// stopUpdates останавливает приём обновлений и дожидается завершения уже
+// запущенных обработчиков, но не дольше shutdownTimeout.
+func stopUpdates(updater *ext.Updater) {
+	done := make(chan error, 1)
+	go func() {
+		done <- updater.Stop()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Printf("Failed to stop updater: %v", err)
+		}
+	case <-time.After(shutdownTimeout):
+		log.Println("Timed out waiting for handlers to finish")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/agent-yandex/dating-bot/internal/config"
+)
+
+const testToken = "123456:test"
+
+// fakeBotAPI отвечает на запросы бота к Bot API и запоминает вызванные
+// методы с параметрами.
+type fakeBotAPI struct {
+	mu    sync.Mutex
+	calls map[string]map[string]string
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	_ = json.NewDecoder(r.Body).Decode(&params)
+	method := strings.TrimPrefix(r.URL.Path, "/bot"+testToken+"/")
+
+	f.mu.Lock()
+	f.calls[method] = params
+	f.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": true})
+}
+
+func (f *fakeBotAPI) call(method string) (map[string]string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	params, ok := f.calls[method]
+	return params, ok
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func postUpdate(t *testing.T, url, secret string, update gotgbot.Update) int {
+	t.Helper()
+	body, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookDispatchesUpdates(t *testing.T) {
+	api := &fakeBotAPI{calls: make(map[string]map[string]string)}
+	apiServer := httptest.NewServer(api)
+	defer apiServer.Close()
+
+	b, err := gotgbot.NewBot(testToken, &gotgbot.BotOpts{
+		DisableTokenCheck: true,
+		BotClient: &gotgbot.BaseBotClient{
+			DefaultRequestOpts: &gotgbot.RequestOpts{APIURL: apiServer.URL},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handled := make(chan string, 1)
+	dp := ext.NewDispatcher(nil)
+	dp.AddHandler(handlers.NewMessage(nil, func(b *gotgbot.Bot, ctx *ext.Context) error {
+		handled <- ctx.EffectiveMessage.Text
+		return nil
+	}))
+	updater := ext.NewUpdater(dp, nil)
+
+	cfg := config.WebhookConfig{
+		ListenAddr:  freeAddr(t),
+		URL:         "https://bot.example.com",
+		Path:        "webhook",
+		SecretToken: "s3cret",
+	}
+	if err := startWebhook(updater, b, cfg); err != nil {
+		t.Fatalf("startWebhook: %v", err)
+	}
+	defer stopUpdates(updater)
+
+	params, ok := api.call("setWebhook")
+	if !ok {
+		t.Fatal("webhook was not registered in Telegram")
+	}
+	if params["url"] != "https://bot.example.com/webhook" || params["secret_token"] != "s3cret" {
+		t.Errorf("setWebhook params = %v", params)
+	}
+
+	url := "http://" + cfg.ListenAddr + "/" + cfg.Path
+	update := gotgbot.Update{
+		UpdateId: 1,
+		Message: &gotgbot.Message{
+			MessageId: 1,
+			Text:      "привет",
+			From:      &gotgbot.User{Id: 1},
+			Chat:      gotgbot.Chat{Id: 1, Type: "private"},
+		},
+	}
+
+	if code := postUpdate(t, url, "wrong", update); code != http.StatusUnauthorized {
+		t.Errorf("update with a wrong secret: status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := postUpdate(t, url, "", update); code != http.StatusUnauthorized {
+		t.Errorf("update without a secret: status %d, want %d", code, http.StatusUnauthorized)
+	}
+	select {
+	case text := <-handled:
+		t.Fatalf("unauthenticated update reached the handler: %q", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if code := postUpdate(t, url, cfg.SecretToken, update); code != http.StatusOK {
+		t.Fatalf("update with the secret: status %d, want %d", code, http.StatusOK)
+	}
+	select {
+	case text := <-handled:
+		if text != "привет" {
+			t.Errorf("handled text = %q", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatcher did not run the handler")
+	}
+}
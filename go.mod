@@ -5,12 +5,14 @@ go 1.23.7
 require (
 	github.com/Masterminds/squirrel v1.5.4
 	github.com/PaulSonOfLars/gotgbot/v2 v2.0.0-rc.29
+	github.com/alicebob/miniredis/v2 v2.37.0
 	github.com/elgris/stom v0.0.0-20160204063428-05ccb51a70bb
 	github.com/georgysavva/scany/v2 v2.1.4
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/jackc/pgx/v5 v5.0.0
 	github.com/joho/godotenv v1.5.1
 	github.com/minio/minio-go/v7 v7.0.91
+	github.com/rabbitmq/amqp091-go v1.9.0
 	go.uber.org/zap v1.27.0
 )
 
@@ -34,6 +36,7 @@ require (
 	github.com/minio/md5-simd v1.1.2 // indirect
 	github.com/rs/xid v1.6.0 // indirect
 	github.com/stretchr/testify v1.10.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
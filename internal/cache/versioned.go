@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Versioned — кэш с отдельным пространством ключей для каждого
+// пользователя. В ключ значения входит номер версии пользователя, поэтому
+// сброс — это один INCR версии вместо поиска ключей через KEYS: старые
+// значения становятся недостижимыми и удаляются Redis по TTL.
+//
+// Ключ версии живёт не меньше любого значения своей версии (его TTL
+// продлевается при каждой записи), поэтому после его истечения нумерация
+// может начаться заново без риска прочитать устаревшие данные.
+type Versioned struct {
+	redis  *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewVersioned(redisClient *redis.Client, prefix string, ttl time.Duration) *Versioned {
+	return &Versioned{
+		redis:  redisClient,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Get возвращает значение по ключу в пространстве пользователя; ok равен
+// false, если значения нет или оно было сброшено.
+func (v *Versioned) Get(ctx context.Context, userID int64, key string) ([]byte, bool, error) {
+	version, err := v.version(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	raw, err := v.redis.Get(ctx, v.dataKey(userID, version, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cache: %w", err)
+	}
+	return raw, true, nil
+}
+
+// Set сохраняет значение на ttl. Если пространство сбросили между чтением
+// версии и записью, значение попадёт в старую версию и не будет прочитано.
+func (v *Versioned) Set(ctx context.Context, userID int64, key string, value []byte) error {
+	version, err := v.version(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = v.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, v.dataKey(userID, version, key), value, v.ttl)
+		v.Extend(ctx, pipe, userID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// Keys возвращает имена ключей names в текущей версии пространства
+// пользователя — для значений, которые хранятся не строкой, а списком или
+// множеством и пишутся напрямую. Все имена относятся к одной версии.
+// Записывая по ним, нужно в той же транзакции вызвать Extend.
+func (v *Versioned) Keys(ctx context.Context, userID int64, names ...string) ([]string, error) {
+	version, err := v.version(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = v.dataKey(userID, version, name)
+	}
+	return keys, nil
+}
+
+// Extend продлевает версию пользователя на ttl в транзакции pipe, чтобы
+// она жила не меньше записанных в ней значений.
+func (v *Versioned) Extend(ctx context.Context, pipe redis.Pipeliner, userID int64) {
+	pipe.Expire(ctx, v.versionKey(userID), v.ttl)
+}
+
+// Invalidate сбрасывает пространства указанных пользователей.
+func (v *Versioned) Invalidate(ctx context.Context, userIDs ...int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := v.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userID := range userIDs {
+			pipe.Incr(ctx, v.versionKey(userID))
+			pipe.Expire(ctx, v.versionKey(userID), v.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+func (v *Versioned) version(ctx context.Context, userID int64) (int64, error) {
+	version, err := v.redis.Get(ctx, v.versionKey(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache version: %w", err)
+	}
+	return version, nil
+}
+
+func (v *Versioned) versionKey(userID int64) string {
+	return v.prefix + ":ver:user:" + strconv.FormatInt(userID, 10)
+}
+
+func (v *Versioned) dataKey(userID, version int64, key string) string {
+	return v.prefix + ":data:user:" + strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(version, 10) + ":" + key
+}
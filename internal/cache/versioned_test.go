@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+const ttl = time.Minute
+
+func newTestCache(t *testing.T) (*Versioned, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewVersioned(client, "test", ttl), mr
+}
+
+func get(t *testing.T, v *Versioned, userID int64, key string) (string, bool) {
+	t.Helper()
+	raw, ok, err := v.Get(context.Background(), userID, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return string(raw), ok
+}
+
+func set(t *testing.T, v *Versioned, userID int64, key, value string) {
+	t.Helper()
+	if err := v.Set(context.Background(), userID, key, []byte(value)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+}
+
+func TestVersionedGetSet(t *testing.T) {
+	v, _ := newTestCache(t)
+
+	if _, ok := get(t, v, 1, "page"); ok {
+		t.Fatal("empty cache returned a value")
+	}
+	set(t, v, 1, "page", "first")
+	set(t, v, 2, "page", "second")
+	if got, ok := get(t, v, 1, "page"); !ok || got != "first" {
+		t.Errorf("Get(1) = %q, %v; want first", got, ok)
+	}
+	if got, ok := get(t, v, 2, "page"); !ok || got != "second" {
+		t.Errorf("Get(2) = %q, %v; want second", got, ok)
+	}
+}
+
+func TestVersionedInvalidate(t *testing.T) {
+	ctx := context.Background()
+	v, _ := newTestCache(t)
+	set(t, v, 1, "a", "1a")
+	set(t, v, 1, "b", "1b")
+	set(t, v, 2, "a", "2a")
+	set(t, v, 3, "a", "3a")
+
+	if err := v.Invalidate(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []int64{1, 2} {
+		for _, key := range []string{"a", "b"} {
+			if got, ok := get(t, v, userID, key); ok {
+				t.Errorf("Get(%d, %s) = %q after Invalidate", userID, key, got)
+			}
+		}
+	}
+	if got, ok := get(t, v, 3, "a"); !ok || got != "3a" {
+		t.Errorf("Get(3) = %q, %v; another user's cache was invalidated", got, ok)
+	}
+
+	// В новой версии кэш снова работает
+	set(t, v, 1, "a", "new")
+	if got, ok := get(t, v, 1, "a"); !ok || got != "new" {
+		t.Errorf("Get(1) after Invalidate and Set = %q, %v; want new", got, ok)
+	}
+
+	if err := v.Invalidate(ctx); err != nil {
+		t.Errorf("Invalidate without users: %v", err)
+	}
+}
+
+func TestVersionedKeys(t *testing.T) {
+	ctx := context.Background()
+	v, mr := newTestCache(t)
+
+	keys, err := v.Keys(ctx, 1, "queue", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mr.RPush(keys[0], "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Invalidate(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := v.Keys(ctx, 1, "queue", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh[0] == keys[0] || fresh[1] == keys[1] {
+		t.Fatalf("keys did not change after Invalidate: %v, %v", keys, fresh)
+	}
+	if mr.Exists(fresh[0]) {
+		t.Error("list from the old version is visible in the new one")
+	}
+}
+
+func TestVersionedVersionExpiry(t *testing.T) {
+	ctx := context.Background()
+	v, mr := newTestCache(t)
+	versionKey := v.versionKey(1)
+
+	set(t, v, 1, "old", "stale")
+	if err := v.Invalidate(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись продлевает версию: она живёт не меньше своих значений
+	mr.FastForward(ttl / 2)
+	set(t, v, 1, "page", "value")
+	mr.FastForward(ttl/2 + time.Second)
+	if got, ok := get(t, v, 1, "page"); !ok || got != "value" {
+		t.Fatalf("Get = %q, %v; value expired together with the version", got, ok)
+	}
+	if !mr.Exists(versionKey) {
+		t.Fatal("version key expired before its values")
+	}
+
+	// Когда истекли и значения, и версия, нумерация начинается заново, и
+	// значения сброшенной когда-то нулевой версии не оживают
+	mr.FastForward(ttl)
+	if mr.Exists(versionKey) {
+		t.Fatal("version key did not expire")
+	}
+	if got, ok := get(t, v, 1, "old"); ok {
+		t.Errorf("Get(old) = %q after the version was reset", got)
+	}
+	set(t, v, 1, "page", "again")
+	if got, ok := get(t, v, 1, "page"); !ok || got != "again" {
+		t.Errorf("Get after reset = %q, %v; want again", got, ok)
+	}
+}
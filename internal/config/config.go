@@ -1,11 +1,24 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+
+	defaultEnvFile = "./config/.env"
+)
+
 type MinioConfig struct {
 	Endpoint  string
 	AccessKey string
@@ -13,34 +26,380 @@ type MinioConfig struct {
 	Bucket    string
 }
 
+// RedisConfig — параметры подключения к Redis.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// DBPoolConfig — размеры и времена жизни соединений пула PostgreSQL.
+type DBPoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
+// PollingConfig — параметры long polling.
+type PollingConfig struct {
+	Timeout            time.Duration
+	RequestTimeout     time.Duration
+	DropPendingUpdates bool
+}
+
+// webhookSecretPattern — допустимый секрет вебхука по правилам Bot API.
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// WebhookConfig — настройки приёма обновлений через вебхук.
+type WebhookConfig struct {
+	ListenAddr  string
+	URL         string
+	Path        string
+	SecretToken string
+	CertFile    string
+	KeyFile     string
+}
+
+// SearchConfig — размеры страниц и время жизни кешей поиска и лайков.
+type SearchConfig struct {
+	PageSize      int
+	SnapshotSize  int // сколько анкет фиксируется в порядке выдачи за один запрос
+	LikesPageSize int
+	ResultsTTL    time.Duration
+	SeenTTL       time.Duration
+	PassTTL       time.Duration // через сколько пропущенная анкета снова попадёт в поиск
+	UndoWindow    time.Duration // сколько времени после свайпа его можно отменить
+}
+
+// RateLimitConfig — ограничения частоты действий пользователя. Callbacks,
+// Messages и Likes действуют в скользящем окне Window, DailyLikes — в
+// скользящих сутках и не применяется к премиум-пользователям.
+type RateLimitConfig struct {
+	Window     time.Duration
+	Callbacks  int
+	Messages   int
+	Likes      int
+	DailyLikes int
+}
+
+// RatingConfig — пересчёт рейтинга анкет и эксперимент с формулой сортировки.
+type RatingConfig struct {
+	// NewFormulaPercent — доля пользователей (0–100), чья выдача сортируется
+	// по новой формуле; остальные видят сортировку по числу лайков. По
+	// умолчанию 0: эксперимент включается явно
+	NewFormulaPercent int
+	RecomputeInterval time.Duration
+	BatchSize         int
+}
+
+// NotifyConfig — уведомления о новых лайках. В тихие часы [QuietFrom,
+// QuietTo) по времени Timezone уведомления копятся; после уведомления
+// следующие лайки собираются в дайджест не чаще раза в DigestInterval.
+type NotifyConfig struct {
+	QuietFrom      int
+	QuietTo        int
+	Timezone       string
+	DigestInterval time.Duration
+}
+
+// OutboundConfig — очередь исходящих уведомлений в RabbitMQ. Пустой
+// RabbitURL включает очередь в памяти процесса (для локального запуска).
+type OutboundConfig struct {
+	RabbitURL     string
+	Queue         string
+	RatePerSecond int
+	MaxAttempts   int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+}
+
+// EventsConfig — доставка доменных событий. При Async уведомления,
+// статистика и аналитика обрабатываются через очередь Queue брокера из
+// OutboundConfig; кэши сбрасываются синхронно в любом случае.
+type EventsConfig struct {
+	Async bool
+	Queue string
+}
+
+// JobsConfig — фоновое обслуживание. Задачи выполняет только экземпляр,
+// удерживающий блокировку лидера в Redis.
+type JobsConfig struct {
+	LeaseTTL              time.Duration
+	LikesCleanupInterval  time.Duration
+	PassesCleanupInterval time.Duration
+	ViewsCleanupInterval  time.Duration
+	StatsRefreshInterval  time.Duration
+	PremiumExpiryInterval time.Duration
+	LikeRemindersInterval time.Duration
+	LikeDigestInterval    time.Duration
+}
+
+// PremiumConfig — цена и срок премиум-подписки.
+type PremiumConfig struct {
+	PriceStars int64
+	Duration   time.Duration
+}
+
 type AppConfig struct {
 	DBHost             string
 	DBPort             string
 	DBUser             string
 	DBPassword         string
 	DBName             string
+	DBPool             DBPoolConfig
 	TELEGRAM_BOT_TOKEN string
 	Minio              MinioConfig // Новое поле для MinIO
+	Redis              RedisConfig
+	BotMode            string // "polling" (по умолчанию) или "webhook"
+	Polling            PollingConfig
+	Webhook            WebhookConfig
+	Search             SearchConfig
+	LikeTTL            time.Duration // срок жизни лайка; передаётся и в базу
+	LikeReminderWindow time.Duration // за сколько до истечения лайка напоминать о нём
+	Premium            PremiumConfig
+	RateLimit          RateLimitConfig
+	Rating             RatingConfig
+	Jobs               JobsConfig
+	Notify             NotifyConfig
+	Outbound           OutboundConfig
+	Events             EventsConfig
+	AdminUserIDs       []int64 // кому доступны служебные команды
 }
 
-func LoadConfig() AppConfig {
-	err := godotenv.Load("./config/.env")
-	if err != nil {
-		return AppConfig{}
+// LoadConfig читает конфигурацию из переменных окружения. Если есть файл
+// .env (путь можно переопределить через CONFIG_FILE), его значения
+// дополняют окружение, не перезаписывая уже заданные переменные.
+// Ошибки разбора и валидации собираются и возвращаются одним списком.
+func LoadConfig() (AppConfig, error) {
+	envFile := os.Getenv("CONFIG_FILE")
+	if envFile == "" {
+		envFile = defaultEnvFile
+	}
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return AppConfig{}, fmt.Errorf("failed to load %s: %w", envFile, err)
 	}
 
-	return AppConfig{
-		DBHost:             os.Getenv("DB_HOST"),
-		DBPort:             os.Getenv("DB_PORT"),
-		DBUser:             os.Getenv("DB_USER"),
-		DBPassword:         os.Getenv("DB_PASSWORD"),
-		DBName:             os.Getenv("DB_NAME"),
-		TELEGRAM_BOT_TOKEN: os.Getenv("TELEGRAM_BOT_TOKEN"),
+	e := &env{}
+	cfg := AppConfig{
+		DBHost:     e.String("DB_HOST", ""),
+		DBPort:     e.String("DB_PORT", "5432"),
+		DBUser:     e.String("DB_USER", ""),
+		DBPassword: e.String("DB_PASSWORD", ""),
+		DBName:     e.String("DB_NAME", ""),
+		DBPool: DBPoolConfig{
+			MaxConns:        int32(e.Int("DB_MAX_CONNS", 20)),
+			MinConns:        int32(e.Int("DB_MIN_CONNS", 2)),
+			MaxConnLifetime: e.Duration("DB_MAX_CONN_LIFETIME", 30*time.Minute),
+			MaxConnIdleTime: e.Duration("DB_MAX_CONN_IDLE_TIME", 5*time.Minute),
+		},
+		TELEGRAM_BOT_TOKEN: e.String("TELEGRAM_BOT_TOKEN", ""),
 		Minio: MinioConfig{
-			Endpoint:  os.Getenv("MINIO_ENDPOINT"),
-			AccessKey: os.Getenv("MINIO_ACCESS_KEY"),
-			SecretKey: os.Getenv("MINIO_SECRET_KEY"),
-			Bucket:    os.Getenv("MINIO_BUCKET"),
+			Endpoint:  e.String("MINIO_ENDPOINT", ""),
+			AccessKey: e.String("MINIO_ACCESS_KEY", ""),
+			SecretKey: e.String("MINIO_SECRET_KEY", ""),
+			Bucket:    e.String("MINIO_BUCKET", ""),
+		},
+		Redis: RedisConfig{
+			Addr:     e.String("REDIS_ADDR", "localhost:6378"),
+			Password: e.String("REDIS_PASSWORD", ""),
+			DB:       e.Int("REDIS_DB", 0),
+		},
+		BotMode: e.String("BOT_MODE", ModePolling),
+		Polling: PollingConfig{
+			Timeout:            e.Duration("POLLING_TIMEOUT", 9*time.Second),
+			RequestTimeout:     e.Duration("POLLING_REQUEST_TIMEOUT", 10*time.Second),
+			DropPendingUpdates: e.Bool("POLLING_DROP_PENDING_UPDATES", true),
 		},
+		Webhook: WebhookConfig{
+			ListenAddr:  e.String("WEBHOOK_LISTEN_ADDR", "0.0.0.0:8080"),
+			URL:         e.String("WEBHOOK_URL", ""),
+			Path:        e.String("WEBHOOK_PATH", "webhook"),
+			SecretToken: e.String("WEBHOOK_SECRET_TOKEN", ""),
+			CertFile:    e.String("WEBHOOK_CERT_FILE", ""),
+			KeyFile:     e.String("WEBHOOK_KEY_FILE", ""),
+		},
+		Search: SearchConfig{
+			PageSize:      e.Int("SEARCH_PAGE_SIZE", 50),
+			SnapshotSize:  e.Int("SEARCH_SNAPSHOT_SIZE", 500),
+			LikesPageSize: e.Int("LIKES_PAGE_SIZE", 10),
+			ResultsTTL:    e.Duration("SEARCH_RESULTS_TTL", 30*time.Minute),
+			SeenTTL:       e.Duration("SEARCH_SEEN_TTL", 24*time.Hour),
+			PassTTL:       e.Duration("SEARCH_PASS_TTL", 30*24*time.Hour),
+			UndoWindow:    e.Duration("SEARCH_UNDO_WINDOW", 5*time.Minute),
+		},
+		LikeTTL:            e.Duration("LIKE_TTL", 72*time.Hour),
+		LikeReminderWindow: e.Duration("LIKE_REMINDER_WINDOW", 24*time.Hour),
+		Premium: PremiumConfig{
+			PriceStars: int64(e.Int("PREMIUM_PRICE_STARS", 250)),
+			Duration:   e.Duration("PREMIUM_DURATION", 30*24*time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Window:     e.Duration("RATE_LIMIT_WINDOW", time.Minute),
+			Callbacks:  e.Int("RATE_LIMIT_CALLBACKS", 60),
+			Messages:   e.Int("RATE_LIMIT_MESSAGES", 30),
+			Likes:      e.Int("RATE_LIMIT_LIKES", 20),
+			DailyLikes: e.Int("DAILY_LIKE_QUOTA", 50),
+		},
+		Rating: RatingConfig{
+			NewFormulaPercent: e.Int("RATING_NEW_FORMULA_PERCENT", 0),
+			RecomputeInterval: e.Duration("RATING_RECOMPUTE_INTERVAL", 10*time.Minute),
+			BatchSize:         e.Int("RATING_BATCH_SIZE", 500),
+		},
+		Jobs: JobsConfig{
+			LeaseTTL:              e.Duration("JOBS_LEASE_TTL", 30*time.Second),
+			LikesCleanupInterval:  e.Duration("JOBS_LIKES_CLEANUP_INTERVAL", time.Hour),
+			PassesCleanupInterval: e.Duration("JOBS_PASSES_CLEANUP_INTERVAL", time.Hour),
+			ViewsCleanupInterval:  e.Duration("JOBS_VIEWS_CLEANUP_INTERVAL", time.Hour),
+			StatsRefreshInterval:  e.Duration("JOBS_STATS_REFRESH_INTERVAL", time.Hour),
+			PremiumExpiryInterval: e.Duration("JOBS_PREMIUM_EXPIRY_INTERVAL", 15*time.Minute),
+			LikeRemindersInterval: e.Duration("JOBS_LIKE_REMINDERS_INTERVAL", time.Hour),
+			LikeDigestInterval:    e.Duration("JOBS_LIKE_DIGEST_INTERVAL", 5*time.Minute),
+		},
+		Notify: NotifyConfig{
+			QuietFrom:      e.Int("NOTIFY_QUIET_FROM_HOUR", 23),
+			QuietTo:        e.Int("NOTIFY_QUIET_TO_HOUR", 9),
+			Timezone:       e.String("NOTIFY_TIMEZONE", "Europe/Moscow"),
+			DigestInterval: e.Duration("NOTIFY_DIGEST_INTERVAL", time.Hour),
+		},
+		Outbound: OutboundConfig{
+			RabbitURL:     e.String("RABBITMQ_URL", ""),
+			Queue:         e.String("OUTBOUND_QUEUE", "outbound.messages"),
+			RatePerSecond: e.Int("OUTBOUND_RATE_PER_SECOND", 25),
+			MaxAttempts:   e.Int("OUTBOUND_MAX_ATTEMPTS", 5),
+			BaseBackoff:   e.Duration("OUTBOUND_BASE_BACKOFF", 2*time.Second),
+			MaxBackoff:    e.Duration("OUTBOUND_MAX_BACKOFF", 5*time.Minute),
+		},
+		Events: EventsConfig{
+			Async: e.Bool("EVENTS_ASYNC", false),
+			Queue: e.String("EVENTS_QUEUE", "domain.events"),
+		},
+		AdminUserIDs: e.Int64s("ADMIN_USER_IDS"),
+	}
+
+	problems := append(e.problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return cfg, nil
+}
+
+// Validate проверяет обязательные и взаимозависимые параметры и возвращает
+// ошибку со списком всех найденных проблем.
+func (c AppConfig) Validate() error {
+	if problems := c.validate(); len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func (c AppConfig) validate() []string {
+	var problems []string
+	required := func(key, value string) {
+		if value == "" {
+			problems = append(problems, key+" is required")
+		}
+	}
+	positive := func(key string, value int64) {
+		if value <= 0 {
+			problems = append(problems, key+" must be positive")
+		}
+	}
+
+	required("DB_HOST", c.DBHost)
+	required("DB_PORT", c.DBPort)
+	required("DB_USER", c.DBUser)
+	required("DB_NAME", c.DBName)
+	required("TELEGRAM_BOT_TOKEN", c.TELEGRAM_BOT_TOKEN)
+	required("MINIO_ENDPOINT", c.Minio.Endpoint)
+	required("MINIO_ACCESS_KEY", c.Minio.AccessKey)
+	required("MINIO_SECRET_KEY", c.Minio.SecretKey)
+	required("MINIO_BUCKET", c.Minio.Bucket)
+	required("REDIS_ADDR", c.Redis.Addr)
+
+	positive("DB_MAX_CONNS", int64(c.DBPool.MaxConns))
+	if c.DBPool.MinConns < 0 || c.DBPool.MinConns > c.DBPool.MaxConns {
+		problems = append(problems, "DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+	positive("DB_MAX_CONN_LIFETIME", int64(c.DBPool.MaxConnLifetime))
+	positive("DB_MAX_CONN_IDLE_TIME", int64(c.DBPool.MaxConnIdleTime))
+	if c.Redis.DB < 0 {
+		problems = append(problems, "REDIS_DB must not be negative")
+	}
+
+	switch c.BotMode {
+	case ModePolling:
+		positive("POLLING_TIMEOUT", int64(c.Polling.Timeout))
+		if c.Polling.RequestTimeout <= c.Polling.Timeout {
+			problems = append(problems, "POLLING_REQUEST_TIMEOUT must be greater than POLLING_TIMEOUT")
+		}
+	case ModeWebhook:
+		required("WEBHOOK_LISTEN_ADDR", c.Webhook.ListenAddr)
+		required("WEBHOOK_URL", c.Webhook.URL)
+		required("WEBHOOK_PATH", c.Webhook.Path)
+		// Без секрета любой, кто знает адрес вебхука, может присылать боту
+		// поддельные обновления
+		required("WEBHOOK_SECRET_TOKEN", c.Webhook.SecretToken)
+		if c.Webhook.SecretToken != "" && !webhookSecretPattern.MatchString(c.Webhook.SecretToken) {
+			problems = append(problems, "WEBHOOK_SECRET_TOKEN must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+		if (c.Webhook.CertFile == "") != (c.Webhook.KeyFile == "") {
+			problems = append(problems, "WEBHOOK_CERT_FILE and WEBHOOK_KEY_FILE must be set together")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("BOT_MODE must be %q or %q, got %q", ModePolling, ModeWebhook, c.BotMode))
 	}
+
+	positive("SEARCH_PAGE_SIZE", int64(c.Search.PageSize))
+	positive("SEARCH_SNAPSHOT_SIZE", int64(c.Search.SnapshotSize))
+	positive("LIKES_PAGE_SIZE", int64(c.Search.LikesPageSize))
+	positive("SEARCH_RESULTS_TTL", int64(c.Search.ResultsTTL))
+	positive("SEARCH_SEEN_TTL", int64(c.Search.SeenTTL))
+	positive("SEARCH_PASS_TTL", int64(c.Search.PassTTL))
+	positive("SEARCH_UNDO_WINDOW", int64(c.Search.UndoWindow))
+	positive("LIKE_TTL", int64(c.LikeTTL))
+	positive("LIKE_REMINDER_WINDOW", int64(c.LikeReminderWindow))
+	if c.LikeReminderWindow >= c.LikeTTL {
+		problems = append(problems, "LIKE_REMINDER_WINDOW must be less than LIKE_TTL")
+	}
+	positive("PREMIUM_PRICE_STARS", c.Premium.PriceStars)
+	positive("PREMIUM_DURATION", int64(c.Premium.Duration))
+	positive("RATE_LIMIT_WINDOW", int64(c.RateLimit.Window))
+	positive("RATE_LIMIT_CALLBACKS", int64(c.RateLimit.Callbacks))
+	positive("RATE_LIMIT_MESSAGES", int64(c.RateLimit.Messages))
+	positive("RATE_LIMIT_LIKES", int64(c.RateLimit.Likes))
+	positive("DAILY_LIKE_QUOTA", int64(c.RateLimit.DailyLikes))
+	if c.Rating.NewFormulaPercent < 0 || c.Rating.NewFormulaPercent > 100 {
+		problems = append(problems, "RATING_NEW_FORMULA_PERCENT must be between 0 and 100")
+	}
+	positive("RATING_RECOMPUTE_INTERVAL", int64(c.Rating.RecomputeInterval))
+	positive("RATING_BATCH_SIZE", int64(c.Rating.BatchSize))
+	positive("JOBS_LEASE_TTL", int64(c.Jobs.LeaseTTL))
+	positive("JOBS_LIKES_CLEANUP_INTERVAL", int64(c.Jobs.LikesCleanupInterval))
+	positive("JOBS_PASSES_CLEANUP_INTERVAL", int64(c.Jobs.PassesCleanupInterval))
+	positive("JOBS_VIEWS_CLEANUP_INTERVAL", int64(c.Jobs.ViewsCleanupInterval))
+	positive("JOBS_STATS_REFRESH_INTERVAL", int64(c.Jobs.StatsRefreshInterval))
+	positive("JOBS_PREMIUM_EXPIRY_INTERVAL", int64(c.Jobs.PremiumExpiryInterval))
+	positive("JOBS_LIKE_REMINDERS_INTERVAL", int64(c.Jobs.LikeRemindersInterval))
+	positive("JOBS_LIKE_DIGEST_INTERVAL", int64(c.Jobs.LikeDigestInterval))
+	if c.Notify.QuietFrom < 0 || c.Notify.QuietFrom > 23 {
+		problems = append(problems, "NOTIFY_QUIET_FROM_HOUR must be between 0 and 23")
+	}
+	if c.Notify.QuietTo < 0 || c.Notify.QuietTo > 23 {
+		problems = append(problems, "NOTIFY_QUIET_TO_HOUR must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(c.Notify.Timezone); err != nil {
+		problems = append(problems, fmt.Sprintf("NOTIFY_TIMEZONE is not a valid time zone: %q", c.Notify.Timezone))
+	}
+	positive("NOTIFY_DIGEST_INTERVAL", int64(c.Notify.DigestInterval))
+	required("OUTBOUND_QUEUE", c.Outbound.Queue)
+	required("EVENTS_QUEUE", c.Events.Queue)
+	positive("OUTBOUND_RATE_PER_SECOND", int64(c.Outbound.RatePerSecond))
+	positive("OUTBOUND_MAX_ATTEMPTS", int64(c.Outbound.MaxAttempts))
+	positive("OUTBOUND_BASE_BACKOFF", int64(c.Outbound.BaseBackoff))
+	if c.Outbound.MaxBackoff < c.Outbound.BaseBackoff {
+		problems = append(problems, "OUTBOUND_MAX_BACKOFF must not be less than OUTBOUND_BASE_BACKOFF")
+	}
+
+	return problems
 }
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhookSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		problem string
+	}{
+		{name: "missing", secret: "", problem: "WEBHOOK_SECRET_TOKEN is required"},
+		{name: "invalid characters", secret: "not a token!", problem: "WEBHOOK_SECRET_TOKEN must be 1-256 characters of A-Z, a-z, 0-9, _ and -"},
+		{name: "valid", secret: "s3cret_token-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := AppConfig{
+				BotMode: ModeWebhook,
+				Webhook: WebhookConfig{
+					ListenAddr:  "127.0.0.1:8080",
+					URL:         "https://bot.example.com",
+					Path:        "webhook",
+					SecretToken: tt.secret,
+				},
+			}
+			problems := cfg.validate()
+			hasSecretProblem := slices.ContainsFunc(problems, func(p string) bool {
+				return strings.HasPrefix(p, "WEBHOOK_SECRET_TOKEN")
+			})
+			if tt.problem == "" {
+				if hasSecretProblem {
+					t.Errorf("unexpected secret problem in %v", problems)
+				}
+				return
+			}
+			if !slices.Contains(problems, tt.problem) {
+				t.Errorf("problems = %v, want %q", problems, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidatePollingDoesNotNeedWebhookSecret(t *testing.T) {
+	cfg := AppConfig{BotMode: ModePolling}
+	if problems := cfg.validate(); slices.Contains(problems, "WEBHOOK_SECRET_TOKEN is required") {
+		t.Fatal("webhook secret required in polling mode")
+	}
+}
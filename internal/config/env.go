@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// env читает типизированные значения из окружения, подставляя значения по
+// умолчанию для пустых переменных и накапливая ошибки разбора.
+type env struct {
+	problems []string
+}
+
+func (e *env) String(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (e *env) Int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be an integer, got %q", key, v))
+		return def
+	}
+	return n
+}
+
+func (e *env) Bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a boolean, got %q", key, v))
+		return def
+	}
+	return b
+}
+
+// Duration принимает значения в формате time.ParseDuration ("30m", "72h").
+func (e *env) Duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a duration like 30m or 72h, got %q", key, v))
+		return def
+	}
+	return d
+}
+
+// Int64s разбирает список чисел через запятую ("1,2,3").
+func (e *env) Int64s(key string) []int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	var values []int64
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			e.problems = append(e.problems, fmt.Sprintf("%s must be a comma-separated list of integers, got %q", key, v))
+			return nil
+		}
+		values = append(values, n)
+	}
+	return values
+}
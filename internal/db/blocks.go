@@ -43,6 +43,7 @@ func (b *Block) columns(pref string) []string {
 type BlockQuery interface {
 	GetByID(ctx context.Context, id int64) (*Block, error)
 	GetAllByBlockerID(ctx context.Context, blockerID int64) ([]*Block, error)
+	GetBlockedUsers(ctx context.Context, blockerID int64, offset, limit uint64) ([]*User, error)
 	Insert(ctx context.Context, block *Block) (*Block, error)
 	Delete(ctx context.Context, block *Block) error
 }
@@ -128,6 +129,51 @@ func (b blockQuery) GetAllByBlockerID(ctx context.Context, blockerID int64) ([]*
 	return blocks, nil
 }
 
+func (b blockQuery) GetBlockedUsers(ctx context.Context, blockerID int64, offset, limit uint64) ([]*User, error) {
+	b.logger.Debug("Fetching blocked users",
+		zap.Int64("blocker_id", blockerID),
+		zap.Uint64("offset", offset),
+		zap.Uint64("limit", limit))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var users []*User
+	qb := b.sq.Select((&User{}).columns("u")...).
+		From(BlocksTable + " bl").
+		InnerJoin(UsersTable + " u ON bl.blocked_id = u.id").
+		Where(squirrel.Eq{"bl.blocker_id": blockerID}).
+		OrderBy("bl.created_at DESC").
+		Limit(limit).
+		Offset(offset)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		b.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = pgxscan.Select(ctx, b.runner, &users, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			b.logger.Warn("Database error",
+				zap.Int64("blocker_id", blockerID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			b.logger.Error("Failed to fetch blocked users",
+				zap.Int64("blocker_id", blockerID),
+				zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	b.logger.Info("Blocked users fetched successfully",
+		zap.Int64("blocker_id", blockerID),
+		zap.Int("count", len(users)))
+	return users, nil
+}
+
 func (b blockQuery) Insert(ctx context.Context, block *Block) (*Block, error) {
 	b.logger.Debug("Inserting block",
 		zap.Int64("blocker_id", block.BlockerID),
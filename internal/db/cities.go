@@ -18,15 +18,17 @@ import (
 const Citiestable = "cities"
 
 const (
-	CitiesID       = "id"
-	CitiesName     = "name"
-	CitiesLocation = "location"
+	CitiesID         = "id"
+	CitiesName       = "name"
+	CitiesLocation   = "location"
+	CitiesPopulation = "population"
 )
 
 type City struct {
-	ID       int64  `db:"id" insert:"id"`
-	Name     string `db:"name" insert:"name" update:"name"`
-	Location string `db:"location" insert:"location" update:"location"`
+	ID         int64  `db:"id" insert:"id"`
+	Name       string `db:"name" insert:"name" update:"name"`
+	Location   string `db:"location" insert:"location" update:"location"`
+	Population int64  `db:"population" insert:"population" update:"population"`
 }
 
 var (
@@ -42,6 +44,8 @@ func (c *City) columns(pref string) []string {
 type CityQuery interface {
 	GetByID(ctx context.Context, id int64) (*City, error)
 	GetIDByName(ctx context.Context, name string) (int64, error)
+	SearchByName(ctx context.Context, query string, limit uint64) ([]*City, error)
+	GetNearest(ctx context.Context, point Point) (*City, error)
 	Insert(ctx context.Context, city *City) (*City, error)
 }
 
@@ -124,6 +128,82 @@ func (c cityQuery) GetIDByName(ctx context.Context, name string) (int64, error)
 	return cityID, nil
 }
 
+// SearchByName ищет города по нечёткому совпадению названия (pg_trgm).
+// Точное совпадение идёт первым, близкие по сходству варианты упорядочены
+// по численности населения.
+func (c cityQuery) SearchByName(ctx context.Context, query string, limit uint64) ([]*City, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	c.logger.Debug("Searching cities by name", zap.String("query", query), zap.Uint64("limit", limit))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var cities []*City
+	qb, args, err := c.sq.Select((&City{}).columns("")...).
+		From(Citiestable).
+		Where(squirrel.Or{
+			squirrel.Expr("name % ?", query),
+			squirrel.Like{CitiesName: escapeLike(query) + "%"},
+		}).
+		OrderByClause("name = ? DESC", query).
+		OrderByClause("round(similarity(name, ?)::numeric, 1) DESC", query).
+		OrderBy(CitiesPopulation + " DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		c.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	err = pgxscan.Select(ctx, c.runner, &cities, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			c.logger.Warn("Database error",
+				zap.String("query", query),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			c.logger.Warn("Failed to search cities", zap.String("query", query), zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	c.logger.Info("Cities found", zap.String("query", query), zap.Int("count", len(cities)))
+	return cities, nil
+}
+
+// GetNearest находит ближайший к точке город (KNN по idx_cities_location).
+func (c cityQuery) GetNearest(ctx context.Context, point Point) (*City, error) {
+	c.logger.Debug("Fetching nearest city")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	city := &City{}
+	qb, args, err := c.sq.Select(city.columns("")...).
+		From(Citiestable).
+		OrderByClause("location <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography", point.Longitude, point.Latitude).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		c.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	err = pgxscan.Get(ctx, c.runner, city, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			c.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			c.logger.Warn("Failed to fetch nearest city", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	c.logger.Info("Nearest city fetched successfully", zap.Int64("city_id", city.ID))
+	return city, nil
+}
+
 func (c cityQuery) Insert(ctx context.Context, city *City) (*City, error) {
 	c.logger.Debug("Inserting city", zap.String("name", city.Name))
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -28,10 +28,10 @@ func InitDB(cfg config.AppConfig, logger *zap.Logger) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	poolConfig.MaxConns = 20
-	poolConfig.MinConns = 2
-	poolConfig.MaxConnLifetime = 30 * time.Minute
-	poolConfig.MaxConnIdleTime = 5 * time.Minute
+	poolConfig.MaxConns = cfg.DBPool.MaxConns
+	poolConfig.MinConns = cfg.DBPool.MinConns
+	poolConfig.MaxConnLifetime = cfg.DBPool.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DBPool.MaxConnIdleTime
 	poolConfig.HealthCheckPeriod = 1 * time.Minute
 
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Тесты запросов идут против настоящего Postgres с PostGIS и применёнными
+// миграциями (make docker-up && make migrate-up). Без TEST_PG_DB_DSN они
+// пропускаются.
+const testDSNEnv = "TEST_PG_DB_DSN"
+
+// Данные тестов живут в диапазоне id, который не пересекается с Telegram id
+// реальных пользователей, и удаляются после каждого теста.
+const (
+	testCityID    = 900001
+	testUserIDMin = 900000001
+	testUserIDMax = 900000999
+)
+
+type testDB struct {
+	pool   *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func newTestDB(t *testing.T) *testDB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv + " is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	tdb := &testDB{
+		pool:   pool,
+		sq:     squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger: zap.NewNop(),
+	}
+	tdb.cleanup(t)
+	t.Cleanup(func() {
+		tdb.cleanup(t)
+		pool.Close()
+	})
+	if _, err := NewCityQuery(pool, tdb.sq, tdb.logger).Insert(context.Background(), &City{
+		ID:       testCityID,
+		Name:     "тестовск",
+		Location: "POINT(37.62 55.75)",
+	}); err != nil {
+		t.Fatalf("insert city: %v", err)
+	}
+	return tdb
+}
+
+func (d *testDB) cleanup(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := d.pool.Exec(ctx, "DELETE FROM users WHERE id BETWEEN $1 AND $2", testUserIDMin, testUserIDMax); err != nil {
+		t.Fatalf("cleanup users: %v", err)
+	}
+	if _, err := d.pool.Exec(ctx, "DELETE FROM cities WHERE id = $1", testCityID); err != nil {
+		t.Fatalf("cleanup cities: %v", err)
+	}
+}
+
+// addUser создаёт пользователя в тестовом городе вместе с настройками
+// поиска по умолчанию.
+func (d *testDB) addUser(t *testing.T, id int64, gender string, age int) {
+	t.Helper()
+	ctx := context.Background()
+	cityID := int64(testCityID)
+	if _, err := NewUserQuery(d.pool, d.sq, d.logger).Insert(ctx, &User{
+		ID:         id,
+		TgUsername: "test",
+		Gender:     gender,
+		Age:        age,
+		CityID:     &cityID,
+	}); err != nil {
+		t.Fatalf("insert user %d: %v", id, err)
+	}
+	if _, err := NewUserPreferencesQuery(d.pool, d.sq, d.logger).Insert(ctx, id); err != nil {
+		t.Fatalf("insert preferences %d: %v", id, err)
+	}
+}
@@ -23,15 +23,36 @@ const (
 	LikesMessage    = "message"
 	LikesCreatedAt  = "created_at"
 	LikesExpiresAt  = "expires_at"
+	// LikesExpiryRemindedAt — когда получателю напомнили, что лайк скоро истечёт
+	LikesExpiryRemindedAt = "expiry_reminded_at"
 )
 
 type Like struct {
-	ID         int64     `db:"id"`
-	FromUserID int64     `db:"from_user_id" insert:"from_user_id" delete:"from_user_id"`
-	ToUserID   int64     `db:"to_user_id" insert:"to_user_id" delete:"to_user_id"`
-	Message    *string   `db:"message" insert:"message"`
-	CreatedAt  time.Time `db:"created_at"`
-	ExpiresAt  time.Time `db:"expires_at"`
+	ID               int64      `db:"id"`
+	FromUserID       int64      `db:"from_user_id" insert:"from_user_id" delete:"from_user_id"`
+	ToUserID         int64      `db:"to_user_id" insert:"to_user_id" delete:"to_user_id"`
+	Message          *string    `db:"message" insert:"message"`
+	CreatedAt        time.Time  `db:"created_at"`
+	ExpiresAt        time.Time  `db:"expires_at"`
+	ExpiryRemindedAt *time.Time `db:"expiry_reminded_at"`
+}
+
+// ExpiringLikes — число действующих лайков пользователя, истекающих в
+// ближайшее время.
+type ExpiringLikes struct {
+	UserID int64 `db:"user_id"`
+	Count  int   `db:"count"`
+	// ClaimedAt — отметка, которой помечены лайки; по ней отметку можно снять
+	ClaimedAt time.Time `db:"claimed_at"`
+}
+
+// LikeProfile — профиль пользователя, поставившего лайк, вместе с
+// приложенным к лайку сообщением и расстоянием до него. DistanceKm пуст,
+// если у кого-то из двоих не указан город.
+type LikeProfile struct {
+	User
+	LikeMessage *string  `db:"like_message"`
+	DistanceKm  *float64 `db:"distance_km"`
 }
 
 var (
@@ -47,7 +68,11 @@ func (l *Like) columns(pref string) []string {
 type LikeQuery interface {
 	GetByID(ctx context.Context, id int64) (*Like, error)
 	GetAllByToUserID(ctx context.Context, userID int64) ([]*Like, error)
-	GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64, offset, limit uint64) ([]*User, error)
+	GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64, offset, limit uint64) ([]*LikeProfile, error)
+	CountByToUserID(ctx context.Context, toUserID int64) (int, error)
+	DeleteExpired(ctx context.Context) ([]int64, error)
+	ClaimExpiring(ctx context.Context, within time.Duration) ([]*ExpiringLikes, error)
+	ReleaseExpiring(ctx context.Context, toUserID int64, claimedAt time.Time) error
 	Insert(ctx context.Context, like *Like) (*Like, error)
 	Delete(ctx context.Context, like *Like) error
 	DeleteByIDs(ctx context.Context, fromUserID, toUserID int64) error
@@ -134,7 +159,7 @@ func (l likeQuery) GetAllByToUserID(ctx context.Context, userID int64) ([]*Like,
 	return likes, nil
 }
 
-func (l likeQuery) GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64, offset, limit uint64) ([]*User, error) {
+func (l likeQuery) GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64, offset, limit uint64) ([]*LikeProfile, error) {
 	l.logger.Debug("Fetching users who liked",
 		zap.Int64("to_user_id", toUserID),
 		zap.Uint64("offset", offset),
@@ -142,10 +167,15 @@ func (l likeQuery) GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var users []*User
+	var users []*LikeProfile
 	qb := l.sq.Select((&User{}).columns("u")...).
+		Column("l.message AS like_message").
+		Column(userDistanceExpr + " / 1000 AS distance_km").
 		From(LikesTable + " l").
 		InnerJoin(UsersTable + " u ON l.from_user_id = u.id").
+		LeftJoin(Citiestable + " c ON u.city_id = c.id").
+		InnerJoin(UsersTable + " u_own ON u_own.id = l.to_user_id").
+		LeftJoin(Citiestable + " c_own ON u_own.city_id = c_own.id").
 		Where(squirrel.Eq{"l.to_user_id": toUserID}).
 		Where(squirrel.Expr("l.expires_at > NOW()")).
 		OrderBy("l.created_at DESC").
@@ -180,6 +210,40 @@ func (l likeQuery) GetAllByToUserIDWithUsers(ctx context.Context, toUserID int64
 	return users, nil
 }
 
+// CountByToUserID возвращает число действующих лайков, полученных пользователем.
+func (l likeQuery) CountByToUserID(ctx context.Context, toUserID int64) (int, error) {
+	l.logger.Debug("Counting likes by to_user_id", zap.Int64("to_user_id", toUserID))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := l.sq.Select("COUNT(*)").
+		From(LikesTable).
+		Where(squirrel.Eq{LikesToUserID: toUserID}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		ToSql()
+	if err != nil {
+		l.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := l.runner.QueryRow(ctx, qb, args...).Scan(&count); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			l.logger.Warn("Database error",
+				zap.Int64("to_user_id", toUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			l.logger.Error("Failed to count likes",
+				zap.Int64("to_user_id", toUserID),
+				zap.Error(err))
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return count, nil
+}
+
 func (l likeQuery) Insert(ctx context.Context, like *Like) (*Like, error) {
 	l.logger.Debug("Inserting like",
 		zap.Int64("from_user_id", like.FromUserID),
@@ -315,3 +379,117 @@ func (l likeQuery) DeleteByIDs(ctx context.Context, fromUserID, toUserID int64)
 	)
 	return nil
 }
+
+// DeleteExpired удаляет истёкшие лайки и возвращает id пользователей,
+// которые их получили, без повторов.
+func (l likeQuery) DeleteExpired(ctx context.Context) ([]int64, error) {
+	l.logger.Debug("Deleting expired likes")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	qb, args, err := l.sq.Delete(LikesTable).
+		Where(squirrel.Expr(LikesExpiresAt + " <= now()")).
+		Suffix("RETURNING " + LikesToUserID).
+		ToSql()
+	if err != nil {
+		l.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var recipients []int64
+	err = pgxscan.Select(ctx, l.runner, &recipients,
+		"WITH deleted AS ("+qb+") SELECT DISTINCT "+LikesToUserID+" FROM deleted", args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			l.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			l.logger.Error("Failed to delete expired likes", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return recipients, nil
+}
+
+// ClaimExpiring отмечает как напомненные лайки активным пользователям,
+// истекающие в течение within, и возвращает получателей с числом всех их
+// истекающих лайков. Каждый лайк попадает в выборку не больше одного раза.
+func (l likeQuery) ClaimExpiring(ctx context.Context, within time.Duration) ([]*ExpiringLikes, error) {
+	l.logger.Debug("Claiming expiring likes", zap.Duration("within", within))
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	expiringSoon := squirrel.Expr(LikesExpiresAt+" > now() AND "+LikesExpiresAt+" <= now() + make_interval(secs => ?)", within.Seconds())
+	// Подзапрос собирается с "?", нумерацию $n выполняет внешний запрос
+	claim := l.sq.PlaceholderFormat(squirrel.Question).Update(LikesTable).
+		Set(LikesExpiryRemindedAt, squirrel.Expr("now()")).
+		Where(expiringSoon).
+		Where(squirrel.Eq{LikesExpiryRemindedAt: nil}).
+		Where(squirrel.Expr(LikesToUserID + " IN (SELECT id FROM " + UsersTable + " WHERE is_active)")).
+		Suffix("RETURNING " + LikesToUserID)
+	query, args, err := l.sq.Select(LikesToUserID+" AS user_id", "COUNT(*) AS count", "now() AS claimed_at").
+		PrefixExpr(squirrel.Expr("WITH claimed AS (?)", claim)).
+		From(LikesTable).
+		Where(squirrel.Expr(LikesToUserID + " IN (SELECT " + LikesToUserID + " FROM claimed)")).
+		Where(expiringSoon).
+		GroupBy(LikesToUserID).
+		ToSql()
+	if err != nil {
+		l.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var expiring []*ExpiringLikes
+	if err := pgxscan.Select(ctx, l.runner, &expiring, query, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			l.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			l.logger.Error("Failed to claim expiring likes", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return expiring, nil
+}
+
+// ReleaseExpiring снимает отметку о напоминании с лайков получателя,
+// помеченных в ClaimExpiring отметкой claimedAt, чтобы следующий запуск
+// напомнил о них снова.
+func (l likeQuery) ReleaseExpiring(ctx context.Context, toUserID int64, claimedAt time.Time) error {
+	l.logger.Debug("Releasing expiring likes", zap.Int64("to_user_id", toUserID))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := l.sq.Update(LikesTable).
+		Set(LikesExpiryRemindedAt, nil).
+		Where(squirrel.Eq{
+			LikesToUserID:         toUserID,
+			LikesExpiryRemindedAt: claimedAt,
+		}).
+		ToSql()
+	if err != nil {
+		l.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := l.runner.Exec(ctx, qb, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			l.logger.Warn("Database error",
+				zap.Int64("to_user_id", toUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			l.logger.Error("Failed to release expiring likes",
+				zap.Int64("to_user_id", toUserID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/elgris/stom"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const MatchesTable = "matches"
+
+const (
+	MatchesID        = "id"
+	MatchesUser1ID   = "user1_id"
+	MatchesUser2ID   = "user2_id"
+	MatchesCreatedAt = "created_at"
+)
+
+// Match — взаимная симпатия. Пара хранится упорядоченно: User1ID < User2ID.
+type Match struct {
+	ID        int64     `db:"id"`
+	User1ID   int64     `db:"user1_id" insert:"user1_id"`
+	User2ID   int64     `db:"user2_id" insert:"user2_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+var (
+	stomMatchSelect = stom.MustNewStom(Match{}).SetTag(selectTag)
+	stomMatchInsert = stom.MustNewStom(Match{}).SetTag(insertTag)
+)
+
+func (m *Match) columns(pref string) []string {
+	return colNamesWithPref(stomMatchSelect.TagValues(), pref)
+}
+
+// NewMatch создаёт пару с упорядоченными идентификаторами.
+func NewMatch(userID1, userID2 int64) *Match {
+	if userID1 > userID2 {
+		userID1, userID2 = userID2, userID1
+	}
+	return &Match{User1ID: userID1, User2ID: userID2}
+}
+
+type MatchQuery interface {
+	Insert(ctx context.Context, match *Match) (*Match, error)
+	ListByUser(ctx context.Context, userID int64, offset, limit uint64) ([]*User, error)
+	Delete(ctx context.Context, userID1, userID2 int64) error
+}
+
+type matchQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewMatchQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) MatchQuery {
+	return &matchQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+func (m matchQuery) Insert(ctx context.Context, match *Match) (*Match, error) {
+	m.logger.Debug("Inserting match",
+		zap.Int64("user1_id", match.User1ID),
+		zap.Int64("user2_id", match.User2ID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertMap, err := stomMatchInsert.ToMap(match)
+	if err != nil {
+		m.logger.Error("Failed to map struct", zap.Error(err))
+		return nil, fmt.Errorf("failed to map struct: %w", err)
+	}
+	qb, args, err := m.sq.Insert(MatchesTable).
+		SetMap(insertMap).
+		Suffix("RETURNING " + MatchesID + ", " + MatchesCreatedAt).
+		ToSql()
+	if err != nil {
+		m.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	err = m.runner.QueryRow(ctx, qb, args...).Scan(&match.ID, &match.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			m.logger.Warn("Database error",
+				zap.Int64("user1_id", match.User1ID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			m.logger.Error("Failed to insert match",
+				zap.Int64("user1_id", match.User1ID),
+				zap.Error(err),
+			)
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	m.logger.Info("Match inserted successfully", zap.Int64("match_id", match.ID))
+	return match, nil
+}
+
+func (m matchQuery) ListByUser(ctx context.Context, userID int64, offset, limit uint64) ([]*User, error) {
+	m.logger.Debug("Fetching matches",
+		zap.Int64("user_id", userID),
+		zap.Uint64("offset", offset),
+		zap.Uint64("limit", limit))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var users []*User
+	qb := m.sq.Select((&User{}).columns("u")...).
+		From(MatchesTable+" m").
+		InnerJoin(UsersTable+" u ON u.id = CASE WHEN m.user1_id = ? THEN m.user2_id ELSE m.user1_id END", userID).
+		Where(squirrel.Or{
+			squirrel.Eq{"m.user1_id": userID},
+			squirrel.Eq{"m.user2_id": userID},
+		}).
+		OrderBy("m.created_at DESC, m.id DESC").
+		Limit(limit).
+		Offset(offset)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		m.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = pgxscan.Select(ctx, m.runner, &users, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			m.logger.Warn("Database error",
+				zap.Int64("user_id", userID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			m.logger.Error("Failed to fetch matches",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	m.logger.Info("Matches fetched successfully",
+		zap.Int64("user_id", userID),
+		zap.Int("count", len(users)))
+	return users, nil
+}
+
+func (m matchQuery) Delete(ctx context.Context, userID1, userID2 int64) error {
+	match := NewMatch(userID1, userID2)
+	m.logger.Debug("Deleting match",
+		zap.Int64("user1_id", match.User1ID),
+		zap.Int64("user2_id", match.User2ID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := m.sq.Delete(MatchesTable).
+		Where(squirrel.Eq{
+			MatchesUser1ID: match.User1ID,
+			MatchesUser2ID: match.User2ID,
+		}).
+		ToSql()
+	if err != nil {
+		m.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := m.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			m.logger.Warn("Database error",
+				zap.Int64("user1_id", match.User1ID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			m.logger.Error("Failed to delete match",
+				zap.Int64("user1_id", match.User1ID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		m.logger.Warn("No match found to delete",
+			zap.Int64("user1_id", match.User1ID),
+			zap.Int64("user2_id", match.User2ID))
+	}
+	m.logger.Info("Match deleted successfully",
+		zap.Int64("user1_id", match.User1ID),
+		zap.Int64("user2_id", match.User2ID),
+	)
+	return nil
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const PassesTable = "passes"
+
+const (
+	PassesID         = "id"
+	PassesFromUserID = "from_user_id"
+	PassesToUserID   = "to_user_id"
+	PassesCreatedAt  = "created_at"
+	PassesExpiresAt  = "expires_at"
+)
+
+// Pass — пропущенная (дизлайкнутая) анкета. До ExpiresAt она не
+// показывается в поиске.
+type Pass struct {
+	ID         int64     `db:"id"`
+	FromUserID int64     `db:"from_user_id"`
+	ToUserID   int64     `db:"to_user_id"`
+	CreatedAt  time.Time `db:"created_at"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+type PassQuery interface {
+	Insert(ctx context.Context, fromUserID, toUserID int64, ttl time.Duration) error
+	Delete(ctx context.Context, fromUserID, toUserID int64) error
+	DeleteAllByUser(ctx context.Context, fromUserID int64) (int64, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type passQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewPassQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) PassQuery {
+	return &passQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+// Insert сохраняет пропуск на ttl; повторный пропуск продлевает срок.
+func (p passQuery) Insert(ctx context.Context, fromUserID, toUserID int64, ttl time.Duration) error {
+	p.logger.Debug("Inserting pass",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := p.sq.Insert(PassesTable).
+		Columns(PassesFromUserID, PassesToUserID, PassesExpiresAt).
+		Values(fromUserID, toUserID, squirrel.Expr("now() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix(`ON CONFLICT (from_user_id, to_user_id) DO UPDATE
+			SET created_at = now(),
+			    expires_at = EXCLUDED.expires_at`).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = p.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to insert pass",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	p.logger.Info("Pass inserted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	return nil
+}
+
+func (p passQuery) Delete(ctx context.Context, fromUserID, toUserID int64) error {
+	p.logger.Debug("Deleting pass",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := p.sq.Delete(PassesTable).
+		Where(squirrel.Eq{
+			PassesFromUserID: fromUserID,
+			PassesToUserID:   toUserID,
+		}).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = p.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to delete pass",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	p.logger.Info("Pass deleted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	return nil
+}
+
+// DeleteAllByUser удаляет все пропуски пользователя и возвращает их число.
+func (p passQuery) DeleteAllByUser(ctx context.Context, fromUserID int64) (int64, error) {
+	p.logger.Debug("Deleting all passes", zap.Int64("from_user_id", fromUserID))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := p.sq.Delete(PassesTable).
+		Where(squirrel.Eq{PassesFromUserID: fromUserID}).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := p.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to delete passes",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	p.logger.Info("Passes deleted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("count", result.RowsAffected()),
+	)
+	return result.RowsAffected(), nil
+}
+
+// DeleteExpired удаляет истёкшие пропуски и возвращает их число.
+func (p passQuery) DeleteExpired(ctx context.Context) (int64, error) {
+	p.logger.Debug("Deleting expired passes")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	qb, args, err := p.sq.Delete(PassesTable).
+		Where(squirrel.Expr(PassesExpiresAt + " <= now()")).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := p.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to delete expired passes", zap.Error(err))
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
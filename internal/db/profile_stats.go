@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const ProfileStatsTable = "profile_stats"
+
+const (
+	ProfileStatsUserID        = "user_id"
+	ProfileStatsViews         = "views"
+	ProfileStatsLikesReceived = "likes_received"
+	ProfileStatsUpdatedAt     = "updated_at"
+)
+
+// ScoringInput — данные анкеты, по которым считается её место в поиске.
+type ScoringInput struct {
+	UserID        int64     `db:"user_id"`
+	Views         int64     `db:"views"`
+	LikesReceived int64     `db:"likes_received"`
+	ActiveLikes   int64     `db:"active_likes"`
+	HasPhoto      bool      `db:"has_photo"`
+	HasBio        bool      `db:"has_bio"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// ScoreUpdate — новые значения rating (старая формула) и score (новая).
+type ScoreUpdate struct {
+	UserID int64
+	Rating int
+	Score  int
+}
+
+type ProfileStatsQuery interface {
+	IncrementViews(ctx context.Context, userID int64) error
+	AddLikes(ctx context.Context, userID int64, delta int64) error
+	ListScoringInputs(ctx context.Context, afterUserID int64, limit uint64) ([]*ScoringInput, error)
+	UpdateScores(ctx context.Context, updates []ScoreUpdate) (int64, error)
+}
+
+type profileStatsQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewProfileStatsQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) ProfileStatsQuery {
+	return &profileStatsQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+// IncrementViews засчитывает показ анкеты в поиске.
+func (p profileStatsQuery) IncrementViews(ctx context.Context, userID int64) error {
+	return p.add(ctx, userID, ProfileStatsViews, 1)
+}
+
+// AddLikes изменяет счётчик полученных лайков; отрицательный delta
+// используется при отмене лайка.
+func (p profileStatsQuery) AddLikes(ctx context.Context, userID int64, delta int64) error {
+	return p.add(ctx, userID, ProfileStatsLikesReceived, delta)
+}
+
+func (p profileStatsQuery) add(ctx context.Context, userID int64, column string, delta int64) error {
+	p.logger.Debug("Updating profile stats",
+		zap.Int64("user_id", userID),
+		zap.String("column", column),
+		zap.Int64("delta", delta),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := p.sq.Insert(ProfileStatsTable).
+		Columns(ProfileStatsUserID, column).
+		Values(userID, squirrel.Expr("GREATEST(?::bigint, 0)", delta)).
+		Suffix(fmt.Sprintf(`ON CONFLICT (user_id) DO UPDATE
+			SET %[1]s = GREATEST(%[2]s.%[1]s + ?::bigint, 0),
+			    updated_at = now()`, column, ProfileStatsTable), delta).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = p.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.Int64("user_id", userID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to update profile stats",
+				zap.Int64("user_id", userID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
+
+// ListScoringInputs возвращает данные для пересчёта активных анкет с id
+// больше afterUserID, по возрастанию id.
+func (p profileStatsQuery) ListScoringInputs(ctx context.Context, afterUserID int64, limit uint64) ([]*ScoringInput, error) {
+	p.logger.Debug("Listing scoring inputs",
+		zap.Int64("after_user_id", afterUserID),
+		zap.Uint64("limit", limit),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var inputs []*ScoringInput
+	qb, args, err := p.sq.Select(
+		"u.id AS user_id",
+		"COALESCE(s.views, 0) AS views",
+		"COALESCE(s.likes_received, 0) AS likes_received",
+		"(SELECT COUNT(*) FROM likes l WHERE l.to_user_id = u.id AND l.expires_at > now()) AS active_likes",
+		"u.profile_photo_url IS NOT NULL AS has_photo",
+		"COALESCE(u.bio, '') <> '' AS has_bio",
+		"u.created_at",
+	).
+		From(UsersTable + " u").
+		LeftJoin(ProfileStatsTable + " s ON s.user_id = u.id").
+		Where(squirrel.Eq{"u.is_active": true}).
+		Where(squirrel.Gt{"u.id": afterUserID}).
+		OrderBy("u.id").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		p.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = pgxscan.Select(ctx, p.runner, &inputs, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to list scoring inputs", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return inputs, nil
+}
+
+// UpdateScores записывает пересчитанные rating и score одним запросом и
+// возвращает число изменившихся анкет.
+func (p profileStatsQuery) UpdateScores(ctx context.Context, updates []ScoreUpdate) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	p.logger.Debug("Updating scores", zap.Int("count", len(updates)))
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ids := make([]int64, len(updates))
+	ratings := make([]int32, len(updates))
+	scores := make([]int32, len(updates))
+	for i, upd := range updates {
+		ids[i] = upd.UserID
+		ratings[i] = int32(upd.Rating)
+		scores[i] = int32(upd.Score)
+	}
+
+	tag, err := p.runner.Exec(ctx, `
+		UPDATE users u
+		SET rating = v.rating, score = v.score
+		FROM unnest($1::bigint[], $2::integer[], $3::integer[]) AS v(id, rating, score)
+		WHERE u.id = v.id
+		  AND (u.rating, u.score) IS DISTINCT FROM (v.rating, v.score)`,
+		ids, ratings, scores)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			p.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			p.logger.Error("Failed to update scores", zap.Error(err))
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
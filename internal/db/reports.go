@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const ReportsTable = "reports"
+
+const (
+	ReportsID         = "id"
+	ReportsReporterID = "reporter_id"
+	ReportsReportedID = "reported_id"
+	ReportsCreatedAt  = "created_at"
+	ReportsResolvedAt = "resolved_at"
+)
+
+// ReportSummary — открытые жалобы на одного пользователя.
+type ReportSummary struct {
+	ReportedID   int64     `db:"reported_id"`
+	Count        int       `db:"count"`
+	LastReportAt time.Time `db:"last_report_at"`
+}
+
+type ReportQuery interface {
+	// Insert сохраняет жалобу; повторная жалоба на того же пользователя
+	// снова открывает её.
+	Insert(ctx context.Context, reporterID, reportedID int64) error
+	// ListOpen возвращает пользователей с нерассмотренными жалобами, начиная
+	// с самых частых.
+	ListOpen(ctx context.Context, limit uint64) ([]*ReportSummary, error)
+	// Resolve закрывает все открытые жалобы на пользователя.
+	Resolve(ctx context.Context, reportedID int64) (int64, error)
+}
+
+type reportQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewReportQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) ReportQuery {
+	return &reportQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+func (r reportQuery) Insert(ctx context.Context, reporterID, reportedID int64) error {
+	r.logger.Debug("Inserting report",
+		zap.Int64("reporter_id", reporterID),
+		zap.Int64("reported_id", reportedID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := r.sq.Insert(ReportsTable).
+		Columns(ReportsReporterID, ReportsReportedID).
+		Values(reporterID, reportedID).
+		Suffix(`ON CONFLICT (reporter_id, reported_id) DO UPDATE
+			SET created_at = now(),
+			    resolved_at = NULL`).
+		ToSql()
+	if err != nil {
+		r.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = r.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			r.logger.Warn("Database error",
+				zap.Int64("reporter_id", reporterID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			r.logger.Error("Failed to insert report",
+				zap.Int64("reporter_id", reporterID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	r.logger.Info("Report inserted successfully",
+		zap.Int64("reporter_id", reporterID),
+		zap.Int64("reported_id", reportedID),
+	)
+	return nil
+}
+
+func (r reportQuery) ListOpen(ctx context.Context, limit uint64) ([]*ReportSummary, error) {
+	r.logger.Debug("Fetching open reports", zap.Uint64("limit", limit))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var reports []*ReportSummary
+	qb, args, err := r.sq.Select(ReportsReportedID, "count(*) AS count", "max(created_at) AS last_report_at").
+		From(ReportsTable).
+		Where(squirrel.Eq{ReportsResolvedAt: nil}).
+		GroupBy(ReportsReportedID).
+		OrderBy("count DESC", "last_report_at DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		r.logger.Error("Failed to build query", zap.Error(err))
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	err = pgxscan.Select(ctx, r.runner, &reports, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			r.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			r.logger.Error("Failed to fetch open reports", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return reports, nil
+}
+
+func (r reportQuery) Resolve(ctx context.Context, reportedID int64) (int64, error) {
+	r.logger.Debug("Resolving reports", zap.Int64("reported_id", reportedID))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := r.sq.Update(ReportsTable).
+		Set(ReportsResolvedAt, squirrel.Expr("now()")).
+		Where(squirrel.Eq{
+			ReportsReportedID: reportedID,
+			ReportsResolvedAt: nil,
+		}).
+		ToSql()
+	if err != nil {
+		r.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	tag, err := r.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			r.logger.Warn("Database error",
+				zap.Int64("reported_id", reportedID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			r.logger.Error("Failed to resolve reports",
+				zap.Int64("reported_id", reportedID),
+				zap.Error(err),
+			)
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	r.logger.Info("Reports resolved",
+		zap.Int64("reported_id", reportedID),
+		zap.Int64("count", tag.RowsAffected()))
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const SettingsTable = "app_settings"
+
+const (
+	SettingsKey       = "key"
+	SettingsValue     = "value"
+	SettingsUpdatedAt = "updated_at"
+)
+
+// SettingLikeTTL — срок жизни лайка, который триггер likes подставляет,
+// если expires_at не задан явно.
+const SettingLikeTTL = "like_ttl"
+
+// SettingsQuery передаёт в базу параметры конфигурации, которыми
+// пользуется SQL-код (триггеры, процедуры).
+type SettingsQuery interface {
+	SetLikeTTL(ctx context.Context, ttl time.Duration) error
+}
+
+type settingsQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewSettingsQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) SettingsQuery {
+	return &settingsQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+func (s settingsQuery) SetLikeTTL(ctx context.Context, ttl time.Duration) error {
+	return s.set(ctx, SettingLikeTTL, fmt.Sprintf("%d seconds", int64(ttl.Seconds())))
+}
+
+func (s settingsQuery) set(ctx context.Context, key, value string) error {
+	s.logger.Debug("Updating setting", zap.String("key", key), zap.String("value", value))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := s.sq.Insert(SettingsTable).
+		Columns(SettingsKey, SettingsValue).
+		Values(key, value).
+		Suffix(`ON CONFLICT (key) DO UPDATE
+			SET value = EXCLUDED.value,
+			    updated_at = now()`).
+		ToSql()
+	if err != nil {
+		s.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := s.runner.Exec(ctx, qb, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			s.logger.Warn("Database error",
+				zap.String("key", key),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			s.logger.Error("Failed to update setting",
+				zap.String("key", key),
+				zap.Error(err))
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
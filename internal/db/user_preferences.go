@@ -9,6 +9,7 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/elgris/stom"
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
@@ -22,6 +23,8 @@ const (
 	UserPreferencesMaxAge      = "max_age"
 	UserPreferencesGenderPref  = "gender_preference"
 	UserPreferencesMaxDistance = "max_distance_km"
+	UserPreferencesMutualMatch = "mutual_match"
+	UserPreferencesNotifyLikes = "notify_likes"
 	UserPreferencesUpdatedAt   = "updated_at"
 )
 
@@ -31,6 +34,8 @@ type UserPreference struct {
 	MaxAge      int       `db:"max_age" insert:"max_age" update:"max_age"`
 	GenderPref  string    `db:"gender_preference" insert:"gender_preference" update:"gender_preference"`
 	MaxDistance int       `db:"max_distance_km" insert:"max_distance_km" update:"max_distance_km"`
+	MutualMatch bool      `db:"mutual_match"`
+	NotifyLikes bool      `db:"notify_likes"`
 	UpdatedAt   time.Time `db:"updated_at"`
 }
 
@@ -48,6 +53,9 @@ type UserPreferencesQuery interface {
 	GetByUserID(ctx context.Context, userID int64) (*UserPreference, error)
 	Insert(ctx context.Context, id int64) (*UserPreference, error)
 	Update(ctx context.Context, pref *UserPreference, id int64) error
+	SetMutualMatch(ctx context.Context, id int64, enabled bool) error
+	SetNotifyLikes(ctx context.Context, id int64, enabled bool) error
+	LikeNotificationsEnabled(ctx context.Context, id int64) (bool, error)
 }
 
 type userPreferencesQuery struct {
@@ -171,3 +179,109 @@ func (up userPreferencesQuery) Update(ctx context.Context, pref *UserPreference,
 	up.logger.Info("Preference updated successfully", zap.Int64("user_id", id))
 	return nil
 }
+
+// SetMutualMatch включает или выключает взаимный подбор: показывать только
+// тех, чьим настройкам поиска подходит и сам пользователь.
+func (up userPreferencesQuery) SetMutualMatch(ctx context.Context, id int64, enabled bool) error {
+	up.logger.Debug("Updating mutual match",
+		zap.Int64("user_id", id),
+		zap.Bool("enabled", enabled))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := up.sq.Update(UserPreferencesTable).
+		Set(UserPreferencesMutualMatch, enabled).
+		Set(UserPreferencesUpdatedAt, squirrel.Expr("now()")).
+		Where(squirrel.Eq{UserPreferencesUserID: id}).
+		ToSql()
+	if err != nil {
+		up.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = up.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			up.logger.Warn("Database error",
+				zap.Int64("user_id", id),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			up.logger.Error("Failed to update mutual match", zap.Int64("user_id", id), zap.Error(err))
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	up.logger.Info("Mutual match updated successfully", zap.Int64("user_id", id))
+	return nil
+}
+
+// SetNotifyLikes включает или выключает уведомления о новых лайках.
+func (up userPreferencesQuery) SetNotifyLikes(ctx context.Context, id int64, enabled bool) error {
+	up.logger.Debug("Updating like notifications",
+		zap.Int64("user_id", id),
+		zap.Bool("enabled", enabled))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := up.sq.Update(UserPreferencesTable).
+		Set(UserPreferencesNotifyLikes, enabled).
+		Set(UserPreferencesUpdatedAt, squirrel.Expr("now()")).
+		Where(squirrel.Eq{UserPreferencesUserID: id}).
+		ToSql()
+	if err != nil {
+		up.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = up.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			up.logger.Warn("Database error",
+				zap.Int64("user_id", id),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			up.logger.Error("Failed to update like notifications", zap.Int64("user_id", id), zap.Error(err))
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	up.logger.Info("Like notifications updated successfully", zap.Int64("user_id", id))
+	return nil
+}
+
+// LikeNotificationsEnabled сообщает, нужно ли уведомлять пользователя о
+// новых лайках. Без сохранённых настроек уведомления включены.
+func (up userPreferencesQuery) LikeNotificationsEnabled(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := up.sq.Select(UserPreferencesNotifyLikes).
+		From(UserPreferencesTable).
+		Where(squirrel.Eq{UserPreferencesUserID: id}).
+		ToSql()
+	if err != nil {
+		up.logger.Error("Failed to build query", zap.Error(err))
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+	var enabled bool
+	err = up.runner.QueryRow(ctx, qb, args...).Scan(&enabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			up.logger.Warn("Database error",
+				zap.Int64("user_id", id),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			up.logger.Error("Failed to fetch like notifications", zap.Int64("user_id", id), zap.Error(err))
+		}
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return enabled, nil
+}
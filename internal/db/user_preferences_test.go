@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserPreferencesMutualMatchDefaultsToFalse(t *testing.T) {
+	tdb := newTestDB(t)
+	tdb.addUser(t, testUserIDMin, "m", 30)
+
+	pref, err := NewUserPreferencesQuery(tdb.pool, tdb.sq, tdb.logger).GetByUserID(context.Background(), testUserIDMin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pref.MutualMatch {
+		t.Error("mutual match is enabled for a new user")
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const UserStatsView = "user_stats"
+
+type UserStatsQuery interface {
+	Refresh(ctx context.Context) error
+}
+
+type userStatsQuery struct {
+	runner *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewUserStatsQuery(runner *pgxpool.Pool, logger *zap.Logger) UserStatsQuery {
+	return &userStatsQuery{
+		runner: runner,
+		logger: logger,
+	}
+}
+
+// Refresh пересчитывает материализованное представление, не блокируя чтение.
+func (u userStatsQuery) Refresh(ctx context.Context) error {
+	u.logger.Debug("Refreshing user stats")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if _, err := u.runner.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+UserStatsView); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			u.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err))
+		} else {
+			u.logger.Error("Failed to refresh user stats", zap.Error(err))
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
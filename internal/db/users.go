@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/squirrel"
@@ -27,26 +29,30 @@ const (
 	UsersBio          = "bio"
 	UsersIsActive     = "is_active"
 	UsersIsPremium    = "is_premium"
+	UsersPremiumUntil = "premium_until"
 	UsersRating       = "rating"
+	UsersScore        = "score"
 	UsersCreatedAt    = "created_at"
 	UsersUpdatedAt    = "updated_at"
+	UsersLocation     = "location"
 	TgUsername        = "tg_username"
 )
 
 type User struct {
-	ID           int64     `db:"id" insert:"id"`
-	Username     *string   `db:"username" insert:"username" update:"username"`
-	TgUsername   string    `db:"tg_username" insert:"tg_username"`
-	Gender       string    `db:"gender" insert:"gender" update:"gender"`
-	Age          int       `db:"age" insert:"age" update:"age"`
-	ProfilePhoto *string   `db:"profile_photo_url" insert_photo:"profile_photo_url" update_photo:"profile_photo_url"`
-	CityID       *int64    `db:"city_id" insert:"city_id" update:"city_id"`
-	Bio          *string   `db:"bio" insert:"bio" update:"bio"`
-	IsActive     bool      `db:"is_active" insert_active:"is_active" update_active:"is_active"`
-	IsPremium    bool      `db:"is_premium"`
-	Rating       int       `db:"rating"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID           int64      `db:"id" insert:"id"`
+	Username     *string    `db:"username" insert:"username" update:"username"`
+	TgUsername   string     `db:"tg_username" insert:"tg_username"`
+	Gender       string     `db:"gender" insert:"gender" update:"gender"`
+	Age          int        `db:"age" insert:"age" update:"age"`
+	ProfilePhoto *string    `db:"profile_photo_url" insert_photo:"profile_photo_url" update_photo:"profile_photo_url"`
+	CityID       *int64     `db:"city_id" insert:"city_id" update:"city_id"`
+	Bio          *string    `db:"bio" insert:"bio" update:"bio"`
+	IsActive     bool       `db:"is_active" insert_active:"is_active" update_active:"is_active"`
+	IsPremium    bool       `db:"is_premium"`
+	PremiumUntil *time.Time `db:"premium_until"`
+	Rating       int        `db:"rating"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
 }
 
 var (
@@ -59,14 +65,58 @@ func (u *User) columns(pref string) []string {
 	return colNamesWithPref(stomUserSelect.TagValues(), pref)
 }
 
+// Point — координаты пользователя. Перед сохранением округляются до
+// locationPrecision, чтобы не хранить точное местоположение.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// locationPrecision — шаг сетки в градусах (около 1 км по широте).
+const locationPrecision = 0.01
+
+// Coarse возвращает точку, округлённую до locationPrecision.
+func (p Point) Coarse() Point {
+	return Point{
+		Latitude:  math.Round(p.Latitude/locationPrecision) * locationPrecision,
+		Longitude: math.Round(p.Longitude/locationPrecision) * locationPrecision,
+	}
+}
+
+// SearchOrder — колонка, по которой сортируется выдача поиска.
+type SearchOrder string
+
+const (
+	// OrderByRating — прежняя формула (число лайков).
+	OrderByRating SearchOrder = UsersRating
+	// OrderByScore — новая формула из пакета scoring.
+	OrderByScore SearchOrder = UsersScore
+)
+
+// SearchResult — анкета из поиска вместе с расстоянием до неё.
+type SearchResult struct {
+	User
+	DistanceKm float64 `db:"distance_km"`
+	Boosted    bool    `db:"boosted"`
+}
+
+// userBoostExpr — поднимает анкеты с действующим премиумом в начало выдачи.
+const userBoostExpr = "COALESCE(u.premium_until > now(), FALSE)"
+
+// userDistanceExpr — расстояние в метрах между кандидатом u и владельцем
+// поиска u_own: по точке пользователя, если он делился геопозицией, иначе
+// по центру города.
+const userDistanceExpr = "ST_Distance(COALESCE(u.location, c.location), COALESCE(u_own.location, c_own.location))"
+
 type UserQuery interface {
 	GetByID(ctx context.Context, id int64) (*User, error)
 	Insert(ctx context.Context, user *User) (*User, error)
 	Update(ctx context.Context, user *User, id int64) (*User, error)
 	UpdateProfilePhoto(ctx context.Context, id int64, profilePhoto *string) error
 	UpdateActive(ctx context.Context, id int64, isActive bool) error
-	UpdateRating(ctx context.Context, id int64) error
-	SelectUsers(ctx context.Context, id int64, offset uint64) ([]*User, error)
+	UpdateLocation(ctx context.Context, id int64, point *Point) error
+	SelectCandidateIDs(ctx context.Context, id int64, order SearchOrder, limit uint64) ([]int64, error)
+	SelectUsersByIDs(ctx context.Context, id int64, ids []int64) ([]*SearchResult, error)
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -133,7 +183,7 @@ func (u userQuery) Insert(ctx context.Context, user *User) (*User, error) {
 	}
 	qb, args, err := u.sq.Insert(UsersTable).
 		SetMap(insertMap).
-		Suffix("RETURNING *").
+		Suffix("RETURNING " + strings.Join(user.columns(""), ", ")).
 		ToSql()
 	if err != nil {
 		u.logger.Error("Failed to build query", zap.Error(err))
@@ -170,7 +220,7 @@ func (u userQuery) Update(ctx context.Context, user *User, id int64) (*User, err
 	qb, args, err := u.sq.Update(UsersTable).
 		SetMap(updateMap).
 		Where(squirrel.Eq{UsersID: id}).
-		Suffix("RETURNING *").
+		Suffix("RETURNING " + strings.Join(user.columns(""), ", ")).
 		ToSql()
 	if err != nil {
 		u.logger.Error("Failed to build query", zap.Error(err))
@@ -228,6 +278,44 @@ func (u userQuery) UpdateProfilePhoto(ctx context.Context, id int64, profilePhot
 	return nil
 }
 
+// UpdateLocation сохраняет огрублённые координаты пользователя; nil
+// сбрасывает их, и поиск снова опирается на координаты города.
+func (u userQuery) UpdateLocation(ctx context.Context, id int64, point *Point) error {
+	u.logger.Debug("Updating user location", zap.Int64("user_id", id))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var location interface{}
+	if point != nil {
+		coarse := point.Coarse()
+		location = squirrel.Expr("ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography", coarse.Longitude, coarse.Latitude)
+	}
+	qb, args, err := u.sq.Update(UsersTable).
+		Set(UsersLocation, location).
+		Where(squirrel.Eq{UsersID: id}).
+		ToSql()
+	if err != nil {
+		u.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = u.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			u.logger.Warn("Database error",
+				zap.Int64("user_id", id),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			u.logger.Error("Failed to update location", zap.Int64("user_id", id), zap.Error(err))
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	u.logger.Info("Location updated successfully", zap.Int64("user_id", id))
+	return nil
+}
+
 func (u userQuery) UpdateActive(ctx context.Context, id int64, isActive bool) error {
 	u.logger.Debug("Updating user active status",
 		zap.Int64("user_id", id),
@@ -265,17 +353,11 @@ func (u userQuery) UpdateActive(ctx context.Context, id int64, isActive bool) er
 	return nil
 }
 
-func (u userQuery) SelectUsers(ctx context.Context, id int64, offset uint64) ([]*User, error) {
-	u.logger.Debug("Selecting users",
-		zap.Int64("user_id", id),
-		zap.Uint64("offset", offset),
-	)
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	var users []*User
-
-	qb := u.sq.Select((&User{}).columns("u")...).
+// searchQuery строит выборку анкет, подходящих пользователю id: фильтры
+// поиска, блокировки, лайки, пропуски и уже просмотренные анкеты. Колонки
+// задаёт вызывающий.
+func (u userQuery) searchQuery(id int64) squirrel.SelectBuilder {
+	return u.sq.Select().
 		From(UsersTable+" u").
 		InnerJoin("cities c ON u.city_id = c.id").
 		InnerJoin(UserPreferencesTable+" up_own ON up_own.user_id = ?", id).
@@ -284,31 +366,68 @@ func (u userQuery) SelectUsers(ctx context.Context, id int64, offset uint64) ([]
 		LeftJoin(BlocksTable+" b1 ON u.id = b1.blocked_id AND b1.blocker_id = ?", id).
 		LeftJoin(BlocksTable+" b2 ON u.id = b2.blocker_id AND b2.blocked_id = ?", id).
 		LeftJoin(LikesTable+" l ON l.from_user_id = ? AND l.to_user_id = u.id", id).
+		LeftJoin(PassesTable+" p ON p.from_user_id = ? AND p.to_user_id = u.id AND p.expires_at > now()", id).
+		LeftJoin(ViewsTable+" v ON v.from_user_id = ? AND v.to_user_id = u.id AND v.expires_at > now()", id).
+		LeftJoin(UserPreferencesTable + " up ON up.user_id = u.id").
 		Where(squirrel.And{
 			squirrel.NotEq{"u.id": id},
 			squirrel.Eq{"u.is_active": true},
 			squirrel.Eq{"b1.id": nil},
 			squirrel.Eq{"b2.id": nil},
 			squirrel.Eq{"l.id": nil},
+			squirrel.Eq{"p.id": nil},
+			squirrel.Eq{"v.id": nil},
 			squirrel.Expr("u.age >= up_own.min_age"),
 			squirrel.Expr("u.age <= up_own.max_age"),
 			squirrel.Or{
 				squirrel.Eq{"up_own.gender_preference": "a"},
 				squirrel.Expr("u.gender = up_own.gender_preference"),
 			},
-			squirrel.Expr("ST_Distance(c.location, c_own.location) <= up_own.max_distance_km * 1000"),
-		}).
-		OrderBy("u.rating DESC, u.id").
-		Limit(50).
-		Offset(offset)
+			squirrel.Expr(userDistanceExpr + " <= up_own.max_distance_km * 1000"),
+			// Взаимный подбор: владелец поиска должен подходить под настройки кандидата
+			squirrel.Or{
+				squirrel.Expr("NOT up_own.mutual_match"),
+				squirrel.Eq{"up.user_id": nil},
+				squirrel.And{
+					squirrel.Expr("u_own.age BETWEEN up.min_age AND up.max_age"),
+					squirrel.Or{
+						squirrel.Eq{"up.gender_preference": "a"},
+						squirrel.Expr("up.gender_preference = u_own.gender"),
+					},
+					squirrel.Expr(userDistanceExpr + " <= COALESCE(up.max_distance_km, 100) * 1000"),
+				},
+			},
+		})
+}
+
+// SelectCandidateIDs возвращает id подходящих анкет в порядке выдачи:
+// сначала премиум, затем по убыванию колонки order.
+func (u userQuery) SelectCandidateIDs(ctx context.Context, id int64, order SearchOrder, limit uint64) ([]int64, error) {
+	u.logger.Debug("Selecting candidate ids",
+		zap.Int64("user_id", id),
+		zap.String("order", string(order)),
+		zap.Uint64("limit", limit),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	query, args, err := qb.ToSql()
+	if order != OrderByRating && order != OrderByScore {
+		return nil, fmt.Errorf("unknown search order %q", order)
+	}
+	rankColumn := "u." + string(order)
+
+	query, args, err := u.searchQuery(id).
+		Column("u.id").
+		OrderBy(userBoostExpr+" DESC", rankColumn+" DESC", "u.id").
+		Limit(limit).
+		ToSql()
 	if err != nil {
 		u.logger.Error("Failed to build query", zap.Error(err))
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	err = pgxscan.Select(ctx, u.runner, &users, query, args...)
+	var ids []int64
+	err = pgxscan.Select(ctx, u.runner, &ids, query, args...)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -318,32 +437,44 @@ func (u userQuery) SelectUsers(ctx context.Context, id int64, offset uint64) ([]
 				zap.Error(err),
 			)
 		} else {
-			u.logger.Error("Failed to select users", zap.Int64("user_id", id), zap.Error(err))
+			u.logger.Error("Failed to select candidate ids", zap.Int64("user_id", id), zap.Error(err))
 		}
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	u.logger.Info("Users selected successfully",
+	u.logger.Info("Candidate ids selected successfully",
 		zap.Int64("user_id", id),
-		zap.Int("count", len(users)),
+		zap.Int("count", len(ids)),
 	)
-	return users, nil
+	return ids, nil
 }
 
-func (u userQuery) Delete(ctx context.Context, id int64) error {
-	u.logger.Debug("Deleting user", zap.Int64("user_id", id))
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+// SelectUsersByIDs загружает анкеты из ids в том же порядке. Анкеты,
+// которые перестали подходить пользователю id, в результат не попадают.
+func (u userQuery) SelectUsersByIDs(ctx context.Context, id int64, ids []int64) ([]*SearchResult, error) {
+	u.logger.Debug("Selecting users by ids",
+		zap.Int64("user_id", id),
+		zap.Int64s("ids", ids),
+	)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	qb, args, err := u.sq.Delete(UsersTable).
-		Where(squirrel.Eq{UsersID: id}).
+	query, args, err := u.searchQuery(id).
+		Columns((&User{}).columns("u")...).
+		Column(userDistanceExpr+" / 1000 AS distance_km").
+		Column(userBoostExpr+" AS boosted").
+		Where("u.id = ANY(?)", ids).
+		OrderByClause("array_position(?::bigint[], u.id)", ids).
 		ToSql()
 	if err != nil {
 		u.logger.Error("Failed to build query", zap.Error(err))
-		return fmt.Errorf("failed to build query: %w", err)
+		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
-
-	result, err := u.runner.Exec(ctx, qb, args...)
+	var users []*SearchResult
+	err = pgxscan.Select(ctx, u.runner, &users, query, args...)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -353,75 +484,52 @@ func (u userQuery) Delete(ctx context.Context, id int64) error {
 				zap.Error(err),
 			)
 		} else {
-			u.logger.Error("Failed to delete user", zap.Int64("user_id", id), zap.Error(err))
+			u.logger.Error("Failed to select users", zap.Int64("user_id", id), zap.Error(err))
 		}
-		return fmt.Errorf("failed to execute query: %w", err)
-	}
-
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		u.logger.Warn("No user found to delete", zap.Int64("user_id", id))
-		return fmt.Errorf("no user found with id %d", id)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	u.logger.Info("User deleted successfully", zap.Int64("user_id", id))
-	return nil
+	u.logger.Info("Users selected successfully",
+		zap.Int64("user_id", id),
+		zap.Int("count", len(users)),
+	)
+	return users, nil
 }
 
-func (u userQuery) UpdateRating(ctx context.Context, userID int64) error {
-	u.logger.Debug("Updating user rating", zap.Int64("user_id", userID))
+func (u userQuery) Delete(ctx context.Context, id int64) error {
+	u.logger.Debug("Deleting user", zap.Int64("user_id", id))
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var rating int
-	query := `
-		SELECT COUNT(*)
-		FROM likes
-		WHERE to_user_id = $1
-		AND expires_at > NOW()
-	`
-	err := u.runner.QueryRow(ctx, query, userID).Scan(&rating)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			u.logger.Warn("Database error",
-				zap.Int64("user_id", userID),
-				zap.String("pg_error_code", pgErr.Code),
-				zap.Error(err),
-			)
-		} else {
-			u.logger.Error("Failed to count likes for rating", zap.Int64("user_id", userID), zap.Error(err))
-		}
-		return fmt.Errorf("failed to count likes: %w", err)
-	}
-
-	// Обновляем рейтинг в таблице users
-	updateMap := map[string]interface{}{
-		UsersRating: rating,
-	}
-	qb, args, err := u.sq.Update(UsersTable).
-		SetMap(updateMap).
-		Where(squirrel.Eq{UsersID: userID}).
+	qb, args, err := u.sq.Delete(UsersTable).
+		Where(squirrel.Eq{UsersID: id}).
 		ToSql()
 	if err != nil {
 		u.logger.Error("Failed to build query", zap.Error(err))
 		return fmt.Errorf("failed to build query: %w", err)
 	}
-	_, err = u.runner.Exec(ctx, qb, args...)
+
+	result, err := u.runner.Exec(ctx, qb, args...)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			u.logger.Warn("Database error",
-				zap.Int64("user_id", userID),
+				zap.Int64("user_id", id),
 				zap.String("pg_error_code", pgErr.Code),
 				zap.Error(err),
 			)
 		} else {
-			u.logger.Error("Failed to update rating", zap.Int64("user_id", userID), zap.Error(err))
+			u.logger.Error("Failed to delete user", zap.Int64("user_id", id), zap.Error(err))
 		}
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	u.logger.Info("Rating updated successfully", zap.Int64("user_id", userID), zap.Int("rating", rating))
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		u.logger.Warn("No user found to delete", zap.Int64("user_id", id))
+		return fmt.Errorf("no user found with id %d", id)
+	}
+
+	u.logger.Info("User deleted successfully", zap.Int64("user_id", id))
 	return nil
 }
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func searchIDs(t *testing.T, users UserQuery, id int64) []int64 {
+	t.Helper()
+	ids, err := users.SelectCandidateIDs(context.Background(), id, OrderByRating, 100)
+	if err != nil {
+		t.Fatalf("SelectCandidateIDs: %v", err)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestSelectUsersMutualMatch(t *testing.T) {
+	tdb := newTestDB(t)
+	ctx := context.Background()
+	users := NewUserQuery(tdb.pool, tdb.sq, tdb.logger)
+	prefs := NewUserPreferencesQuery(tdb.pool, tdb.sq, tdb.logger)
+
+	owner := int64(testUserIDMin)
+	picky := owner + 1 // ищет мужчин 20-25 лет, владелец поиска не подходит
+	open := owner + 2  // настройки по умолчанию
+	tdb.addUser(t, owner, "m", 30)
+	tdb.addUser(t, picky, "f", 24)
+	tdb.addUser(t, open, "f", 27)
+	if err := prefs.Update(ctx, &UserPreference{
+		MinAge:      20,
+		MaxAge:      25,
+		GenderPref:  "m",
+		MaxDistance: 100,
+	}, picky); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := searchIDs(t, users, owner); !slices.Equal(got, []int64{picky, open}) {
+		t.Errorf("without mutual match: %v, want %v", got, []int64{picky, open})
+	}
+
+	if err := prefs.SetMutualMatch(ctx, owner, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, users, owner); !slices.Equal(got, []int64{open}) {
+		t.Errorf("with mutual match: %v, want %v", got, []int64{open})
+	}
+}
+
+func TestSelectCandidateIDsAndUsersByIDs(t *testing.T) {
+	tdb := newTestDB(t)
+	ctx := context.Background()
+	users := NewUserQuery(tdb.pool, tdb.sq, tdb.logger)
+
+	owner := int64(testUserIDMin)
+	tdb.addUser(t, owner, "m", 30)
+	ratings := map[int64]int{owner + 1: 5, owner + 2: 9, owner + 3: 1}
+	for id, rating := range ratings {
+		tdb.addUser(t, id, "f", 27)
+		if _, err := tdb.pool.Exec(ctx, "UPDATE users SET rating = $1 WHERE id = $2", rating, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Просмотренная анкета в выдачу не попадает, пока просмотр не истёк
+	views := NewViewQuery(tdb.pool, tdb.sq, tdb.logger)
+	if err := views.Insert(ctx, owner, owner+3, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := users.SelectCandidateIDs(ctx, owner, OrderByRating, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{owner + 2, owner + 1}; !slices.Equal(ids, want) {
+		t.Errorf("candidate ids = %v, want %v", ids, want)
+	}
+
+	// Отменённый просмотр возвращает анкету. Анкета, ставшая неактивной
+	// после снимка, в карточки не попадает, а порядок остаётся порядком
+	// переданных id
+	if err := views.Delete(ctx, owner, owner+3); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.UpdateActive(ctx, owner+2, false); err != nil {
+		t.Fatal(err)
+	}
+	cards, err := users.SelectUsersByIDs(ctx, owner, []int64{owner + 3, owner + 2, owner + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]int64, 0, len(cards))
+	for _, card := range cards {
+		got = append(got, card.ID)
+	}
+	if want := []int64{owner + 3, owner + 1}; !slices.Equal(got, want) {
+		t.Errorf("cards = %v, want %v", got, want)
+	}
+}
@@ -28,3 +28,8 @@ func colNamesWithPref(cols []string, pref string) []string {
 	}
 	return prefCols
 }
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const ViewsTable = "profile_views"
+
+const (
+	ViewsID         = "id"
+	ViewsFromUserID = "from_user_id"
+	ViewsToUserID   = "to_user_id"
+	ViewsCreatedAt  = "created_at"
+	ViewsExpiresAt  = "expires_at"
+)
+
+// View — анкета, которую пользователь пролистал в поиске. До ExpiresAt она
+// не попадает в выдачу снова.
+type View struct {
+	ID         int64     `db:"id"`
+	FromUserID int64     `db:"from_user_id"`
+	ToUserID   int64     `db:"to_user_id"`
+	CreatedAt  time.Time `db:"created_at"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+type ViewQuery interface {
+	Insert(ctx context.Context, fromUserID, toUserID int64, ttl time.Duration) error
+	Delete(ctx context.Context, fromUserID, toUserID int64) error
+	DeleteAllByUser(ctx context.Context, fromUserID int64) (int64, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type viewQuery struct {
+	runner *pgxpool.Pool
+	sq     squirrel.StatementBuilderType
+	logger *zap.Logger
+}
+
+func NewViewQuery(runner *pgxpool.Pool, sq squirrel.StatementBuilderType, logger *zap.Logger) ViewQuery {
+	return &viewQuery{
+		runner: runner,
+		sq:     sq,
+		logger: logger,
+	}
+}
+
+// Insert отмечает анкету просмотренной на ttl; повторный просмотр продлевает
+// срок.
+func (v viewQuery) Insert(ctx context.Context, fromUserID, toUserID int64, ttl time.Duration) error {
+	v.logger.Debug("Inserting view",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := v.sq.Insert(ViewsTable).
+		Columns(ViewsFromUserID, ViewsToUserID, ViewsExpiresAt).
+		Values(fromUserID, toUserID, squirrel.Expr("now() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix(`ON CONFLICT (from_user_id, to_user_id) DO UPDATE
+			SET created_at = now(),
+			    expires_at = EXCLUDED.expires_at`).
+		ToSql()
+	if err != nil {
+		v.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = v.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			v.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			v.logger.Error("Failed to insert view",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	v.logger.Info("View inserted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	return nil
+}
+
+func (v viewQuery) Delete(ctx context.Context, fromUserID, toUserID int64) error {
+	v.logger.Debug("Deleting view",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := v.sq.Delete(ViewsTable).
+		Where(squirrel.Eq{
+			ViewsFromUserID: fromUserID,
+			ViewsToUserID:   toUserID,
+		}).
+		ToSql()
+	if err != nil {
+		v.logger.Error("Failed to build query", zap.Error(err))
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	_, err = v.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			v.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			v.logger.Error("Failed to delete view",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	v.logger.Info("View deleted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+	)
+	return nil
+}
+
+// DeleteAllByUser удаляет все просмотры пользователя и возвращает их число.
+func (v viewQuery) DeleteAllByUser(ctx context.Context, fromUserID int64) (int64, error) {
+	v.logger.Debug("Deleting all views", zap.Int64("from_user_id", fromUserID))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	qb, args, err := v.sq.Delete(ViewsTable).
+		Where(squirrel.Eq{ViewsFromUserID: fromUserID}).
+		ToSql()
+	if err != nil {
+		v.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := v.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			v.logger.Warn("Database error",
+				zap.Int64("from_user_id", fromUserID),
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			v.logger.Error("Failed to delete views",
+				zap.Int64("from_user_id", fromUserID),
+				zap.Error(err),
+			)
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	v.logger.Info("Views deleted successfully",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("count", result.RowsAffected()),
+	)
+	return result.RowsAffected(), nil
+}
+
+// DeleteExpired удаляет истёкшие просмотры и возвращает их число.
+func (v viewQuery) DeleteExpired(ctx context.Context) (int64, error) {
+	v.logger.Debug("Deleting expired views")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	qb, args, err := v.sq.Delete(ViewsTable).
+		Where(squirrel.Expr(ViewsExpiresAt + " <= now()")).
+		ToSql()
+	if err != nil {
+		v.logger.Error("Failed to build query", zap.Error(err))
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := v.runner.Exec(ctx, qb, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			v.logger.Warn("Database error",
+				zap.String("pg_error_code", pgErr.Code),
+				zap.Error(err),
+			)
+		} else {
+			v.logger.Error("Failed to delete expired views", zap.Error(err))
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
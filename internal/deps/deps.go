@@ -2,11 +2,15 @@ package deps
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/agent-yandex/dating-bot/internal/config"
 	"github.com/agent-yandex/dating-bot/internal/db"
 	"github.com/agent-yandex/dating-bot/internal/logger"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	storage "github.com/agent-yandex/dating-bot/internal/minio"
+	"github.com/agent-yandex/dating-bot/internal/premium"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
@@ -17,12 +21,21 @@ type DB struct {
 	Blocks          db.BlockQuery
 	Cities          db.CityQuery
 	Likes           db.LikeQuery
+	Matches         db.MatchQuery
+	Passes          db.PassQuery
+	Views           db.ViewQuery
+	Reports         db.ReportQuery
+	ProfileStats    db.ProfileStatsQuery
+	UserStats       db.UserStatsQuery
 }
 
 type Dependencies struct {
-	DB     DB
-	Pool   *pgxpool.Pool
-	Logger *zap.Logger
+	DB       DB
+	Pool     *pgxpool.Pool
+	Storage  storage.FileStorage
+	Matching *matching.Service
+	Premium  *premium.Service
+	Logger   *zap.Logger
 }
 
 func ProvideDependencies(ctx context.Context, cfg config.AppConfig) (*Dependencies, error) {
@@ -34,6 +47,19 @@ func ProvideDependencies(ctx context.Context, cfg config.AppConfig) (*Dependenci
 		return nil, err
 	}
 
+	minioClient, err := storage.NewMinioClient(
+		cfg.Minio.Endpoint,
+		cfg.Minio.AccessKey,
+		cfg.Minio.SecretKey,
+		cfg.Minio.Bucket,
+		logger,
+	)
+	if err != nil {
+		logger.Error("Failed to init MinIO", zap.Error(err))
+		pool.Close()
+		return nil, err
+	}
+
 	sq := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	deps := &Dependencies{
 		DB: DB{
@@ -42,9 +68,18 @@ func ProvideDependencies(ctx context.Context, cfg config.AppConfig) (*Dependenci
 			Blocks:          db.NewBlockQuery(pool, sq, logger),
 			Cities:          db.NewCityQuery(pool, sq, logger),
 			Likes:           db.NewLikeQuery(pool, sq, logger),
+			Matches:         db.NewMatchQuery(pool, sq, logger),
+			Passes:          db.NewPassQuery(pool, sq, logger),
+			Views:           db.NewViewQuery(pool, sq, logger),
+			Reports:         db.NewReportQuery(pool, sq, logger),
+			ProfileStats:    db.NewProfileStatsQuery(pool, sq, logger),
+			UserStats:       db.NewUserStatsQuery(pool, logger),
 		},
-		Pool:   pool,
-		Logger: logger,
+		Pool:     pool,
+		Storage:  minioClient,
+		Matching: matching.NewService(matching.NewPostgresRepository(pool, sq, cfg.LikeTTL), logger),
+		Premium:  premium.NewService(premium.NewPostgresRepository(pool, sq), premiumPlan(cfg.Premium), logger),
+		Logger:   logger,
 	}
 
 	if err := pool.Ping(ctx); err != nil {
@@ -53,10 +88,27 @@ func ProvideDependencies(ctx context.Context, cfg config.AppConfig) (*Dependenci
 		return nil, err
 	}
 
+	// Триггер likes должен выставлять тот же срок, что и приложение
+	if err := db.NewSettingsQuery(pool, sq, logger).SetLikeTTL(ctx, cfg.LikeTTL); err != nil {
+		logger.Error("Failed to sync like TTL", zap.Error(err))
+		pool.Close()
+		return nil, err
+	}
+
 	logger.Info("Dependencies initialized successfully")
 	return deps, nil
 }
 
+func premiumPlan(cfg config.PremiumConfig) premium.Plan {
+	return premium.Plan{
+		Title:       "Премиум",
+		Description: fmt.Sprintf("Премиум на %d дн.: список тех, кто вас лайкнул, лайки без суточного лимита и приоритет в поиске.", int(cfg.Duration.Hours()/24)),
+		Payload:     "premium:v1",
+		Stars:       cfg.PriceStars,
+		Duration:    cfg.Duration,
+	}
+}
+
 func (d *Dependencies) Cleanup() {
 	d.Logger.Info("Cleaning up dependencies")
 	d.Logger.Sync()
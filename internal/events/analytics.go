@@ -0,0 +1,20 @@
+package events
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// SubscribeAnalytics пишет все доменные события в структурированный лог,
+// откуда их забирает сбор аналитики.
+func SubscribeAnalytics(bus Bus, logger *zap.Logger) {
+	for name := range decoders {
+		bus.Subscribe(name, func(_ context.Context, e Event) error {
+			logger.Info("Domain event",
+				zap.String("event", e.Name()),
+				zap.Any("payload", e))
+			return nil
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/outbound"
+	"go.uber.org/zap"
+)
+
+// decoders восстанавливают событие по имени при чтении из брокера.
+var decoders = map[string]func(payload []byte) (Event, error){}
+
+func register[T Event]() {
+	var zero T
+	decoders[zero.Name()] = func(payload []byte) (Event, error) {
+		var e T
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+}
+
+func init() {
+	register[LikeCreated]()
+	register[LikeWithdrawn]()
+	register[MatchCreated]()
+	register[UserBlocked]()
+	register[ProfileUpdated]()
+	register[PreferencesChanged]()
+}
+
+// Повторы событий, которые подписчики не смогли обработать.
+const (
+	maxAttempts = 5
+	// retryDelay — пауза перед первым повтором; с каждой попыткой растёт
+	retryDelay = 5 * time.Second
+)
+
+type envelope struct {
+	Name    string
+	Payload json.RawMessage
+	// Attempt — сколько раз событие уже не удалось обработать
+	Attempt int `json:",omitempty"`
+	// Pending — номера подписчиков, которым событие нужно доставить
+	// повторно, из Subscribers подписчиков на момент неудачной попытки
+	Pending     []int  `json:",omitempty"`
+	Subscribers int    `json:",omitempty"`
+	LastError   string `json:",omitempty"`
+}
+
+// BrokerBus передаёт события подписчикам через брокер: Publish только
+// ставит событие в очередь, а Run забирает его и вызывает подписчиков на
+// каком-либо из экземпляров бота. Подходит для побочных эффектов, которым
+// не нужно завершиться до ответа пользователю.
+//
+// Если подписчик вернул ошибку, событие через паузу доставляется повторно
+// только упавшим подписчикам, чтобы успешные не сработали дважды; после
+// maxAttempts попыток оно уходит в очередь недоставленных.
+type BrokerBus struct {
+	broker      outbound.Broker
+	maxAttempts int
+	retryDelay  time.Duration
+	logger      *zap.Logger
+
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBrokerBus(broker outbound.Broker, logger *zap.Logger) *BrokerBus {
+	return &BrokerBus{
+		broker:      broker,
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		logger:      logger,
+		handlers:    make(map[string][]Handler),
+	}
+}
+
+func (b *BrokerBus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], h)
+}
+
+func (b *BrokerBus) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", e.Name(), err)
+	}
+	body, err := json.Marshal(envelope{Name: e.Name(), Payload: payload})
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", e.Name(), err)
+	}
+	if err := b.broker.Publish(ctx, body, 0); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", e.Name(), err)
+	}
+	return nil
+}
+
+// Run доставляет события подписчикам до отмены ctx, переподключаясь после
+// обрыва связи с брокером.
+func (b *BrokerBus) Run(ctx context.Context) {
+	for {
+		err := b.broker.Consume(ctx, b.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Error("Events consumer stopped, restarting", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// handle вызывает подписчиков события. Если кто-то из них упал, событие
+// публикуется заново для повтора или откладывается в недоставленные;
+// ошибка возвращается, только если не удалось ни то, ни другое, и тогда
+// брокер сам вернёт сообщение в очередь.
+func (b *BrokerBus) handle(ctx context.Context, body []byte) error {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		b.logger.Error("Failed to decode event", zap.Error(err))
+		return b.broker.DeadLetter(ctx, body)
+	}
+	decode, ok := decoders[env.Name]
+	if !ok {
+		b.logger.Error("Unknown event", zap.String("event", env.Name))
+		return b.broker.DeadLetter(ctx, body)
+	}
+	e, err := decode(env.Payload)
+	if err != nil {
+		b.logger.Error("Failed to decode event",
+			zap.String("event", env.Name),
+			zap.Error(err))
+		return b.broker.DeadLetter(ctx, body)
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[env.Name]
+	b.mu.RUnlock()
+
+	pending := env.Pending
+	if env.Attempt == 0 {
+		pending = make([]int, len(handlers))
+		for i := range handlers {
+			pending[i] = i
+		}
+	} else if env.Subscribers != len(handlers) {
+		// Подписчики изменились между попытками, например при обновлении
+		// бота, и номера больше не указывают на те же обработчики
+		b.logger.Error("Event subscribers changed between attempts",
+			zap.String("event", env.Name),
+			zap.Int("was", env.Subscribers),
+			zap.Int("now", len(handlers)))
+		return b.deadLetter(ctx, &env)
+	}
+
+	var (
+		failed []int
+		errs   []error
+	)
+	for _, i := range pending {
+		if err := handlers[i](ctx, e); err != nil {
+			b.logger.Error("Event handler failed",
+				zap.String("event", env.Name),
+				zap.Int("attempt", env.Attempt+1),
+				zap.Error(err))
+			failed = append(failed, i)
+			errs = append(errs, err)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	env.Attempt++
+	env.Pending = failed
+	env.Subscribers = len(handlers)
+	env.LastError = errors.Join(errs...).Error()
+	if env.Attempt >= b.maxAttempts {
+		b.logger.Error("Event handlers failed permanently",
+			zap.String("event", env.Name),
+			zap.Int("attempts", env.Attempt))
+		return b.deadLetter(ctx, &env)
+	}
+	body, err = json.Marshal(&env)
+	if err != nil {
+		return err
+	}
+	return b.broker.Publish(ctx, body, b.retryDelay*time.Duration(env.Attempt))
+}
+
+func (b *BrokerBus) deadLetter(ctx context.Context, env *envelope) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.broker.DeadLetter(ctx, body)
+}
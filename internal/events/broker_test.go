@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/outbound"
+	"go.uber.org/zap"
+)
+
+// startBrokerBus запускает шину на очереди в памяти с быстрыми повторами.
+func startBrokerBus(t *testing.T, subscribe func(bus *BrokerBus)) (*BrokerBus, *outbound.MemoryBroker) {
+	t.Helper()
+	broker := outbound.NewMemoryBroker(10)
+	bus := NewBrokerBus(broker, zap.NewNop())
+	bus.maxAttempts = 3
+	bus.retryDelay = time.Millisecond
+	subscribe(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bus.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return bus, broker
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// calls считает вызовы подписчиков по имени.
+type calls struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *calls) add(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[name]++
+	return c.counts[name]
+}
+
+func (c *calls) get(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+func TestBrokerBusDeliversEvents(t *testing.T) {
+	var mu sync.Mutex
+	var got []MatchCreated
+	bus, _ := startBrokerBus(t, func(bus *BrokerBus) {
+		On(bus, func(_ context.Context, e MatchCreated) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, e)
+			return nil
+		})
+	})
+
+	if err := bus.Publish(context.Background(), MatchCreated{UserID: 1, PartnerID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 1
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if got[0] != (MatchCreated{UserID: 1, PartnerID: 2}) {
+		t.Errorf("got = %+v", got[0])
+	}
+}
+
+func TestBrokerBusRetriesOnlyFailedSubscribers(t *testing.T) {
+	var c calls
+	bus, broker := startBrokerBus(t, func(bus *BrokerBus) {
+		On(bus, func(context.Context, LikeCreated) error {
+			c.add("stats")
+			return nil
+		})
+		On(bus, func(context.Context, LikeCreated) error {
+			if c.add("notify") == 1 {
+				return errors.New("telegram is down")
+			}
+			return nil
+		})
+	})
+
+	if err := bus.Publish(context.Background(), LikeCreated{FromUserID: 1, ToUserID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return c.get("notify") == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if got := c.get("stats"); got != 1 {
+		t.Errorf("successful subscriber called %d times, want 1", got)
+	}
+	if got := c.get("notify"); got != 2 {
+		t.Errorf("failed subscriber called %d times, want 2", got)
+	}
+	if dead := broker.Dead(); len(dead) != 0 {
+		t.Errorf("dead letters = %q", dead)
+	}
+}
+
+func TestBrokerBusDeadLettersAfterMaxAttempts(t *testing.T) {
+	var c calls
+	bus, broker := startBrokerBus(t, func(bus *BrokerBus) {
+		On(bus, func(context.Context, UserBlocked) error {
+			c.add("cache")
+			return nil
+		})
+		On(bus, func(context.Context, UserBlocked) error {
+			c.add("broken")
+			return errors.New("always fails")
+		})
+	})
+
+	if err := bus.Publish(context.Background(), UserBlocked{BlockerID: 1, BlockedID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(broker.Dead()) == 1 })
+
+	var env envelope
+	if err := json.Unmarshal(broker.Dead()[0], &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Name != "user.blocked" || env.Attempt != 3 || len(env.Pending) != 1 || env.Pending[0] != 1 || env.LastError != "always fails" {
+		t.Errorf("dead letter = %+v", env)
+	}
+	if c.get("broken") != 3 || c.get("cache") != 1 {
+		t.Errorf("calls: broken %d, cache %d; want 3 and 1", c.get("broken"), c.get("cache"))
+	}
+}
+
+func TestBrokerBusDeadLettersUndecodableEvents(t *testing.T) {
+	_, broker := startBrokerBus(t, func(*BrokerBus) {})
+
+	ctx := context.Background()
+	for _, body := range []string{
+		`not json`,
+		`{"Name":"unknown.event","Payload":{}}`,
+		`{"Name":"like.created","Payload":"not an object"}`,
+	} {
+		if err := broker.Publish(ctx, []byte(body), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, func() bool { return len(broker.Dead()) == 3 })
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Event — доменное событие. Name однозначно определяет тип события и
+// используется при передаче через брокер.
+type Event interface {
+	Name() string
+}
+
+// LikeCreated — пользователь FromUserID лайкнул ToUserID, и лайк ждёт ответа.
+type LikeCreated struct {
+	FromUserID int64
+	ToUserID   int64
+	HasMessage bool
+}
+
+func (LikeCreated) Name() string { return "like.created" }
+
+// LikeWithdrawn — пользователь отменил свой лайк.
+type LikeWithdrawn struct {
+	FromUserID int64
+	ToUserID   int64
+}
+
+func (LikeWithdrawn) Name() string { return "like.withdrawn" }
+
+// MatchCreated — UserID ответил лайком на лайк PartnerID, и образовалась пара.
+type MatchCreated struct {
+	UserID    int64
+	PartnerID int64
+}
+
+func (MatchCreated) Name() string { return "match.created" }
+
+// UserBlocked — BlockerID заблокировал BlockedID; лайки и пара между ними
+// уже удалены.
+type UserBlocked struct {
+	BlockerID int64
+	BlockedID int64
+}
+
+func (UserBlocked) Name() string { return "user.blocked" }
+
+// ProfileUpdated — пользователь создал или изменил анкету.
+type ProfileUpdated struct {
+	UserID  int64
+	Created bool
+}
+
+func (ProfileUpdated) Name() string { return "profile.updated" }
+
+// PreferencesChanged — пользователь изменил настройки поиска.
+type PreferencesChanged struct {
+	UserID int64
+}
+
+func (PreferencesChanged) Name() string { return "preferences.changed" }
+
+// Handler обрабатывает событие.
+type Handler func(ctx context.Context, e Event) error
+
+// Publisher публикует события.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Bus — шина событий: публикация и подписка по имени события.
+type Bus interface {
+	Publisher
+	Subscribe(name string, h Handler)
+}
+
+// On подписывает типизированный обработчик на события типа T.
+func On[T Event](bus Bus, h func(ctx context.Context, e T) error) {
+	var zero T
+	bus.Subscribe(zero.Name(), func(ctx context.Context, e Event) error {
+		typed, ok := e.(T)
+		if !ok {
+			return fmt.Errorf("unexpected event type %T for %s", e, zero.Name())
+		}
+		return h(ctx, typed)
+	})
+}
+
+// SyncBus вызывает подписчиков сразу в горутине публикующего. Ошибка одного
+// подписчика не мешает остальным; все ошибки возвращаются вместе.
+type SyncBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	logger   *zap.Logger
+}
+
+func NewSyncBus(logger *zap.Logger) *SyncBus {
+	return &SyncBus{
+		handlers: make(map[string][]Handler),
+		logger:   logger,
+	}
+}
+
+func (b *SyncBus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], h)
+}
+
+func (b *SyncBus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	handlers := b.handlers[e.Name()]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			b.logger.Error("Event handler failed",
+				zap.String("event", e.Name()),
+				zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Multi публикует событие во все переданные шины по очереди.
+type Multi []Publisher
+
+func (m Multi) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
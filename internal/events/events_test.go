@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSyncBusCallsEverySubscriber(t *testing.T) {
+	bus := NewSyncBus(zap.NewNop())
+	errFirst := errors.New("first failed")
+	var calls []string
+	bus.Subscribe(LikeCreated{}.Name(), func(context.Context, Event) error {
+		calls = append(calls, "first")
+		return errFirst
+	})
+	On(bus, func(_ context.Context, e LikeCreated) error {
+		calls = append(calls, "typed")
+		if e.ToUserID != 2 {
+			t.Errorf("event = %+v", e)
+		}
+		return nil
+	})
+	On(bus, func(context.Context, MatchCreated) error {
+		calls = append(calls, "match")
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), LikeCreated{FromUserID: 1, ToUserID: 2})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("Publish error = %v, want %v", err, errFirst)
+	}
+	// Ошибка первого подписчика не мешает остальным
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "typed" {
+		t.Errorf("calls = %v, want [first typed]", calls)
+	}
+}
+
+func TestMultiPublishesToEveryBus(t *testing.T) {
+	first, second := NewSyncBus(zap.NewNop()), NewSyncBus(zap.NewNop())
+	errSecond := errors.New("second failed")
+	var got []int64
+	On(first, func(_ context.Context, e UserBlocked) error {
+		got = append(got, e.BlockerID)
+		return nil
+	})
+	On(second, func(_ context.Context, e UserBlocked) error {
+		got = append(got, e.BlockedID)
+		return errSecond
+	})
+
+	err := Multi{first, second}.Publish(context.Background(), UserBlocked{BlockerID: 1, BlockedID: 2})
+	if !errors.Is(err, errSecond) {
+		t.Errorf("Publish error = %v, want %v", err, errSecond)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got = %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,162 @@
+package matching
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"go.uber.org/zap"
+)
+
+// Result — итог обработки лайка.
+type Result int
+
+const (
+	// Liked — лайк сохранён, взаимности пока нет.
+	Liked Result = iota + 1
+	// AlreadyLiked — пользователь уже лайкал этот профиль.
+	AlreadyLiked
+	// Matched — встречный лайк найден, образовалась пара.
+	Matched
+)
+
+func (r Result) String() string {
+	switch r {
+	case Liked:
+		return "liked"
+	case AlreadyLiked:
+		return "already_liked"
+	case Matched:
+		return "matched"
+	default:
+		return "unknown"
+	}
+}
+
+// Repository открывает транзакцию, в рамках которой сервис принимает решение.
+type Repository interface {
+	InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
+}
+
+// Tx — операции над лайками внутри одной транзакции.
+type Tx interface {
+	// LockUsers блокирует строки пользователей, чтобы встречные лайки одной
+	// пары обрабатывались последовательно.
+	LockUsers(ctx context.Context, userIDs ...int64) error
+	// HasLike сообщает, есть ли действующий лайк fromUserID -> toUserID.
+	HasLike(ctx context.Context, fromUserID, toUserID int64) (bool, error)
+	// InsertLike сохраняет лайк и возвращает false, если он уже существует.
+	InsertLike(ctx context.Context, like *db.Like) (bool, error)
+	// DeleteLike удаляет лайк fromUserID -> toUserID, если он есть.
+	DeleteLike(ctx context.Context, fromUserID, toUserID int64) error
+	// InsertMatch сохраняет пару; повторная вставка не является ошибкой.
+	InsertMatch(ctx context.Context, match *db.Match) error
+}
+
+type Service struct {
+	repo   Repository
+	logger *zap.Logger
+}
+
+func NewService(repo Repository, logger *zap.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Like обрабатывает лайк fromUserID -> toUserID: сохраняет его или, если
+// встречный лайк уже есть, удаляет оба, сохраняет пару и возвращает Matched.
+func (s *Service) Like(ctx context.Context, like *db.Like) (Result, error) {
+	fromUserID, toUserID := like.FromUserID, like.ToUserID
+	if fromUserID == toUserID {
+		return 0, fmt.Errorf("user %d cannot like themselves", fromUserID)
+	}
+
+	var result Result
+	err := s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		if err := tx.LockUsers(ctx, fromUserID, toUserID); err != nil {
+			return fmt.Errorf("failed to lock users: %w", err)
+		}
+
+		reciprocal, err := tx.HasLike(ctx, toUserID, fromUserID)
+		if err != nil {
+			return fmt.Errorf("failed to check reciprocal like: %w", err)
+		}
+
+		if !reciprocal {
+			inserted, err := tx.InsertLike(ctx, like)
+			if err != nil {
+				return fmt.Errorf("failed to insert like: %w", err)
+			}
+			if !inserted {
+				result = AlreadyLiked
+				return nil
+			}
+			result = Liked
+			return nil
+		}
+
+		if err := tx.DeleteLike(ctx, toUserID, fromUserID); err != nil {
+			return fmt.Errorf("failed to delete reciprocal like: %w", err)
+		}
+		if err := tx.DeleteLike(ctx, fromUserID, toUserID); err != nil {
+			return fmt.Errorf("failed to delete like: %w", err)
+		}
+		if err := tx.InsertMatch(ctx, db.NewMatch(fromUserID, toUserID)); err != nil {
+			return fmt.Errorf("failed to insert match: %w", err)
+		}
+		result = Matched
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to process like",
+			zap.Int64("from_user_id", fromUserID),
+			zap.Int64("to_user_id", toUserID),
+			zap.Error(err))
+		return 0, err
+	}
+
+	s.logger.Info("Like processed",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+		zap.Stringer("result", result))
+	return result, nil
+}
+
+// Withdraw отменяет лайк fromUserID -> toUserID. Возвращает false, если
+// лайка уже нет: например, он стал взаимным и превратился в пару.
+func (s *Service) Withdraw(ctx context.Context, fromUserID, toUserID int64) (bool, error) {
+	var withdrawn bool
+	err := s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		if err := tx.LockUsers(ctx, fromUserID, toUserID); err != nil {
+			return fmt.Errorf("failed to lock users: %w", err)
+		}
+
+		exists, err := tx.HasLike(ctx, fromUserID, toUserID)
+		if err != nil {
+			return fmt.Errorf("failed to check like: %w", err)
+		}
+		if !exists {
+			return nil
+		}
+		if err := tx.DeleteLike(ctx, fromUserID, toUserID); err != nil {
+			return fmt.Errorf("failed to delete like: %w", err)
+		}
+		withdrawn = true
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to withdraw like",
+			zap.Int64("from_user_id", fromUserID),
+			zap.Int64("to_user_id", toUserID),
+			zap.Error(err))
+		return false, err
+	}
+
+	s.logger.Info("Like withdrawal processed",
+		zap.Int64("from_user_id", fromUserID),
+		zap.Int64("to_user_id", toUserID),
+		zap.Bool("withdrawn", withdrawn))
+	return withdrawn, nil
+}
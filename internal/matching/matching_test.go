@@ -0,0 +1,225 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"go.uber.org/zap"
+)
+
+type pair struct{ from, to int64 }
+
+// fakeRepo хранит лайки и пары в памяти. Транзакции выполняются по одной,
+// а при ошибке изменения откатываются, как в Postgres.
+type fakeRepo struct {
+	mu      sync.Mutex
+	likes   map[pair]*db.Like
+	matches map[pair]bool
+	// failMatch заставляет InsertMatch вернуть ошибку
+	failMatch bool
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		likes:   make(map[pair]*db.Like),
+		matches: make(map[pair]bool),
+	}
+}
+
+func (r *fakeRepo) InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &fakeTx{
+		likes:     make(map[pair]*db.Like, len(r.likes)),
+		matches:   make(map[pair]bool, len(r.matches)),
+		failMatch: r.failMatch,
+	}
+	for k, v := range r.likes {
+		tx.likes[k] = v
+	}
+	for k, v := range r.matches {
+		tx.matches[k] = v
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	r.likes, r.matches = tx.likes, tx.matches
+	return nil
+}
+
+type fakeTx struct {
+	likes     map[pair]*db.Like
+	matches   map[pair]bool
+	failMatch bool
+}
+
+func (t *fakeTx) LockUsers(context.Context, ...int64) error { return nil }
+
+func (t *fakeTx) HasLike(_ context.Context, from, to int64) (bool, error) {
+	_, ok := t.likes[pair{from, to}]
+	return ok, nil
+}
+
+func (t *fakeTx) InsertLike(_ context.Context, like *db.Like) (bool, error) {
+	key := pair{like.FromUserID, like.ToUserID}
+	if _, ok := t.likes[key]; ok {
+		return false, nil
+	}
+	t.likes[key] = like
+	return true, nil
+}
+
+func (t *fakeTx) DeleteLike(_ context.Context, from, to int64) error {
+	delete(t.likes, pair{from, to})
+	return nil
+}
+
+func (t *fakeTx) InsertMatch(_ context.Context, match *db.Match) error {
+	if t.failMatch {
+		return errors.New("insert failed")
+	}
+	t.matches[pair{match.User1ID, match.User2ID}] = true
+	return nil
+}
+
+func like(from, to int64) *db.Like {
+	return &db.Like{FromUserID: from, ToUserID: to}
+}
+
+func TestLike(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	res, err := s.Like(ctx, like(1, 2))
+	if err != nil || res != Liked {
+		t.Fatalf("first like = %v, %v; want Liked", res, err)
+	}
+	res, err = s.Like(ctx, like(1, 2))
+	if err != nil || res != AlreadyLiked {
+		t.Fatalf("repeated like = %v, %v; want AlreadyLiked", res, err)
+	}
+	if len(repo.likes) != 1 {
+		t.Errorf("likes = %d, want 1", len(repo.likes))
+	}
+
+	res, err = s.Like(ctx, like(2, 1))
+	if err != nil || res != Matched {
+		t.Fatalf("reciprocal like = %v, %v; want Matched", res, err)
+	}
+	if len(repo.likes) != 0 {
+		t.Errorf("likes left after match: %v", repo.likes)
+	}
+	if len(repo.matches) != 1 {
+		t.Errorf("matches = %d, want 1", len(repo.matches))
+	}
+}
+
+func TestLikeSelf(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, zap.NewNop())
+
+	if _, err := s.Like(context.Background(), like(3, 3)); err == nil {
+		t.Fatal("self-like succeeded")
+	}
+	if len(repo.likes) != 0 {
+		t.Errorf("self-like was stored")
+	}
+}
+
+func TestLikeRollsBackFailedMatch(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := s.Like(ctx, like(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	repo.failMatch = true
+	if _, err := s.Like(ctx, like(2, 1)); err == nil {
+		t.Fatal("like succeeded although the match was not saved")
+	}
+	// Встречный лайк не должен пропасть вместе с несохранённой парой
+	if _, ok := repo.likes[pair{1, 2}]; !ok {
+		t.Error("reciprocal like was deleted by a failed transaction")
+	}
+	if len(repo.matches) != 0 {
+		t.Errorf("matches = %v, want none", repo.matches)
+	}
+}
+
+func TestConcurrentReciprocalLikesMatchOnce(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		repo := newFakeRepo()
+		s := NewService(repo, zap.NewNop())
+
+		results := make([]Result, 2)
+		var wg sync.WaitGroup
+		for j, l := range []*db.Like{like(1, 2), like(2, 1)} {
+			wg.Add(1)
+			go func(j int, l *db.Like) {
+				defer wg.Done()
+				res, err := s.Like(context.Background(), l)
+				if err != nil {
+					t.Error(err)
+				}
+				results[j] = res
+			}(j, l)
+		}
+		wg.Wait()
+
+		matched := 0
+		for _, res := range results {
+			if res == Matched {
+				matched++
+			}
+		}
+		if matched != 1 {
+			t.Fatalf("results = %v, want exactly one Matched", results)
+		}
+		if len(repo.likes) != 0 || len(repo.matches) != 1 {
+			t.Fatalf("likes = %v, matches = %v", repo.likes, repo.matches)
+		}
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := s.Like(ctx, like(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	withdrawn, err := s.Withdraw(ctx, 1, 2)
+	if err != nil || !withdrawn {
+		t.Fatalf("Withdraw = %v, %v; want true", withdrawn, err)
+	}
+	if len(repo.likes) != 0 {
+		t.Errorf("like was not deleted: %v", repo.likes)
+	}
+}
+
+func TestWithdrawAfterMatch(t *testing.T) {
+	repo := newFakeRepo()
+	s := NewService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := s.Like(ctx, like(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Like(ctx, like(2, 1)); err != nil || res != Matched {
+		t.Fatalf("reciprocal like = %v, %v; want Matched", res, err)
+	}
+	withdrawn, err := s.Withdraw(ctx, 1, 2)
+	if err != nil || withdrawn {
+		t.Fatalf("Withdraw = %v, %v; want false", withdrawn, err)
+	}
+	if len(repo.matches) != 1 {
+		t.Errorf("matches = %v, want the pair to stay", repo.matches)
+	}
+}
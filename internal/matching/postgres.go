@@ -0,0 +1,131 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type pgRepository struct {
+	pool    *pgxpool.Pool
+	sq      squirrel.StatementBuilderType
+	likeTTL time.Duration
+}
+
+// NewPostgresRepository создаёт репозиторий, выставляющий новым лайкам
+// срок жизни likeTTL.
+func NewPostgresRepository(pool *pgxpool.Pool, sq squirrel.StatementBuilderType, likeTTL time.Duration) Repository {
+	return &pgRepository{
+		pool:    pool,
+		sq:      sq,
+		likeTTL: likeTTL,
+	}
+}
+
+func (r *pgRepository) InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return fn(ctx, &pgTx{tx: tx, sq: r.sq, likeTTL: r.likeTTL})
+	})
+}
+
+type pgTx struct {
+	tx      pgx.Tx
+	sq      squirrel.StatementBuilderType
+	likeTTL time.Duration
+}
+
+func (t *pgTx) LockUsers(ctx context.Context, userIDs ...int64) error {
+	qb, args, err := t.sq.Select(db.UsersID).
+		From(db.UsersTable).
+		Where(squirrel.Eq{db.UsersID: userIDs}).
+		OrderBy(db.UsersID).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	rows, err := t.tx.Query(ctx, qb, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+func (t *pgTx) HasLike(ctx context.Context, fromUserID, toUserID int64) (bool, error) {
+	var exists bool
+	err := t.tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM likes
+			WHERE from_user_id = $1 AND to_user_id = $2 AND expires_at > NOW()
+		)`, fromUserID, toUserID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return exists, nil
+}
+
+func (t *pgTx) InsertLike(ctx context.Context, like *db.Like) (bool, error) {
+	qb, args, err := t.sq.Insert(db.LikesTable).
+		Columns(db.LikesFromUserID, db.LikesToUserID, db.LikesMessage, db.LikesExpiresAt).
+		Values(like.FromUserID, like.ToUserID, like.Message,
+			squirrel.Expr("now() + make_interval(secs => ?)", t.likeTTL.Seconds())).
+		// Просроченный, но ещё не вычищенный лайк перезаписываем как новый
+		Suffix(`ON CONFLICT (from_user_id, to_user_id) DO UPDATE
+			SET message = EXCLUDED.message,
+			    created_at = now(),
+			    expires_at = EXCLUDED.expires_at,
+			    expiry_reminded_at = NULL
+			WHERE likes.expires_at <= now()
+			RETURNING *`).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+	err = pgxscan.Get(ctx, t.tx, like, qb, args...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return true, nil
+}
+
+func (t *pgTx) DeleteLike(ctx context.Context, fromUserID, toUserID int64) error {
+	qb, args, err := t.sq.Delete(db.LikesTable).
+		Where(squirrel.Eq{
+			db.LikesFromUserID: fromUserID,
+			db.LikesToUserID:   toUserID,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := t.tx.Exec(ctx, qb, args...); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
+
+func (t *pgTx) InsertMatch(ctx context.Context, match *db.Match) error {
+	qb, args, err := t.sq.Insert(db.MatchesTable).
+		Columns(db.MatchesUser1ID, db.MatchesUser2ID).
+		Values(match.User1ID, match.User2ID).
+		Suffix("ON CONFLICT (user1_id, user2_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := t.tx.Exec(ctx, qb, args...); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
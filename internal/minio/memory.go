@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MemoryStorage хранит файлы в памяти процесса. Используется в тестах и
+// локальной разработке вместо MinIO.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+	// open — число выданных и ещё не закрытых читателей
+	open int
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		objects: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryStorage) UploadFile(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectName] = data
+	return "memory://" + objectName, nil
+}
+
+func (m *MemoryStorage) GetFile(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[objectName]
+	if !ok {
+		return nil, fmt.Errorf("failed to get file: object %s not found", objectName)
+	}
+	m.open++
+	return &memoryFile{Reader: bytes.NewReader(data), storage: m}, nil
+}
+
+// Open возвращает число читателей, которые получили через GetFile и ещё не
+// закрыли.
+func (m *MemoryStorage) Open() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.open
+}
+
+// memoryFile — читатель файла из MemoryStorage, который отмечает закрытие.
+type memoryFile struct {
+	*bytes.Reader
+	storage *MemoryStorage
+	closed  bool
+}
+
+func (f *memoryFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.storage.mu.Lock()
+	defer f.storage.mu.Unlock()
+	f.storage.open--
+	return nil
+}
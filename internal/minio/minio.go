@@ -10,6 +10,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// FileStorage описывает хранилище файлов (фото профилей).
+type FileStorage interface {
+	UploadFile(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error)
+	GetFile(ctx context.Context, objectName string) (io.ReadCloser, error)
+}
+
 type MinioClient struct {
 	client *minio.Client
 	bucket string
@@ -63,7 +69,7 @@ func (m *MinioClient) UploadFile(ctx context.Context, objectName string, reader
 	return url, nil
 }
 
-func (m *MinioClient) GetFile(ctx context.Context, objectName string) (io.Reader, error) {
+func (m *MinioClient) GetFile(ctx context.Context, objectName string) (io.ReadCloser, error) {
 	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
 		m.logger.Error("Failed to get file from MinIO",
@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ViewLikesCallback — данные кнопки, открывающей «Кто меня лайкнул».
+const ViewLikesCallback = "view_likes"
+
+// Button — inline-кнопка уведомления.
+type Button struct {
+	Text         string
+	CallbackData string
+}
+
+// Message — уведомление пользователю.
+type Message struct {
+	Text      string
+	ParseMode string     `json:",omitempty"`
+	Buttons   [][]Button `json:",omitempty"`
+}
+
+// Sender доставляет уведомления. В тестах подменяется реализацией,
+// сохраняющей отправленные сообщения.
+type Sender interface {
+	Send(ctx context.Context, userID int64, msg Message) error
+}
+
+// Settings сообщает, хочет ли пользователь получать уведомления о лайках.
+type Settings interface {
+	LikeNotificationsEnabled(ctx context.Context, userID int64) (bool, error)
+}
+
+// Store хранит накопленные лайки и время последнего уведомления.
+type Store interface {
+	// AddPending увеличивает счётчик неотправленных лайков пользователя.
+	AddPending(ctx context.Context, userID int64) error
+	// TakePending возвращает счётчик и обнуляет его.
+	TakePending(ctx context.Context, userID int64) (int, error)
+	// RestorePending возвращает в счётчик лайки, взятые TakePending, но не
+	// доставленные.
+	RestorePending(ctx context.Context, userID int64, count int) error
+	// RemovePending уменьшает счётчик на отозванный лайк. Если лайк уже
+	// попал в отправленное уведомление и счётчик пуст, ничего не меняет.
+	RemovePending(ctx context.Context, userID int64) error
+	// PendingUsers возвращает пользователей с ненулевым счётчиком.
+	PendingUsers(ctx context.Context) ([]int64, error)
+	// MarkSent отмечает отправку уведомления, если за последние interval
+	// уведомлений не было, и возвращает false, если были.
+	MarkSent(ctx context.Context, userID int64, interval time.Duration) (bool, error)
+	// ClearSent снимает отметку об отправке, если уведомление не ушло.
+	ClearSent(ctx context.Context, userID int64) error
+}
+
+// QuietHours — часы, в которые уведомления не отправляются, а копятся до
+// утреннего дайджеста. From и To — часы суток в Location; интервал может
+// переходить через полночь (23–9).
+type QuietHours struct {
+	From     int
+	To       int
+	Location *time.Location
+}
+
+// Contains сообщает, попадает ли t в тихие часы.
+func (q QuietHours) Contains(t time.Time) bool {
+	if q.From == q.To {
+		return false
+	}
+	h := t.In(q.Location).Hour()
+	if q.From < q.To {
+		return h >= q.From && h < q.To
+	}
+	return h >= q.From || h < q.To
+}
+
+// Service уведомляет пользователей о новых лайках. Первый лайк после паузы
+// приходит сразу, последующие в течение digest копятся и отправляются одним
+// сообщением при следующем вызове Flush.
+type Service struct {
+	sender   Sender
+	store    Store
+	settings Settings
+	quiet    QuietHours
+	digest   time.Duration
+	now      func() time.Time
+	logger   *zap.Logger
+}
+
+func NewService(sender Sender, store Store, settings Settings, quiet QuietHours, digest time.Duration, logger *zap.Logger) *Service {
+	return &Service{
+		sender:   sender,
+		store:    store,
+		settings: settings,
+		quiet:    quiet,
+		digest:   digest,
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+// SetClock подменяет источник времени; используется в тестах.
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// LikeReceived учитывает новый лайк пользователю и, если можно, сразу
+// уведомляет его.
+func (s *Service) LikeReceived(ctx context.Context, userID int64) error {
+	enabled, err := s.settings.LikeNotificationsEnabled(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	if !enabled {
+		return nil
+	}
+
+	if err := s.store.AddPending(ctx, userID); err != nil {
+		return fmt.Errorf("failed to add pending like: %w", err)
+	}
+	if s.quiet.Contains(s.now()) {
+		return nil
+	}
+	return s.deliver(ctx, userID)
+}
+
+// LikeWithdrawn убирает отменённый лайк из ещё не отправленного дайджеста.
+func (s *Service) LikeWithdrawn(ctx context.Context, userID int64) error {
+	if err := s.store.RemovePending(ctx, userID); err != nil {
+		return fmt.Errorf("failed to remove pending like: %w", err)
+	}
+	return nil
+}
+
+// Flush отправляет накопленные дайджесты тем, кому уже можно писать.
+func (s *Service) Flush(ctx context.Context) error {
+	if s.quiet.Contains(s.now()) {
+		return nil
+	}
+
+	userIDs, err := s.store.PendingUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending users: %w", err)
+	}
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		enabled, err := s.settings.LikeNotificationsEnabled(ctx, userID)
+		if err != nil {
+			s.logger.Error("Failed to get notification settings",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+			continue
+		}
+		if !enabled {
+			// Уведомления выключили, пока лайки копились
+			if _, err := s.store.TakePending(ctx, userID); err != nil {
+				s.logger.Error("Failed to drop pending likes",
+					zap.Int64("user_id", userID),
+					zap.Error(err))
+			}
+			continue
+		}
+		if err := s.deliver(ctx, userID); err != nil {
+			s.logger.Error("Failed to send likes digest",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// deliver отправляет накопленные лайки, если с прошлого уведомления прошло
+// не меньше digest. Отметка об отправке и счётчик забираются до отправки,
+// чтобы параллельные вызовы не прислали одно уведомление дважды; если
+// отправить не удалось, они возвращаются, и лайки уйдут следующим дайджестом.
+func (s *Service) deliver(ctx context.Context, userID int64) error {
+	ok, err := s.store.MarkSent(ctx, userID, s.digest)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	count, err := s.store.TakePending(ctx, userID)
+	if err != nil {
+		s.clearSent(userID)
+		return fmt.Errorf("failed to take pending likes: %w", err)
+	}
+	if count == 0 {
+		s.clearSent(userID)
+		return nil
+	}
+
+	if err := s.sender.Send(ctx, userID, likesMessage(count)); err != nil {
+		// Контекст запроса мог быть уже отменён, а вернуть лайки нужно в
+		// любом случае
+		if err := s.store.RestorePending(context.Background(), userID, count); err != nil {
+			s.logger.Error("Failed to restore pending likes",
+				zap.Int64("user_id", userID),
+				zap.Int("likes", count),
+				zap.Error(err))
+		}
+		s.clearSent(userID)
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	s.logger.Info("Like notification sent",
+		zap.Int64("user_id", userID),
+		zap.Int("likes", count))
+	return nil
+}
+
+// clearSent снимает отметку об уведомлении, которое не было отправлено.
+func (s *Service) clearSent(userID int64) {
+	if err := s.store.ClearSent(context.Background(), userID); err != nil {
+		s.logger.Error("Failed to clear notification mark",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+func likesMessage(count int) Message {
+	text := "Вас кто-то лайкнул ❤️"
+	if count > 1 {
+		text = fmt.Sprintf("У вас %d %s ❤️", count, PluralRu(count, "новый лайк", "новых лайка", "новых лайков"))
+	}
+	return Message{
+		Text:    text,
+		Buttons: [][]Button{{{Text: "❤️ Посмотреть", CallbackData: ViewLikesCallback}}},
+	}
+}
+
+// PluralRu выбирает форму слова для числа n: «1 лайк», «2 лайка», «5 лайков».
+func PluralRu(n int, one, few, many string) string {
+	switch {
+	case n%100 >= 11 && n%100 <= 14:
+		return many
+	case n%10 == 1:
+		return one
+	case n%10 >= 2 && n%10 <= 4:
+		return few
+	default:
+		return many
+	}
+}
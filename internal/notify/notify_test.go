@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const userID = 42
+
+// capturingSender сохраняет отправленные уведомления. Пока fail не nil,
+// отправка завершается этой ошибкой.
+type capturingSender struct {
+	mu   sync.Mutex
+	sent []Message
+	fail error
+}
+
+func (s *capturingSender) Send(_ context.Context, _ int64, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail != nil {
+		return s.fail
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *capturingSender) setFail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = err
+}
+
+func (s *capturingSender) texts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	texts := make([]string, 0, len(s.sent))
+	for _, msg := range s.sent {
+		texts = append(texts, msg.Text)
+	}
+	return texts
+}
+
+type fakeSettings map[int64]bool
+
+func (f fakeSettings) LikeNotificationsEnabled(_ context.Context, userID int64) (bool, error) {
+	enabled, ok := f[userID]
+	return enabled || !ok, nil
+}
+
+type testService struct {
+	*Service
+	sender *capturingSender
+	store  *RedisStore
+	mr     *miniredis.Miniredis
+	now    time.Time
+}
+
+// newTestService создаёт сервис с дайджестом раз в час и тихими часами
+// 23–9 по UTC. Часы стоят на полудне.
+func newTestService(t *testing.T, settings fakeSettings) *testService {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	ts := &testService{
+		sender: &capturingSender{},
+		store:  NewRedisStore(client),
+		mr:     mr,
+		now:    time.Date(2025, 4, 21, 12, 0, 0, 0, time.UTC),
+	}
+	quiet := QuietHours{From: 23, To: 9, Location: time.UTC}
+	ts.Service = NewService(ts.sender, ts.store, settings, quiet, time.Hour, zap.NewNop())
+	ts.SetClock(func() time.Time { return ts.now })
+	return ts
+}
+
+// advance переводит часы сервиса и Redis.
+func (ts *testService) advance(d time.Duration) {
+	ts.now = ts.now.Add(d)
+	ts.mr.FastForward(d)
+}
+
+func (ts *testService) like(t *testing.T) {
+	t.Helper()
+	if err := ts.LikeReceived(context.Background(), userID); err != nil {
+		t.Fatalf("LikeReceived: %v", err)
+	}
+}
+
+func (ts *testService) flush(t *testing.T) {
+	t.Helper()
+	if err := ts.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+}
+
+func assertTexts(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("sent = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sent = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestFirstLikeIsSentAndNextAreDigested(t *testing.T) {
+	ts := newTestService(t, nil)
+
+	ts.like(t)
+	assertTexts(t, ts.sender.texts(), "Вас кто-то лайкнул ❤️")
+
+	ts.like(t)
+	ts.like(t)
+	ts.flush(t)
+	assertTexts(t, ts.sender.texts(), "Вас кто-то лайкнул ❤️")
+
+	ts.advance(time.Hour)
+	ts.flush(t)
+	assertTexts(t, ts.sender.texts(), "Вас кто-то лайкнул ❤️", "У вас 2 новых лайка ❤️")
+
+	// Дайджест отправлен, копить больше нечего
+	ts.advance(time.Hour)
+	ts.flush(t)
+	if got := ts.sender.texts(); len(got) != 2 {
+		t.Errorf("sent = %q, want no repeated digest", got)
+	}
+}
+
+func TestQuietHoursHoldLikesUntilMorning(t *testing.T) {
+	ts := newTestService(t, nil)
+	ts.now = time.Date(2025, 4, 21, 23, 30, 0, 0, time.UTC)
+
+	ts.like(t)
+	ts.like(t)
+	ts.flush(t)
+	if got := ts.sender.texts(); len(got) != 0 {
+		t.Fatalf("sent during quiet hours: %q", got)
+	}
+
+	ts.advance(9*time.Hour + 30*time.Minute)
+	ts.flush(t)
+	assertTexts(t, ts.sender.texts(), "У вас 2 новых лайка ❤️")
+}
+
+func TestDisabledNotifications(t *testing.T) {
+	ts := newTestService(t, fakeSettings{userID: false})
+
+	ts.like(t)
+	ts.flush(t)
+	if got := ts.sender.texts(); len(got) != 0 {
+		t.Errorf("sent = %q, want nothing", got)
+	}
+	users, err := ts.store.PendingUsers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("pending users = %v, want none", users)
+	}
+}
+
+func TestFailedSendKeepsLikesPending(t *testing.T) {
+	ts := newTestService(t, nil)
+	ts.sender.setFail(errors.New("telegram is down"))
+
+	// Каждый лайк пробует отправить уведомление заново
+	for range 2 {
+		if err := ts.LikeReceived(context.Background(), userID); err == nil {
+			t.Fatal("LikeReceived succeeded while sending failed")
+		}
+	}
+	if got := ts.sender.texts(); len(got) != 0 {
+		t.Fatalf("sent = %q", got)
+	}
+
+	// Отправка снова работает: лайки не потеряны, и отметка о неудачной
+	// отправке не задерживает дайджест на час
+	ts.sender.setFail(nil)
+	ts.flush(t)
+	assertTexts(t, ts.sender.texts(), "У вас 2 новых лайка ❤️")
+}
+
+func TestWithdrawnLikeLeavesDigest(t *testing.T) {
+	ts := newTestService(t, nil)
+	ctx := context.Background()
+
+	ts.like(t)
+	ts.like(t)
+	ts.like(t)
+	// Оба лайка, ждущие дайджеста, отозвали: присылать нечего
+	for range 2 {
+		if err := ts.LikeWithdrawn(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts.advance(time.Hour)
+	ts.flush(t)
+	assertTexts(t, ts.sender.texts(), "Вас кто-то лайкнул ❤️")
+
+	// Отзыв уже отправленного лайка не уводит счётчик в минус
+	if err := ts.LikeWithdrawn(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	users, err := ts.store.PendingUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("pending users = %v, want none", users)
+	}
+	ts.like(t)
+	assertTexts(t, ts.sender.texts(), "Вас кто-то лайкнул ❤️", "Вас кто-то лайкнул ❤️")
+}
+
+func TestPluralRu(t *testing.T) {
+	tests := map[int]string{
+		1: "лайк", 2: "лайка", 4: "лайка", 5: "лайков", 11: "лайков",
+		12: "лайков", 14: "лайков", 21: "лайк", 22: "лайка", 111: "лайков",
+	}
+	for n, want := range tests {
+		if got := PluralRu(n, "лайк", "лайка", "лайков"); got != want {
+			t.Errorf("PluralRu(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	night := QuietHours{From: 23, To: 9, Location: time.UTC}
+	day := QuietHours{From: 13, To: 15, Location: time.UTC}
+	tests := []struct {
+		quiet QuietHours
+		hour  int
+		want  bool
+	}{
+		{night, 22, false},
+		{night, 23, true},
+		{night, 0, true},
+		{night, 8, true},
+		{night, 9, false},
+		{day, 12, false},
+		{day, 13, true},
+		{day, 15, false},
+		{QuietHours{From: 9, To: 9, Location: time.UTC}, 9, false},
+	}
+	for _, tt := range tests {
+		at := time.Date(2025, 4, 21, tt.hour, 30, 0, 0, time.UTC)
+		if got := tt.quiet.Contains(at); got != tt.want {
+			t.Errorf("%+v.Contains(%d:30) = %v, want %v", tt.quiet, tt.hour, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	pendingKey    = "notify:v1:likes:pending"
+	sentKeyPrefix = "notify:v1:likes:sent:"
+)
+
+// takeScript атомарно читает и удаляет счётчик, чтобы лайк, пришедший
+// между чтением и удалением, не потерялся.
+var takeScript = redis.NewScript(`
+local count = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+return tonumber(count) or 0
+`)
+
+// removeScript уменьшает счётчик на единицу, не опуская его ниже нуля, и
+// удаляет пустой, чтобы пользователь пропал из PendingUsers.
+var removeScript = redis.NewScript(`
+local count = tonumber(redis.call('HGET', KEYS[1], ARGV[1])) or 0
+if count <= 1 then
+    redis.call('HDEL', KEYS[1], ARGV[1])
+    return 0
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+`)
+
+// RedisStore хранит счётчики всех пользователей в одном хэше, а отметки об
+// отправке — в ключах с TTL.
+type RedisStore struct {
+	redis *redis.Client
+}
+
+func NewRedisStore(redisClient *redis.Client) *RedisStore {
+	return &RedisStore{redis: redisClient}
+}
+
+func (s *RedisStore) AddPending(ctx context.Context, userID int64) error {
+	if err := s.redis.HIncrBy(ctx, pendingKey, strconv.FormatInt(userID, 10), 1).Err(); err != nil {
+		return fmt.Errorf("failed to increment pending likes: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) TakePending(ctx context.Context, userID int64) (int, error) {
+	count, err := takeScript.Run(ctx, s.redis, []string{pendingKey}, strconv.FormatInt(userID, 10)).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to take pending likes: %w", err)
+	}
+	return count, nil
+}
+
+func (s *RedisStore) RestorePending(ctx context.Context, userID int64, count int) error {
+	if err := s.redis.HIncrBy(ctx, pendingKey, strconv.FormatInt(userID, 10), int64(count)).Err(); err != nil {
+		return fmt.Errorf("failed to restore pending likes: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) RemovePending(ctx context.Context, userID int64) error {
+	if err := removeScript.Run(ctx, s.redis, []string{pendingKey}, strconv.FormatInt(userID, 10)).Err(); err != nil {
+		return fmt.Errorf("failed to remove pending like: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) PendingUsers(ctx context.Context) ([]int64, error) {
+	fields, err := s.redis.HKeys(ctx, pendingKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending likes: %w", err)
+	}
+	userIDs := make([]int64, 0, len(fields))
+	for _, f := range fields {
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, nil
+}
+
+func (s *RedisStore) MarkSent(ctx context.Context, userID int64, interval time.Duration) (bool, error) {
+	ok, err := s.redis.SetNX(ctx, sentKeyPrefix+strconv.FormatInt(userID, 10), 1, interval).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *RedisStore) ClearSent(ctx context.Context, userID int64) error {
+	if err := s.redis.Del(ctx, sentKeyPrefix+strconv.FormatInt(userID, 10)).Err(); err != nil {
+		return fmt.Errorf("failed to clear notification mark: %w", err)
+	}
+	return nil
+}
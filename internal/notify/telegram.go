@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// TelegramSender отправляет уведомления личным сообщением от бота.
+type TelegramSender struct {
+	bot *gotgbot.Bot
+}
+
+func NewTelegramSender(bot *gotgbot.Bot) *TelegramSender {
+	return &TelegramSender{bot: bot}
+}
+
+func (s *TelegramSender) Send(_ context.Context, userID int64, msg Message) error {
+	opts := &gotgbot.SendMessageOpts{ParseMode: msg.ParseMode}
+	if len(msg.Buttons) > 0 {
+		keyboard := make([][]gotgbot.InlineKeyboardButton, 0, len(msg.Buttons))
+		for _, row := range msg.Buttons {
+			buttons := make([]gotgbot.InlineKeyboardButton, 0, len(row))
+			for _, b := range row {
+				buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: b.Text, CallbackData: b.CallbackData})
+			}
+			keyboard = append(keyboard, buttons)
+		}
+		opts.ReplyMarkup = gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
+	_, err := s.bot.SendMessage(userID, msg.Text, opts)
+	return err
+}
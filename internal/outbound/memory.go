@@ -0,0 +1,140 @@
+package outbound
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryBroker — очередь в памяти процесса для тестов и локального запуска.
+// Сообщения не переживают перезапуск. Отложенные и возвращённые в очередь
+// сообщения хранятся отдельно от буфера, поэтому их публикация не
+// блокируется, даже если буфер заполнен.
+type MemoryBroker struct {
+	queue chan []byte
+	// wake будит потребителей, когда появляется отложенное сообщение
+	wake         chan struct{}
+	requeueDelay time.Duration
+
+	mu      sync.Mutex
+	delayed []delayedMessage
+	dead    [][]byte
+}
+
+type delayedMessage struct {
+	body []byte
+	at   time.Time
+}
+
+func NewMemoryBroker(size int) *MemoryBroker {
+	return &MemoryBroker{
+		queue:        make(chan []byte, size),
+		wake:         make(chan struct{}, 1),
+		requeueDelay: requeueDelay,
+	}
+}
+
+func (m *MemoryBroker) Publish(ctx context.Context, body []byte, delay time.Duration) error {
+	if delay > 0 {
+		m.schedule(body, delay)
+		return nil
+	}
+	select {
+	case m.queue <- body:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *MemoryBroker) schedule(body []byte, delay time.Duration) {
+	m.mu.Lock()
+	m.delayed = append(m.delayed, delayedMessage{body: body, at: time.Now().Add(delay)})
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// due забирает отложенное сообщение, время которого наступило. Если таких
+// нет, возвращает, сколько ждать ближайшего, или 0, если ждать нечего.
+func (m *MemoryBroker) due() ([]byte, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var next time.Duration
+	for i, msg := range m.delayed {
+		wait := msg.at.Sub(now)
+		if wait <= 0 {
+			m.delayed = slices.Delete(m.delayed, i, i+1)
+			return msg.body, 0
+		}
+		if next == 0 || wait < next {
+			next = wait
+		}
+	}
+	return nil, next
+}
+
+func (m *MemoryBroker) DeadLetter(_ context.Context, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dead = append(m.dead, body)
+	return nil
+}
+
+// Dead возвращает недоставленные сообщения.
+func (m *MemoryBroker) Dead() [][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]byte(nil), m.dead...)
+}
+
+func (m *MemoryBroker) Consume(ctx context.Context, handle func(ctx context.Context, body []byte) error) error {
+	for {
+		body, err := m.next(ctx)
+		if err != nil {
+			return err
+		}
+		if body == nil {
+			continue
+		}
+		if err := handle(ctx, body); err != nil {
+			// Как nack с requeue у RabbitMQ, но с паузой, чтобы не крутить
+			// сообщение в цикле, пока причина ошибки не устранена
+			m.schedule(body, m.requeueDelay)
+		}
+	}
+}
+
+// next возвращает следующее сообщение: сначала отложенные, время которых
+// наступило, затем из буфера. Возвращает nil, если нужно проверить
+// отложенные сообщения снова.
+func (m *MemoryBroker) next(ctx context.Context) ([]byte, error) {
+	body, wait := m.due()
+	if body != nil {
+		return body, nil
+	}
+	var timer <-chan time.Time
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case body := <-m.queue:
+		return body, nil
+	case <-m.wake:
+		return nil, nil
+	case <-timer:
+		return nil, nil
+	}
+}
+
+func (m *MemoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// consume запускает потребителя до конца теста.
+func consume(t *testing.T, broker Broker, handle func(ctx context.Context, body []byte) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = broker.Consume(ctx, handle)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// received собирает тела обработанных сообщений.
+type received struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *received) handle(_ context.Context, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	return nil
+}
+
+func (r *received) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func TestMemoryBrokerDelayedPublishDoesNotBlockOnFullBuffer(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker(1)
+	if err := broker.Publish(ctx, []byte("now"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Буфер заполнен, а отложенные сообщения всё равно принимаются
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for _, body := range []string{"later-1", "later-2"} {
+			if err := broker.Publish(ctx, []byte(body), 20*time.Millisecond); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("delayed Publish blocked on a full buffer")
+	}
+
+	var r received
+	consume(t, broker, r.handle)
+	waitFor(t, func() bool { return len(r.get()) == 3 })
+	if got := r.get(); got[0] != "now" {
+		t.Errorf("received = %q, want the immediate message first", got)
+	}
+}
+
+func TestMemoryBrokerDelaysPublishedMessages(t *testing.T) {
+	broker := NewMemoryBroker(10)
+	var r received
+	consume(t, broker, r.handle)
+
+	start := time.Now()
+	if err := broker.Publish(context.Background(), []byte("later"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(r.get()) == 1 })
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Errorf("delayed message delivered after %s", took)
+	}
+}
+
+func TestMemoryBrokerRequeuesFailuresWithPause(t *testing.T) {
+	broker := NewMemoryBroker(10)
+	broker.requeueDelay = 30 * time.Millisecond
+
+	var mu sync.Mutex
+	var attempts []time.Time
+	consume(t, broker, func(context.Context, []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(attempts)
+	}
+
+	if err := broker.Publish(context.Background(), []byte("msg"), 0); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return count() == 3 })
+	// Обработанное сообщение больше не выдаётся
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(attempts))
+	}
+	for i := 1; i < len(attempts); i++ {
+		if gap := attempts[i].Sub(attempts[i-1]); gap < broker.requeueDelay {
+			t.Errorf("redelivery %d came after %s, want at least %s", i, gap, broker.requeueDelay)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package outbound
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/notify"
+)
+
+// Broker — очередь исходящих сообщений.
+type Broker interface {
+	// Publish ставит сообщение в очередь. При delay > 0 оно будет выдано
+	// потребителю не раньше чем через delay.
+	Publish(ctx context.Context, body []byte, delay time.Duration) error
+	// DeadLetter откладывает сообщение, которое не удалось доставить, для
+	// ручного разбора.
+	DeadLetter(ctx context.Context, body []byte) error
+	// Consume передаёт сообщения в handle до отмены ctx или обрыва связи.
+	// Сообщение подтверждается, если handle вернул nil, иначе возвращается
+	// в очередь.
+	Consume(ctx context.Context, handle func(ctx context.Context, body []byte) error) error
+	Close() error
+}
+
+// requeueDelay — пауза перед повторной выдачей сообщения, которое
+// потребитель не смог обработать.
+const requeueDelay = time.Second
+
+// Envelope — исходящее сообщение вместе со служебными данными доставки.
+type Envelope struct {
+	ID        string
+	ChatID    int64
+	Message   notify.Message
+	Attempt   int
+	LastError string `json:",omitempty"`
+}
+
+// Publisher ставит уведомления в очередь вместо отправки из обработчика.
+// Реализует notify.Sender.
+type Publisher struct {
+	broker Broker
+}
+
+func NewPublisher(broker Broker) *Publisher {
+	return &Publisher{broker: broker}
+}
+
+func (p *Publisher) Send(ctx context.Context, userID int64, msg notify.Message) error {
+	body, err := json.Marshal(&Envelope{
+		ID:      newID(),
+		ChatID:  userID,
+		Message: msg,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if err := p.broker.Publish(ctx, body, 0); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
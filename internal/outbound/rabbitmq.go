@@ -0,0 +1,186 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitBroker — очередь на RabbitMQ. Используются три вида очередей:
+//   - <queue> — основная;
+//   - <queue>.retry.<ms> — отложенные сообщения: без потребителей, по
+//     истечении TTL сообщение возвращается в основную очередь через
+//     dead-letter; у каждой задержки своя очередь, чтобы короткие задержки
+//     не ждали за длинными;
+//   - <queue>.dead — недоставленные сообщения.
+type RabbitBroker struct {
+	url   string
+	queue string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	pub      *amqp.Channel
+	declared map[string]bool
+}
+
+// NewRabbitBroker подключается к RabbitMQ и объявляет основную очередь и
+// очередь недоставленных.
+func NewRabbitBroker(url, queue string) (*RabbitBroker, error) {
+	b := &RabbitBroker{
+		url:      url,
+		queue:    queue,
+		declared: make(map[string]bool),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.channel(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// channel возвращает канал публикации, переподключаясь при обрыве.
+// Вызывается под mu.
+func (b *RabbitBroker) channel() (*amqp.Channel, error) {
+	if b.pub != nil && !b.pub.IsClosed() {
+		return b.pub, nil
+	}
+	if b.conn == nil || b.conn.IsClosed() {
+		conn, err := amqp.Dial(b.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		}
+		b.conn = conn
+		b.declared = make(map[string]bool)
+	}
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	b.pub = ch
+
+	if err := b.declare(ch, b.queue, nil); err != nil {
+		return nil, err
+	}
+	if err := b.declare(ch, b.queue+".dead", nil); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (b *RabbitBroker) declare(ch *amqp.Channel, name string, args amqp.Table) error {
+	if b.declared[name] {
+		return nil
+	}
+	if _, err := ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", name, err)
+	}
+	b.declared[name] = true
+	return nil
+}
+
+func (b *RabbitBroker) Publish(ctx context.Context, body []byte, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, err := b.channel()
+	if err != nil {
+		return err
+	}
+	queue := b.queue
+	if delay > 0 {
+		ms := delay.Milliseconds()
+		queue = fmt.Sprintf("%s.retry.%d", b.queue, ms)
+		err := b.declare(ch, queue, amqp.Table{
+			"x-message-ttl":             ms,
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": b.queue,
+			// Неиспользуемая очередь задержки удаляется сама
+			"x-expires": ms + time.Hour.Milliseconds(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return b.publish(ctx, ch, queue, body)
+}
+
+func (b *RabbitBroker) DeadLetter(ctx context.Context, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, err := b.channel()
+	if err != nil {
+		return err
+	}
+	return b.publish(ctx, ch, b.queue+".dead", body)
+}
+
+func (b *RabbitBroker) publish(ctx context.Context, ch *amqp.Channel, queue string, body []byte) error {
+	err := ch.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", queue, err)
+	}
+	return nil
+}
+
+func (b *RabbitBroker) Consume(ctx context.Context, handle func(ctx context.Context, body []byte) error) error {
+	b.mu.Lock()
+	if _, err := b.channel(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	ch, err := b.conn.Channel()
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	// Берём по одному сообщению: отправка всё равно ограничена по частоте
+	if err := ch.Qos(1, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	deliveries, err := ch.Consume(b.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", b.queue, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			if err := handle(ctx, d.Body); err != nil {
+				// Не возвращаем сообщение сразу, чтобы не крутить его в
+				// цикле, пока причина ошибки не устранена
+				sleep(ctx, requeueDelay)
+				_ = d.Nack(false, true)
+				continue
+			}
+			if err := d.Ack(false); err != nil {
+				return fmt.Errorf("failed to ack message: %w", err)
+			}
+		}
+	}
+}
+
+func (b *RabbitBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil || b.conn.IsClosed() {
+		return nil
+	}
+	return b.conn.Close()
+}
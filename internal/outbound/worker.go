@@ -0,0 +1,205 @@
+package outbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// rateKey — общий для всех экземпляров ключ лимита отправки.
+const rateKey = "outbound:send"
+
+// WorkerConfig — параметры доставки.
+type WorkerConfig struct {
+	// RatePerSecond — сколько сообщений в секунду бот отправляет суммарно
+	// со всех экземпляров.
+	RatePerSecond int
+	// MaxAttempts — после стольких неудачных попыток, включая ответы 429,
+	// сообщение уходит в очередь недоставленных.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Worker забирает сообщения из очереди и отправляет их в Telegram.
+type Worker struct {
+	broker  Broker
+	sender  notify.Sender
+	limiter ratelimit.Limiter
+	cfg     WorkerConfig
+	logger  *zap.Logger
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+}
+
+func NewWorker(broker Broker, sender notify.Sender, limiter ratelimit.Limiter, cfg WorkerConfig, logger *zap.Logger) *Worker {
+	return &Worker{
+		broker:  broker,
+		sender:  sender,
+		limiter: limiter,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// Run обрабатывает очередь до отмены ctx, переподключаясь после обрыва
+// связи с брокером.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		err := w.broker.Consume(ctx, w.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		w.logger.Error("Outbound consumer stopped, restarting", zap.Error(err))
+		if !sleep(ctx, 5*time.Second) {
+			return
+		}
+	}
+}
+
+func (w *Worker) handle(ctx context.Context, body []byte) error {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		w.logger.Error("Failed to decode outbound message", zap.Error(err))
+		return w.broker.DeadLetter(ctx, body)
+	}
+
+	if !w.waitForSlot(ctx) {
+		return ctx.Err()
+	}
+
+	err := w.sender.Send(ctx, env.ChatID, env.Message)
+	if err == nil {
+		return nil
+	}
+	env.Attempt++
+	env.LastError = err.Error()
+
+	var tgErr *gotgbot.TelegramError
+	errors.As(err, &tgErr)
+	flood := tgErr != nil && tgErr.Code == http.StatusTooManyRequests
+	var floodWait time.Duration
+	if flood {
+		// Флуд-лимит действует на всего бота, поэтому приостанавливаем всю
+		// отправку, даже если само сообщение больше не будет повторяться
+		floodWait = retryAfter(tgErr, w.cfg.BaseBackoff)
+		w.pause(floodWait)
+		w.logger.Warn("Telegram flood limit hit",
+			zap.Int64("chat_id", env.ChatID),
+			zap.Duration("retry_after", floodWait))
+	}
+
+	switch {
+	case tgErr != nil && (tgErr.Code == http.StatusBadRequest || tgErr.Code == http.StatusForbidden):
+		// Бот заблокирован или чат не существует: повтор не поможет
+		w.logger.Warn("Outbound message rejected",
+			zap.String("id", env.ID),
+			zap.Int64("chat_id", env.ChatID),
+			zap.Error(err))
+		return w.deadLetter(ctx, &env)
+	case env.Attempt >= w.cfg.MaxAttempts:
+		w.logger.Error("Outbound message failed permanently",
+			zap.String("id", env.ID),
+			zap.Int64("chat_id", env.ChatID),
+			zap.Int("attempts", env.Attempt),
+			zap.Error(err))
+		return w.deadLetter(ctx, &env)
+	case flood:
+		// Повторы после 429 тоже считаются попытками: иначе сообщение, на
+		// которое Telegram всегда отвечает 429, крутилось бы бесконечно
+		return w.retry(ctx, &env, floodWait)
+	default:
+		w.logger.Warn("Failed to send outbound message, will retry",
+			zap.String("id", env.ID),
+			zap.Int64("chat_id", env.ChatID),
+			zap.Int("attempt", env.Attempt),
+			zap.Error(err))
+		return w.retry(ctx, &env, w.backoff(env.Attempt))
+	}
+}
+
+// waitForSlot ждёт, пока глобальный лимит и пауза после флуд-лимита
+// позволят отправить сообщение.
+func (w *Worker) waitForSlot(ctx context.Context) bool {
+	w.mu.Lock()
+	pause := time.Until(w.pauseUntil)
+	w.mu.Unlock()
+	if pause > 0 && !sleep(ctx, pause) {
+		return false
+	}
+
+	for {
+		res, err := w.limiter.Allow(ctx, rateKey, w.cfg.RatePerSecond, time.Second)
+		if err != nil {
+			// Без лимитера отправляем: Telegram сам ответит 429, если нужно
+			w.logger.Warn("Failed to check send rate", zap.Error(err))
+			return ctx.Err() == nil
+		}
+		if res.Allowed {
+			return true
+		}
+		if !sleep(ctx, res.RetryAfter) {
+			return false
+		}
+	}
+}
+
+func (w *Worker) pause(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if until := time.Now().Add(d); until.After(w.pauseUntil) {
+		w.pauseUntil = until
+	}
+}
+
+func (w *Worker) retry(ctx context.Context, env *Envelope, delay time.Duration) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return w.broker.Publish(ctx, body, delay)
+}
+
+func (w *Worker) deadLetter(ctx context.Context, env *Envelope) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return w.broker.DeadLetter(ctx, body)
+}
+
+// backoff удваивает задержку с каждой попыткой, не превышая MaxBackoff.
+func (w *Worker) backoff(attempt int) time.Duration {
+	d := w.cfg.BaseBackoff
+	for i := 1; i < attempt && d < w.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.cfg.MaxBackoff)
+}
+
+func retryAfter(err *gotgbot.TelegramError, fallback time.Duration) time.Duration {
+	if err.ResponseParams != nil && err.ResponseParams.RetryAfter > 0 {
+		return time.Duration(err.ResponseParams.RetryAfter) * time.Second
+	}
+	return fallback
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
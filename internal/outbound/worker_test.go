@@ -0,0 +1,157 @@
+package outbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// scriptedSender возвращает ошибки из errs по очереди, а когда они
+// заканчиваются — fallback.
+type scriptedSender struct {
+	mu       sync.Mutex
+	errs     []error
+	fallback error
+	calls    int
+	sent     []notify.Message
+}
+
+func (s *scriptedSender) Send(_ context.Context, _ int64, msg notify.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	err := s.fallback
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	if err == nil {
+		s.sent = append(s.sent, msg)
+	}
+	return err
+}
+
+func (s *scriptedSender) stats() (calls int, sent []notify.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, append([]notify.Message(nil), s.sent...)
+}
+
+func telegramError(code int) error {
+	return &gotgbot.TelegramError{Method: "sendMessage", Code: code, Description: http.StatusText(code)}
+}
+
+// startWorker запускает воркер на очереди в памяти и публикует одно
+// сообщение.
+func startWorker(t *testing.T, sender notify.Sender) *MemoryBroker {
+	t.Helper()
+	broker := NewMemoryBroker(10)
+	worker := NewWorker(broker, sender, ratelimit.NewMemoryLimiter(), WorkerConfig{
+		RatePerSecond: 100,
+		MaxAttempts:   3,
+		BaseBackoff:   time.Millisecond,
+		MaxBackoff:    5 * time.Millisecond,
+	}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	if err := NewPublisher(broker).Send(context.Background(), 42, notify.Message{Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	return broker
+}
+
+func deadEnvelopes(t *testing.T, broker *MemoryBroker) []Envelope {
+	t.Helper()
+	var envs []Envelope
+	for _, body := range broker.Dead() {
+		var env Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			t.Fatal(err)
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+func TestWorkerDelivers(t *testing.T) {
+	sender := &scriptedSender{}
+	startWorker(t, sender)
+
+	waitFor(t, func() bool {
+		_, sent := sender.stats()
+		return len(sent) == 1
+	})
+	if _, sent := sender.stats(); sent[0].Text != "hello" {
+		t.Errorf("sent = %+v", sent)
+	}
+}
+
+func TestWorkerRetriesTransientErrors(t *testing.T) {
+	sender := &scriptedSender{errs: []error{errors.New("timeout"), telegramError(http.StatusInternalServerError)}}
+	broker := startWorker(t, sender)
+
+	waitFor(t, func() bool {
+		_, sent := sender.stats()
+		return len(sent) == 1
+	})
+	if calls, _ := sender.stats(); calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if dead := broker.Dead(); len(dead) != 0 {
+		t.Errorf("dead letters = %q", dead)
+	}
+}
+
+func TestWorkerDeadLettersRejectedMessages(t *testing.T) {
+	sender := &scriptedSender{fallback: telegramError(http.StatusForbidden)}
+	broker := startWorker(t, sender)
+
+	waitFor(t, func() bool { return len(broker.Dead()) == 1 })
+	env := deadEnvelopes(t, broker)[0]
+	if env.ChatID != 42 || env.Attempt != 1 || env.LastError == "" {
+		t.Errorf("dead letter = %+v", env)
+	}
+	if calls, _ := sender.stats(); calls != 1 {
+		t.Errorf("calls = %d, want no retries", calls)
+	}
+}
+
+func TestWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	for name, err := range map[string]error{
+		"server error": telegramError(http.StatusBadGateway),
+		// 429 на каждую попытку не должен повторяться бесконечно
+		"flood limit": telegramError(http.StatusTooManyRequests),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sender := &scriptedSender{fallback: err}
+			broker := startWorker(t, sender)
+
+			waitFor(t, func() bool { return len(broker.Dead()) == 1 })
+			if env := deadEnvelopes(t, broker)[0]; env.Attempt != 3 {
+				t.Errorf("dead letter attempts = %d, want 3", env.Attempt)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if calls, _ := sender.stats(); calls != 3 {
+				t.Errorf("calls = %d, want 3", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package premium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const paymentsTable = "payments"
+
+type pgRepository struct {
+	pool *pgxpool.Pool
+	sq   squirrel.StatementBuilderType
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool, sq squirrel.StatementBuilderType) Repository {
+	return &pgRepository{
+		pool: pool,
+		sq:   sq,
+	}
+}
+
+func (r *pgRepository) InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return fn(ctx, &pgTx{tx: tx, sq: r.sq})
+	})
+}
+
+func (r *pgRepository) Until(ctx context.Context, userID int64) (*time.Time, error) {
+	qb, args, err := r.sq.Select(db.UsersPremiumUntil).
+		From(db.UsersTable).
+		Where(squirrel.Eq{db.UsersID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	var until *time.Time
+	err = r.pool.QueryRow(ctx, qb, args...).Scan(&until)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return until, nil
+}
+
+func (r *pgRepository) ExpireStale(ctx context.Context) (int64, error) {
+	qb, args, err := r.sq.Update(db.UsersTable).
+		Set(db.UsersIsPremium, false).
+		Where(squirrel.Eq{db.UsersIsPremium: true}).
+		Where(squirrel.Or{
+			squirrel.Eq{db.UsersPremiumUntil: nil},
+			squirrel.Expr(db.UsersPremiumUntil + " <= now()"),
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := r.pool.Exec(ctx, qb, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+type pgTx struct {
+	tx pgx.Tx
+	sq squirrel.StatementBuilderType
+}
+
+func (t *pgTx) LockUser(ctx context.Context, userID int64) (*time.Time, error) {
+	qb, args, err := t.sq.Select(db.UsersPremiumUntil).
+		From(db.UsersTable).
+		Where(squirrel.Eq{db.UsersID: userID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	var until *time.Time
+	if err := t.tx.QueryRow(ctx, qb, args...).Scan(&until); err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return until, nil
+}
+
+func (t *pgTx) SetUntil(ctx context.Context, userID int64, until *time.Time) error {
+	qb, args, err := t.sq.Update(db.UsersTable).
+		Set(db.UsersPremiumUntil, until).
+		Set(db.UsersIsPremium, until != nil).
+		Set(db.UsersUpdatedAt, squirrel.Expr("now()")).
+		Where(squirrel.Eq{db.UsersID: userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := t.tx.Exec(ctx, qb, args...); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
+
+func (t *pgTx) InsertPayment(ctx context.Context, payment *Payment, until *time.Time) (bool, error) {
+	qb, args, err := t.sq.Insert(paymentsTable).
+		Columns("user_id", "telegram_charge_id", "provider_charge_id", "currency", "amount", "payload", "premium_until").
+		Values(payment.UserID, payment.TelegramChargeID, payment.ProviderChargeID,
+			payment.Currency, payment.Amount, payment.Payload, until).
+		Suffix("ON CONFLICT (telegram_charge_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+	tag, err := t.tx.Exec(ctx, qb, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
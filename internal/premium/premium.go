@@ -0,0 +1,235 @@
+package premium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CurrencyStars — валюта оплаты в Telegram Stars.
+const CurrencyStars = "XTR"
+
+// ErrInvalidPayment — платёж не соответствует выставленному счёту.
+var ErrInvalidPayment = errors.New("payment does not match the plan")
+
+// Plan — условия покупки премиума.
+type Plan struct {
+	Title       string
+	Description string
+	Payload     string
+	Stars       int64
+	Duration    time.Duration
+}
+
+// Payment — подтверждённый Telegram платёж.
+type Payment struct {
+	UserID           int64
+	TelegramChargeID string
+	ProviderChargeID string
+	Currency         string
+	Amount           int64
+	Payload          string
+}
+
+// Repository открывает транзакцию, в рамках которой меняется подписка.
+type Repository interface {
+	InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
+	// Until возвращает срок окончания премиума или nil, если его не было.
+	Until(ctx context.Context, userID int64) (*time.Time, error)
+	// ExpireStale снимает флаг is_premium с истёкших подписок и возвращает их число.
+	ExpireStale(ctx context.Context) (int64, error)
+}
+
+// Tx — операции над подпиской внутри одной транзакции.
+type Tx interface {
+	// LockUser блокирует строку пользователя и возвращает текущий срок премиума.
+	LockUser(ctx context.Context, userID int64) (*time.Time, error)
+	// SetUntil выставляет срок премиума; nil отзывает подписку.
+	SetUntil(ctx context.Context, userID int64, until *time.Time) error
+	// InsertPayment сохраняет платёж и возвращает false, если он уже учтён.
+	// until — срок премиума, выданного за платёж; nil означает, что премиум
+	// выдать не удалось и платёж нужно вернуть.
+	InsertPayment(ctx context.Context, payment *Payment, until *time.Time) (bool, error)
+}
+
+type Service struct {
+	repo   Repository
+	plan   Plan
+	now    func() time.Time
+	logger *zap.Logger
+}
+
+func NewService(repo Repository, plan Plan, logger *zap.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		plan:   plan,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// Plan возвращает условия покупки.
+func (s *Service) Plan() Plan {
+	return s.plan
+}
+
+// Until возвращает срок действующего премиума или nil, если его нет.
+func (s *Service) Until(ctx context.Context, userID int64) (*time.Time, error) {
+	until, err := s.repo.Until(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium status: %w", err)
+	}
+	if until == nil || !until.After(s.now()) {
+		return nil, nil
+	}
+	return until, nil
+}
+
+// IsActive сообщает, действует ли у пользователя премиум.
+func (s *Service) IsActive(ctx context.Context, userID int64) (bool, error) {
+	until, err := s.Until(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return until != nil, nil
+}
+
+// Grant продлевает премиум на d от текущего срока или от сейчас, если
+// подписка уже закончилась. Возвращает новый срок.
+func (s *Service) Grant(ctx context.Context, userID int64, d time.Duration) (time.Time, error) {
+	var until time.Time
+	err := s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		current, err := tx.LockUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		until = s.extend(current, d)
+		return tx.SetUntil(ctx, userID, &until)
+	})
+	if err != nil {
+		s.logger.Error("Failed to grant premium",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return time.Time{}, err
+	}
+
+	s.logger.Info("Premium granted",
+		zap.Int64("user_id", userID),
+		zap.Time("until", until))
+	return until, nil
+}
+
+// Revoke немедленно отзывает премиум.
+func (s *Service) Revoke(ctx context.Context, userID int64) error {
+	err := s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		if _, err := tx.LockUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		return tx.SetUntil(ctx, userID, nil)
+	})
+	if err != nil {
+		s.logger.Error("Failed to revoke premium",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Premium revoked", zap.Int64("user_id", userID))
+	return nil
+}
+
+// ExpireStale синхронизирует флаг is_premium с истёкшими подписками.
+func (s *Service) ExpireStale(ctx context.Context) (int64, error) {
+	expired, err := s.repo.ExpireStale(ctx)
+	if err != nil {
+		s.logger.Error("Failed to expire premium", zap.Error(err))
+		return 0, err
+	}
+	if expired > 0 {
+		s.logger.Info("Premium subscriptions expired", zap.Int64("count", expired))
+	}
+	return expired, nil
+}
+
+// CheckInvoice проверяет, что счёт соответствует текущему тарифу.
+func (s *Service) CheckInvoice(currency string, amount int64, payload string) error {
+	if currency != CurrencyStars || amount != s.plan.Stars || payload != s.plan.Payload {
+		return ErrInvalidPayment
+	}
+	return nil
+}
+
+// ApplyPayment продлевает премиум по оплаченному счёту. Счёт проверяется
+// при подтверждении оплаты (CheckInvoice), а здесь деньги уже списаны:
+// платёж учитывается всегда, даже если тариф успел измениться. Повторная
+// доставка того же платежа не продлевает подписку второй раз: applied
+// будет false. Если премиум выдать не удалось, платёж всё равно
+// сохраняется без срока, чтобы его можно было вернуть.
+func (s *Service) ApplyPayment(ctx context.Context, payment *Payment) (until time.Time, applied bool, err error) {
+	err = s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		current, err := tx.LockUser(ctx, payment.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		until = s.extend(current, s.plan.Duration)
+		applied, err = tx.InsertPayment(ctx, payment, &until)
+		if err != nil {
+			return fmt.Errorf("failed to insert payment: %w", err)
+		}
+		if !applied {
+			if current != nil {
+				until = *current
+			}
+			return nil
+		}
+		return tx.SetUntil(ctx, payment.UserID, &until)
+	})
+	if err != nil {
+		s.logger.Error("Failed to apply payment",
+			zap.Int64("user_id", payment.UserID),
+			zap.String("charge_id", payment.TelegramChargeID),
+			zap.Error(err))
+		s.recordUngranted(ctx, payment)
+		return time.Time{}, false, err
+	}
+	s.logger.Info("Payment applied",
+		zap.Int64("user_id", payment.UserID),
+		zap.String("charge_id", payment.TelegramChargeID),
+		zap.Bool("applied", applied),
+		zap.Time("until", until))
+	return until, applied, nil
+}
+
+// recordUngranted сохраняет платёж, за который премиум не выдан: Stars уже
+// списаны, и без записи платёж не найти для возврата.
+func (s *Service) recordUngranted(ctx context.Context, payment *Payment) {
+	var recorded bool
+	err := s.repo.InTx(ctx, func(ctx context.Context, tx Tx) error {
+		var err error
+		recorded, err = tx.InsertPayment(ctx, payment, nil)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("Failed to record ungranted payment",
+			zap.Int64("user_id", payment.UserID),
+			zap.String("charge_id", payment.TelegramChargeID),
+			zap.Error(err))
+		return
+	}
+	if recorded {
+		s.logger.Warn("Payment recorded without premium, refund required",
+			zap.Int64("user_id", payment.UserID),
+			zap.String("charge_id", payment.TelegramChargeID))
+	}
+}
+
+func (s *Service) extend(current *time.Time, d time.Duration) time.Time {
+	base := s.now()
+	if current != nil && current.After(base) {
+		base = *current
+	}
+	return base.Add(d)
+}
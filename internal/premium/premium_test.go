@@ -0,0 +1,198 @@
+package premium
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeRepo хранит сроки премиума и учтённые платежи в памяти. Транзакция
+// с ошибкой откатывает изменения.
+type fakeRepo struct {
+	until    map[int64]time.Time
+	payments map[string]*Payment
+	// granted — срок премиума, записанный с платежом
+	granted map[string]*time.Time
+	// lockErr — ошибка блокировки строки пользователя
+	lockErr error
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		until:    make(map[int64]time.Time),
+		payments: make(map[string]*Payment),
+		granted:  make(map[string]*time.Time),
+	}
+}
+
+func (r *fakeRepo) InTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	until, payments, granted := maps.Clone(r.until), maps.Clone(r.payments), maps.Clone(r.granted)
+	if err := fn(ctx, r); err != nil {
+		r.until, r.payments, r.granted = until, payments, granted
+		return err
+	}
+	return nil
+}
+
+func (r *fakeRepo) Until(_ context.Context, userID int64) (*time.Time, error) {
+	return r.LockUser(context.Background(), userID)
+}
+
+func (r *fakeRepo) ExpireStale(context.Context) (int64, error) { return 0, nil }
+
+func (r *fakeRepo) LockUser(_ context.Context, userID int64) (*time.Time, error) {
+	if r.lockErr != nil {
+		return nil, r.lockErr
+	}
+	until, ok := r.until[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &until, nil
+}
+
+func (r *fakeRepo) SetUntil(_ context.Context, userID int64, until *time.Time) error {
+	if until == nil {
+		delete(r.until, userID)
+		return nil
+	}
+	r.until[userID] = *until
+	return nil
+}
+
+func (r *fakeRepo) InsertPayment(_ context.Context, payment *Payment, until *time.Time) (bool, error) {
+	if _, ok := r.payments[payment.TelegramChargeID]; ok {
+		return false, nil
+	}
+	r.payments[payment.TelegramChargeID] = payment
+	r.granted[payment.TelegramChargeID] = until
+	return true, nil
+}
+
+var (
+	testNow  = time.Date(2025, 4, 20, 12, 0, 0, 0, time.UTC)
+	testPlan = Plan{Title: "Премиум", Payload: "premium_30d", Stars: 250, Duration: 30 * 24 * time.Hour}
+)
+
+func newTestService(repo Repository) *Service {
+	s := NewService(repo, testPlan, zap.NewNop())
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func payment(chargeID string, amount int64) *Payment {
+	return &Payment{
+		UserID:           1,
+		TelegramChargeID: chargeID,
+		Currency:         CurrencyStars,
+		Amount:           amount,
+		Payload:          testPlan.Payload,
+	}
+}
+
+func TestCheckInvoice(t *testing.T) {
+	s := newTestService(newFakeRepo())
+	if err := s.CheckInvoice(CurrencyStars, testPlan.Stars, testPlan.Payload); err != nil {
+		t.Errorf("current plan rejected: %v", err)
+	}
+	if err := s.CheckInvoice(CurrencyStars, 100, testPlan.Payload); !errors.Is(err, ErrInvalidPayment) {
+		t.Errorf("old price: err = %v, want ErrInvalidPayment", err)
+	}
+	if err := s.CheckInvoice("USD", testPlan.Stars, testPlan.Payload); !errors.Is(err, ErrInvalidPayment) {
+		t.Errorf("wrong currency: err = %v, want ErrInvalidPayment", err)
+	}
+}
+
+func TestApplyPaymentExtendsPremium(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	until, applied, err := s.ApplyPayment(ctx, payment("charge-1", testPlan.Stars))
+	if err != nil || !applied {
+		t.Fatalf("ApplyPayment = %v, %v", applied, err)
+	}
+	if want := testNow.Add(testPlan.Duration); !until.Equal(want) {
+		t.Errorf("until = %v, want %v", until, want)
+	}
+
+	// Второй платёж продлевает от конца текущей подписки
+	until, applied, err = s.ApplyPayment(ctx, payment("charge-2", testPlan.Stars))
+	if err != nil || !applied {
+		t.Fatalf("ApplyPayment = %v, %v", applied, err)
+	}
+	if want := testNow.Add(2 * testPlan.Duration); !until.Equal(want) {
+		t.Errorf("until = %v, want %v", until, want)
+	}
+}
+
+func TestApplyPaymentIsIdempotent(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	first, _, err := s.ApplyPayment(ctx, payment("charge-1", testPlan.Stars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	until, applied, err := s.ApplyPayment(ctx, payment("charge-1", testPlan.Stars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied {
+		t.Error("repeated delivery was applied again")
+	}
+	if !until.Equal(first) || !repo.until[1].Equal(first) {
+		t.Errorf("until = %v, stored %v, want %v", until, repo.until[1], first)
+	}
+}
+
+func TestApplyPaymentAfterPriceChange(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+
+	// Счёт подтвердили по старой цене, а тариф сменился до прихода платежа:
+	// деньги списаны, поэтому премиум всё равно выдаётся
+	until, applied, err := s.ApplyPayment(context.Background(), payment("charge-1", 100))
+	if err != nil || !applied {
+		t.Fatalf("ApplyPayment = %v, %v; want the payment applied", applied, err)
+	}
+	if _, ok := repo.payments["charge-1"]; !ok {
+		t.Error("payment was not recorded")
+	}
+	if want := testNow.Add(testPlan.Duration); !until.Equal(want) {
+		t.Errorf("until = %v, want %v", until, want)
+	}
+}
+
+func TestApplyPaymentRecordsUngrantedPayment(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+	// Строки пользователя нет: профиль удалён, пока висел счёт
+	repo.lockErr = errors.New("no rows in result set")
+
+	if _, applied, err := s.ApplyPayment(context.Background(), payment("charge-1", testPlan.Stars)); err == nil || applied {
+		t.Fatalf("ApplyPayment = %v, %v; want an error", applied, err)
+	}
+	if _, ok := repo.payments["charge-1"]; !ok {
+		t.Fatal("charge was lost, it cannot be refunded")
+	}
+	if until := repo.granted["charge-1"]; until != nil {
+		t.Errorf("ungranted payment has premium until %v", until)
+	}
+	if _, ok := repo.until[1]; ok {
+		t.Error("premium was granted")
+	}
+
+	// Повторная доставка не дублирует запись
+	if _, _, err := s.ApplyPayment(context.Background(), payment("charge-1", testPlan.Stars)); err == nil {
+		t.Fatal("repeated ApplyPayment succeeded")
+	}
+	if len(repo.payments) != 1 {
+		t.Errorf("payments = %d, want 1", len(repo.payments))
+	}
+}
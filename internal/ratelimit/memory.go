@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter — реализация Limiter в памяти процесса с подменяемыми
+// часами. Подходит для тестов и запуска в одном экземпляре.
+type MemoryLimiter struct {
+	mu     sync.Mutex
+	events map[string][]time.Time
+	now    func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		events: make(map[string][]time.Time),
+		now:    time.Now,
+	}
+}
+
+// SetClock подменяет источник времени.
+func (l *MemoryLimiter) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	events := l.prune(key, now, window)
+	if len(events) < limit {
+		l.events[key] = append(events, now)
+		return Result{Allowed: true, Remaining: limit - len(events) - 1}, nil
+	}
+	return Result{RetryAfter: events[0].Add(window).Sub(now)}, nil
+}
+
+func (l *MemoryLimiter) Peek(_ context.Context, key string, limit int, window time.Duration) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	events := l.prune(key, now, window)
+	if len(events) < limit {
+		return Result{Allowed: true, Remaining: limit - len(events)}, nil
+	}
+	return Result{RetryAfter: events[0].Add(window).Sub(now)}, nil
+}
+
+func (l *MemoryLimiter) Record(_ context.Context, key string, window time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.events[key] = append(l.prune(key, now, window), now)
+	return nil
+}
+
+func (l *MemoryLimiter) Release(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if events := l.events[key]; len(events) > 0 {
+		l.events[key] = events[:len(events)-1]
+	}
+	return nil
+}
+
+// prune отбрасывает события, вышедшие за окно, и возвращает оставшиеся.
+func (l *MemoryLimiter) prune(key string, now time.Time, window time.Duration) []time.Time {
+	events := l.events[key]
+	start := 0
+	for start < len(events) && !events[start].After(now.Add(-window)) {
+		start++
+	}
+	events = events[start:]
+	l.events[key] = events
+	return events
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result — решение лимитера по одному действию.
+type Result struct {
+	Allowed bool
+	// Remaining — сколько действий ещё доступно в текущем окне.
+	Remaining int
+	// RetryAfter — через сколько освободится место, если действие запрещено.
+	RetryAfter time.Duration
+}
+
+// Limiter ограничивает число действий по ключу в скользящем окне.
+type Limiter interface {
+	// Allow проверяет лимит и сразу засчитывает разрешённое действие;
+	// запрещённое не засчитывается.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+	// Peek проверяет лимит, ничего не засчитывая. Нужен, когда действие
+	// может не состояться и списывать его заранее нельзя.
+	Peek(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+	// Record засчитывает уже совершённое действие без проверки лимита.
+	Record(ctx context.Context, key string, window time.Duration) error
+	// Release отменяет последнее засчитанное действие. Так место,
+	// занятое через Allow, возвращается, если действие не состоялось.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// testLimiters возвращает реализации Limiter с общими проверками.
+func testLimiters(t *testing.T) map[string]Limiter {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return map[string]Limiter{
+		"memory": NewMemoryLimiter(),
+		"redis":  NewRedisLimiter(client),
+	}
+}
+
+func TestAllow(t *testing.T) {
+	for name, l := range testLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := 2; i >= 0; i-- {
+				res, err := l.Allow(ctx, "key", 3, time.Minute)
+				if err != nil || !res.Allowed || res.Remaining != i {
+					t.Fatalf("Allow = %+v, %v; want allowed with %d remaining", res, err, i)
+				}
+			}
+			res, err := l.Allow(ctx, "key", 3, time.Minute)
+			if err != nil || res.Allowed || res.RetryAfter <= 0 {
+				t.Fatalf("Allow over the limit = %+v, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestPeekDoesNotCount(t *testing.T) {
+	for name, l := range testLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := 0; i < 3; i++ {
+				res, err := l.Peek(ctx, "key", 1, time.Minute)
+				if err != nil || !res.Allowed || res.Remaining != 1 {
+					t.Fatalf("Peek = %+v, %v; want allowed with 1 remaining", res, err)
+				}
+			}
+
+			if err := l.Record(ctx, "key", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			res, err := l.Peek(ctx, "key", 1, time.Minute)
+			if err != nil || res.Allowed || res.RetryAfter <= 0 {
+				t.Fatalf("Peek after Record = %+v, %v; want refused", res, err)
+			}
+		})
+	}
+}
+
+func TestRecordIgnoresLimit(t *testing.T) {
+	for name, l := range testLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			// Совершённое действие засчитывается, даже если место уже занято
+			for i := 0; i < 2; i++ {
+				if err := l.Record(ctx, "key", time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			res, err := l.Allow(ctx, "key", 2, time.Minute)
+			if err != nil || res.Allowed {
+				t.Fatalf("Allow = %+v, %v; want refused", res, err)
+			}
+		})
+	}
+}
+
+func TestReleaseReturnsReservedSlot(t *testing.T) {
+	for name, l := range testLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if res, err := l.Allow(ctx, "key", 1, time.Minute); err != nil || !res.Allowed {
+				t.Fatalf("Allow = %+v, %v; want allowed", res, err)
+			}
+			if res, err := l.Allow(ctx, "key", 1, time.Minute); err != nil || res.Allowed {
+				t.Fatalf("Allow with the slot taken = %+v, %v; want refused", res, err)
+			}
+
+			if err := l.Release(ctx, "key"); err != nil {
+				t.Fatal(err)
+			}
+			if res, err := l.Allow(ctx, "key", 1, time.Minute); err != nil || !res.Allowed {
+				t.Fatalf("Allow after Release = %+v, %v; want allowed", res, err)
+			}
+
+			// Отменять нечего — ошибки нет
+			if err := l.Release(ctx, "empty"); err != nil {
+				t.Errorf("Release of an empty key: %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterWindowSlides(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 4, 20, 12, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.SetClock(func() time.Time { return now })
+
+	if err := l.Record(ctx, "key", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	res, _ := l.Peek(ctx, "key", 1, time.Minute)
+	if res.Allowed || res.RetryAfter != 30*time.Second {
+		t.Fatalf("Peek inside the window = %+v; want retry in 30s", res)
+	}
+
+	now = now.Add(30 * time.Second)
+	if res, _ := l.Peek(ctx, "key", 1, time.Minute); !res.Allowed {
+		t.Fatalf("Peek after the window = %+v; want allowed", res)
+	}
+}
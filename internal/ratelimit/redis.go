@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript хранит моменты действий в sorted set и атомарно
+// вычищает устаревшие, считает оставшиеся и добавляет новое, если есть место.
+// Возвращает {allowed, remaining, retry_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+    redis.call('ZADD', key, now, ARGV[4])
+    redis.call('PEXPIRE', key, window)
+    return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// peekScript вычищает устаревшие действия и сообщает, есть ли место для
+// нового, ничего не добавляя. Возвращает {allowed, remaining, retry_after_ms}.
+var peekScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+    return {1, limit - count, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+type RedisLimiter struct {
+	redis  *redis.Client
+	prefix string
+}
+
+func NewRedisLimiter(redisClient *redis.Client) *RedisLimiter {
+	return &RedisLimiter{
+		redis:  redisClient,
+		prefix: "ratelimit:v1:",
+	}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := time.Now()
+	return l.run(ctx, slidingWindowScript, key, now, window, limit, member(now))
+}
+
+func (l *RedisLimiter) Peek(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	return l.run(ctx, peekScript, key, time.Now(), window, limit)
+}
+
+func (l *RedisLimiter) Record(ctx context.Context, key string, window time.Duration) error {
+	now := time.Now()
+	fullKey := l.prefix + key
+	_, err := l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, fullKey, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+		pipe.ZAdd(ctx, fullKey, &redis.Z{Score: float64(now.UnixMilli()), Member: member(now)})
+		pipe.PExpire(ctx, fullKey, window)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record rate limited action: %w", err)
+	}
+	return nil
+}
+
+func (l *RedisLimiter) Release(ctx context.Context, key string) error {
+	if err := l.redis.ZPopMax(ctx, l.prefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release rate limited action: %w", err)
+	}
+	return nil
+}
+
+func (l *RedisLimiter) run(ctx context.Context, script *redis.Script, key string, now time.Time, window time.Duration, limit int, extra ...interface{}) (Result, error) {
+	args := append([]interface{}{now.UnixMilli(), window.Milliseconds(), limit}, extra...)
+	raw, err := script.Run(ctx, l.redis, []string{l.prefix + key}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(raw) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", raw)
+	}
+
+	return Result{
+		Allowed:    raw[0] == 1,
+		Remaining:  int(raw[1]),
+		RetryAfter: time.Duration(raw[2]) * time.Millisecond,
+	}, nil
+}
+
+// member — уникальный член множества: несколько действий в одну
+// миллисекунду должны учитываться раздельно.
+func member(now time.Time) string {
+	return strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatInt(rand.Int63(), 36)
+}
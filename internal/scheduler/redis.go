@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// lockScript захватывает свободную блокировку или продлевает свою.
+var lockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current == ARGV[1] then
+    redis.call('PEXPIRE', KEYS[1], ARGV[2])
+    return 1
+end
+if current then
+    return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// unlockScript удаляет блокировку, только если она принадлежит владельцу.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+    return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker — блокировка лидера на одном ключе Redis. Владелец
+// определяется случайным токеном экземпляра.
+type RedisLocker struct {
+	redis *redis.Client
+	key   string
+	token string
+}
+
+func NewRedisLocker(redisClient *redis.Client, name string) *RedisLocker {
+	host, _ := os.Hostname()
+	return &RedisLocker{
+		redis: redisClient,
+		key:   "scheduler:v1:lock:" + name,
+		token: host + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(rand.Int63(), 36),
+	}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	locked, err := lockScript.Run(ctx, l.redis, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return locked == 1, nil
+}
+
+func (l *RedisLocker) Unlock(ctx context.Context) error {
+	if err := unlockScript.Run(ctx, l.redis, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
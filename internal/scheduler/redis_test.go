@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisLocker(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	first := NewRedisLocker(client, "maintenance")
+	second := NewRedisLocker(client, "maintenance")
+
+	tryLock := func(l *RedisLocker) bool {
+		t.Helper()
+		locked, err := l.TryLock(ctx, 30*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return locked
+	}
+
+	if !tryLock(first) {
+		t.Fatal("free lock was not acquired")
+	}
+	if tryLock(second) {
+		t.Fatal("lock was acquired twice")
+	}
+
+	// Владелец продлевает блокировку, и она не истекает
+	mr.FastForward(20 * time.Second)
+	if !tryLock(first) {
+		t.Fatal("owner failed to extend the lock")
+	}
+	mr.FastForward(20 * time.Second)
+	if tryLock(second) {
+		t.Fatal("extended lock expired")
+	}
+
+	// Чужой Unlock не снимает блокировку
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if tryLock(second) {
+		t.Fatal("lock was released by another instance")
+	}
+
+	// Непродлённая блокировка истекает и достаётся другому экземпляру
+	mr.FastForward(31 * time.Second)
+	if !tryLock(second) {
+		t.Fatal("expired lock was not taken over")
+	}
+	if tryLock(first) {
+		t.Fatal("previous owner kept the lock after it expired")
+	}
+
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !tryLock(first) {
+		t.Error("released lock was not acquired")
+	}
+}
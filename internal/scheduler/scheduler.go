@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Clock — источник времени планировщика; в тестах подменяется фейковым.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RealClock — системные часы.
+var RealClock Clock = realClock{}
+
+// Locker — распределённая блокировка лидера. Задачи выполняет только
+// экземпляр, удерживающий её.
+type Locker interface {
+	// TryLock захватывает блокировку или продлевает уже свою на ttl.
+	TryLock(ctx context.Context, ttl time.Duration) (bool, error)
+	// Unlock снимает блокировку, если она принадлежит этому экземпляру.
+	Unlock(ctx context.Context) error
+}
+
+// Job — периодическая задача.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobStatus — состояние задачи на этом экземпляре.
+type JobStatus struct {
+	Name         string
+	Interval     time.Duration
+	Running      bool
+	Runs         int
+	Failures     int
+	LastStart    time.Time
+	LastDuration time.Duration
+	LastError    string
+	NextRun      time.Time
+}
+
+type entry struct {
+	job    Job
+	status JobStatus
+}
+
+type Scheduler struct {
+	locker   Locker
+	clock    Clock
+	leaseTTL time.Duration
+	logger   *zap.Logger
+
+	mu     sync.Mutex
+	jobs   []*entry
+	leader bool
+}
+
+// New создаёт планировщик. Лидерство удерживается на leaseTTL и продлевается
+// каждую треть этого срока.
+func New(locker Locker, clock Clock, leaseTTL time.Duration, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		locker:   locker,
+		clock:    clock,
+		leaseTTL: leaseTTL,
+		logger:   logger,
+	}
+}
+
+// Add регистрирует задачу. Вызывается до Run.
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &entry{
+		job:    job,
+		status: JobStatus{Name: job.Name, Interval: job.Interval},
+	})
+}
+
+// Status возвращает признак лидерства и состояние задач.
+func (s *Scheduler) Status() (bool, []JobStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return s.leader, statuses
+}
+
+// Run борется за лидерство и, пока оно удерживается, выполняет задачи.
+// При потере лидерства выполняющиеся задачи отменяются. Возвращается после
+// отмены ctx, сняв блокировку.
+func (s *Scheduler) Run(ctx context.Context) {
+	var (
+		stopJobs context.CancelFunc
+		jobsDone chan struct{}
+	)
+	resign := func() {
+		stopJobs()
+		<-jobsDone
+		stopJobs = nil
+		s.setLeader(false)
+	}
+	defer func() {
+		if stopJobs != nil {
+			resign()
+		}
+		if err := s.locker.Unlock(context.Background()); err != nil {
+			s.logger.Error("Failed to release scheduler lock", zap.Error(err))
+		}
+	}()
+
+	for {
+		locked, err := s.locker.TryLock(ctx, s.leaseTTL)
+		if err != nil && ctx.Err() == nil {
+			// Без связи с Redis нельзя быть уверенным в лидерстве
+			s.logger.Error("Failed to acquire scheduler lock", zap.Error(err))
+			locked = false
+		}
+
+		switch {
+		case locked && stopJobs == nil:
+			jobsCtx, cancel := context.WithCancel(ctx)
+			stopJobs = cancel
+			jobsDone = make(chan struct{})
+			s.setLeader(true)
+			s.logger.Info("Scheduler became leader")
+			go func(done chan struct{}) {
+				defer close(done)
+				s.runJobs(jobsCtx)
+			}(jobsDone)
+		case !locked && stopJobs != nil:
+			s.logger.Warn("Scheduler lost leadership")
+			resign()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.leaseTTL / 3):
+		}
+	}
+}
+
+func (s *Scheduler) runJobs(ctx context.Context) {
+	for {
+		now := s.clock.Now()
+		next := time.Time{}
+		for _, e := range s.snapshot() {
+			if !e.status.NextRun.After(now) {
+				s.runJob(ctx, e)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			nextRun := s.nextRun(e)
+			if next.IsZero() || nextRun.Before(next) {
+				next = nextRun
+			}
+		}
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(s.clock.Now())):
+		}
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, e *entry) {
+	start := s.clock.Now()
+	s.mu.Lock()
+	e.status.Running = true
+	e.status.LastStart = start
+	s.mu.Unlock()
+
+	err := e.job.Run(ctx)
+	took := s.clock.Now().Sub(start)
+
+	s.mu.Lock()
+	e.status.Running = false
+	if ctx.Err() != nil {
+		// Прерванная задача повторится у следующего лидера
+		s.mu.Unlock()
+		return
+	}
+	e.status.Runs++
+	e.status.LastDuration = took
+	e.status.LastError = ""
+	e.status.NextRun = start.Add(e.job.Interval)
+	if err != nil {
+		e.status.Failures++
+		e.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.Error("Scheduled job failed",
+			zap.String("job", e.job.Name),
+			zap.Duration("took", took),
+			zap.Error(err))
+		return
+	}
+	s.logger.Info("Scheduled job finished",
+		zap.String("job", e.job.Name),
+		zap.Duration("took", took))
+}
+
+func (s *Scheduler) snapshot() []*entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*entry(nil), s.jobs...)
+}
+
+func (s *Scheduler) nextRun(e *entry) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return e.status.NextRun
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leader = leader
+}
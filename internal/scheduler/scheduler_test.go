@@ -0,0 +1,361 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeClock — часы, которые идут только по Advance.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	ticks   int
+	waiters []waiter
+}
+
+type waiter struct {
+	at   time.Time
+	tick int
+	ch   chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 4, 22, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), tick: c.ticks, ch: ch})
+	return ch
+}
+
+// Advance переводит часы и будит ожидающих, чьё время наступило.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.ticks++
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// sleeping сообщает, уснул ли кто-то на d после последнего Advance.
+func (c *fakeClock) sleeping(d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.waiters {
+		if w.tick == c.ticks && w.at.Equal(c.now.Add(d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeLocker выдаёт блокировку, пока она разрешена тестом.
+type fakeLocker struct {
+	mu       sync.Mutex
+	grant    bool
+	unlocked bool
+}
+
+func (l *fakeLocker) TryLock(context.Context, time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.grant, nil
+}
+
+func (l *fakeLocker) Unlock(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.unlocked = true
+	return nil
+}
+
+func (l *fakeLocker) setGrant(grant bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.grant = grant
+}
+
+// counter считает запуски задачи.
+type counter struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (c *counter) run(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs++
+	return nil
+}
+
+func (c *counter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
+
+const leaseTTL = 30 * time.Second
+
+type testScheduler struct {
+	*Scheduler
+	clock  *fakeClock
+	locker *fakeLocker
+	stop   func()
+}
+
+// startScheduler запускает планировщик с задачами jobs и ждёт, пока он
+// уснёт на фейковых часах.
+func startScheduler(t *testing.T, grant bool, jobs ...Job) *testScheduler {
+	t.Helper()
+	clock := newFakeClock()
+	locker := &fakeLocker{grant: grant}
+	s := New(locker, clock, leaseTTL, zap.NewNop())
+	for _, job := range jobs {
+		s.Add(job)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	t.Cleanup(stop)
+
+	ts := &testScheduler{Scheduler: s, clock: clock, locker: locker, stop: stop}
+	ts.settle(t, grant)
+	return ts
+}
+
+// settle ждёт, пока продление лидерства и, у лидера, цикл задач снова
+// уснут на часах. Ожидание цикла задач, остановленного при потере
+// лидерства, остаётся на часах, поэтому у ведомого число ожидающих не
+// проверяется.
+func (s *testScheduler) settle(t *testing.T, leader bool) {
+	t.Helper()
+	waitFor(t, func() bool {
+		isLeader, _ := s.Status()
+		if isLeader != leader || !s.clock.sleeping(leaseTTL/3) {
+			return false
+		}
+		return !leader || s.clock.waiting() == 2
+	})
+}
+
+// advance переводит часы шагами продления лидерства.
+func (s *testScheduler) advance(t *testing.T, d time.Duration, leader bool) {
+	t.Helper()
+	for step := leaseTTL / 3; d > 0; d -= step {
+		s.clock.Advance(min(step, d))
+		s.settle(t, leader)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func jobStatus(t *testing.T, s *Scheduler, name string) JobStatus {
+	t.Helper()
+	_, statuses := s.Status()
+	for _, status := range statuses {
+		if status.Name == name {
+			return status
+		}
+	}
+	t.Fatalf("job %q is not registered", name)
+	return JobStatus{}
+}
+
+func TestSchedulerRunsJobsOnInterval(t *testing.T) {
+	var often, rarely counter
+	s := startScheduler(t, true,
+		Job{Name: "often", Interval: time.Minute, Run: often.run},
+		Job{Name: "rarely", Interval: 5 * time.Minute, Run: rarely.run},
+	)
+
+	// Лидер сразу выполняет все задачи
+	if often.count() != 1 || rarely.count() != 1 {
+		t.Fatalf("runs at start = %d, %d; want 1, 1", often.count(), rarely.count())
+	}
+
+	s.advance(t, 50*time.Second, true)
+	if often.count() != 1 {
+		t.Errorf("job ran before its interval: %d runs", often.count())
+	}
+
+	s.advance(t, 4*time.Minute+10*time.Second, true)
+	if often.count() != 6 || rarely.count() != 2 {
+		t.Errorf("runs after 5m = %d, %d; want 6, 2", often.count(), rarely.count())
+	}
+
+	status := jobStatus(t, s.Scheduler, "rarely")
+	if status.Runs != 2 || status.Running || !status.NextRun.Equal(s.clock.Now().Add(5*time.Minute)) {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	fail := true
+	var mu sync.Mutex
+	s := startScheduler(t, true, Job{
+		Name:     "flaky",
+		Interval: time.Minute,
+		Run: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				return errors.New("database is down")
+			}
+			return nil
+		},
+	})
+
+	status := jobStatus(t, s.Scheduler, "flaky")
+	if status.Runs != 1 || status.Failures != 1 || status.LastError != "database is down" {
+		t.Fatalf("status after failure = %+v", status)
+	}
+	// Упавшая задача повторяется через обычный интервал
+	if want := s.clock.Now().Add(time.Minute); !status.NextRun.Equal(want) {
+		t.Errorf("NextRun = %v, want %v", status.NextRun, want)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	s.advance(t, time.Minute, true)
+	status = jobStatus(t, s.Scheduler, "flaky")
+	if status.Runs != 2 || status.Failures != 1 || status.LastError != "" {
+		t.Errorf("status after recovery = %+v", status)
+	}
+}
+
+func TestSchedulerRunsJobsOnlyWhileLeader(t *testing.T) {
+	var job counter
+	s := startScheduler(t, false, Job{Name: "job", Interval: time.Minute, Run: job.run})
+
+	s.advance(t, 5*time.Minute, false)
+	if job.count() != 0 {
+		t.Fatalf("follower ran the job %d times", job.count())
+	}
+
+	// Блокировка освободилась: экземпляр становится лидером при продлении
+	s.locker.setGrant(true)
+	s.advance(t, leaseTTL/3, true)
+	if job.count() != 1 {
+		t.Fatalf("runs after becoming leader = %d, want 1", job.count())
+	}
+
+	s.locker.setGrant(false)
+	s.advance(t, leaseTTL/3, false)
+	s.advance(t, 5*time.Minute, false)
+	if job.count() != 1 {
+		t.Errorf("job ran %d times after leadership was lost", job.count())
+	}
+}
+
+func TestSchedulerCancelsJobsOnLostLeadership(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	var once sync.Once
+	s := New(&fakeLocker{grant: true}, newFakeClock(), leaseTTL, zap.NewNop())
+	s.Add(Job{
+		Name:     "slow",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			once.Do(func() { close(started) })
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+	})
+	clock := s.clock.(*fakeClock)
+	locker := s.locker.(*fakeLocker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	<-started
+	waitFor(t, func() bool { return clock.waiting() == 1 })
+	if status := jobStatus(t, s, "slow"); !status.Running {
+		t.Fatalf("status = %+v, want running", status)
+	}
+
+	locker.setGrant(false)
+	clock.Advance(leaseTTL / 3)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not cancelled after leadership was lost")
+	}
+	waitFor(t, func() bool {
+		leader, _ := s.Status()
+		return !leader
+	})
+	// Прерванный запуск не засчитывается: задачу повторит следующий лидер
+	if status := jobStatus(t, s, "slow"); status.Running || status.Runs != 0 || !status.NextRun.IsZero() {
+		t.Errorf("status after cancel = %+v", status)
+	}
+}
+
+func TestSchedulerUnlocksOnStop(t *testing.T) {
+	var job counter
+	s := startScheduler(t, true, Job{Name: "job", Interval: time.Minute, Run: job.run})
+
+	s.stop()
+	if leader, _ := s.Status(); leader {
+		t.Error("scheduler is still the leader after stop")
+	}
+	s.locker.mu.Lock()
+	defer s.locker.mu.Unlock()
+	if !s.locker.unlocked {
+		t.Error("lock was not released on stop")
+	}
+}
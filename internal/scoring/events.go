@@ -0,0 +1,23 @@
+package scoring
+
+import (
+	"context"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/events"
+)
+
+// SubscribeStats учитывает лайки в статистике анкет, по которой Job
+// пересчитывает рейтинг.
+func SubscribeStats(bus events.Bus, stats db.ProfileStatsQuery) {
+	events.On(bus, func(ctx context.Context, e events.LikeCreated) error {
+		return stats.AddLikes(ctx, e.ToUserID, 1)
+	})
+	events.On(bus, func(ctx context.Context, e events.LikeWithdrawn) error {
+		return stats.AddLikes(ctx, e.ToUserID, -1)
+	})
+	// Ответный лайк тоже лайк, хотя сам он в таблице likes не остаётся
+	events.On(bus, func(ctx context.Context, e events.MatchCreated) error {
+		return stats.AddLikes(ctx, e.PartnerID, 1)
+	})
+}
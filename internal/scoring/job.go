@@ -0,0 +1,73 @@
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"go.uber.org/zap"
+)
+
+// Job пересчитывает rating и score всех активных анкет пачками.
+type Job struct {
+	stats     db.ProfileStatsQuery
+	rating    Scorer
+	score     Scorer
+	batchSize int
+	logger    *zap.Logger
+}
+
+// NewJob создаёт пересчёт: rating заполняется формулой rating, score —
+// формулой score, чтобы обе выдачи эксперимента были актуальны.
+func NewJob(stats db.ProfileStatsQuery, rating, score Scorer, batchSize int, logger *zap.Logger) *Job {
+	return &Job{
+		stats:     stats,
+		rating:    rating,
+		score:     score,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run выполняет один полный проход по анкетам.
+func (j *Job) Run(ctx context.Context) error {
+	started := time.Now()
+	var afterID, processed, changed int64
+
+	for {
+		inputs, err := j.stats.ListScoringInputs(ctx, afterID, uint64(j.batchSize))
+		if err != nil {
+			return fmt.Errorf("failed to list scoring inputs: %w", err)
+		}
+		if len(inputs) == 0 {
+			break
+		}
+
+		now := time.Now()
+		updates := make([]db.ScoreUpdate, 0, len(inputs))
+		for _, in := range inputs {
+			updates = append(updates, db.ScoreUpdate{
+				UserID: in.UserID,
+				Rating: j.rating.Score(in, now),
+				Score:  j.score.Score(in, now),
+			})
+		}
+		n, err := j.stats.UpdateScores(ctx, updates)
+		if err != nil {
+			return fmt.Errorf("failed to update scores: %w", err)
+		}
+
+		processed += int64(len(inputs))
+		changed += n
+		afterID = inputs[len(inputs)-1].UserID
+	}
+
+	j.logger.Info("Scores recomputed",
+		zap.String("rating_formula", j.rating.Name()),
+		zap.String("score_formula", j.score.Name()),
+		zap.Int64("processed", processed),
+		zap.Int64("changed", changed),
+		zap.Duration("took", time.Since(started)))
+	return nil
+}
@@ -0,0 +1,105 @@
+package scoring
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+)
+
+// Scorer считает место анкеты в поиске: чем больше значение, тем выше анкета.
+type Scorer interface {
+	Name() string
+	Score(in *db.ScoringInput, now time.Time) int
+}
+
+// LikeCount — прежняя формула: число действующих входящих лайков.
+type LikeCount struct{}
+
+func (LikeCount) Name() string {
+	return "likes"
+}
+
+func (LikeCount) Score(in *db.ScoringInput, _ time.Time) int {
+	return int(in.ActiveLikes)
+}
+
+// Weighted оценивает долю лайков среди показов со сглаживанием к средней
+// доле (новые анкеты не взлетают и не тонут от пары случайных оценок),
+// добавляет бонус новизны, затухающий со временем, и бонусы за
+// заполненность профиля.
+type Weighted struct {
+	// PriorViews и PriorRate — сколько «виртуальных» показов с долей лайков
+	// PriorRate добавляется к реальным.
+	PriorViews float64
+	PriorRate  float64
+	// FreshnessBonus уменьшается вдвое каждые FreshnessHalfLife с момента
+	// создания анкеты.
+	FreshnessBonus    float64
+	FreshnessHalfLife time.Duration
+	PhotoBonus        float64
+	BioBonus          float64
+}
+
+// DefaultWeighted — параметры новой формулы по умолчанию.
+func DefaultWeighted() Weighted {
+	return Weighted{
+		PriorViews:        20,
+		PriorRate:         0.1,
+		FreshnessBonus:    150,
+		FreshnessHalfLife: 7 * 24 * time.Hour,
+		PhotoBonus:        100,
+		BioBonus:          50,
+	}
+}
+
+func (Weighted) Name() string {
+	return "weighted"
+}
+
+func (w Weighted) Score(in *db.ScoringInput, now time.Time) int {
+	// Показов не может быть меньше лайков: лайк возможен и без учтённого
+	// показа, например из «Кто меня лайкнул»
+	views := math.Max(float64(in.Views), float64(in.LikesReceived))
+	rate := (float64(in.LikesReceived) + w.PriorViews*w.PriorRate) / (views + w.PriorViews)
+	score := rate * 1000
+
+	if w.FreshnessHalfLife > 0 {
+		age := now.Sub(in.CreatedAt)
+		if age < 0 {
+			age = 0
+		}
+		score += w.FreshnessBonus * math.Exp2(-float64(age)/float64(w.FreshnessHalfLife))
+	}
+	if in.HasPhoto {
+		score += w.PhotoBonus
+	}
+	if in.HasBio {
+		score += w.BioBonus
+	}
+	return int(math.Round(score))
+}
+
+// Experiment распределяет ищущих пользователей между старой и новой
+// формулой. Разбиение стабильно: пользователь всегда попадает в одну группу.
+type Experiment struct {
+	percent uint32
+}
+
+// NewExperiment создаёт разбиение, в котором percent процентов
+// пользователей видят выдачу по новой формуле.
+func NewExperiment(percent int) Experiment {
+	return Experiment{percent: uint32(min(max(percent, 0), 100))}
+}
+
+// Order возвращает порядок выдачи для пользователя.
+func (e Experiment) Order(userID int64) db.SearchOrder {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte("rating-ab:" + strconv.FormatInt(userID, 10)))
+	if h.Sum32()%100 < e.percent {
+		return db.OrderByScore
+	}
+	return db.OrderByRating
+}
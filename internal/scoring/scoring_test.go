@@ -0,0 +1,103 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+)
+
+var testNow = time.Date(2025, 4, 21, 12, 0, 0, 0, time.UTC)
+
+func TestLikeCount(t *testing.T) {
+	in := &db.ScoringInput{Views: 100, LikesReceived: 30, ActiveLikes: 7}
+	if got := (LikeCount{}).Score(in, testNow); got != 7 {
+		t.Errorf("Score = %d, want active likes 7", got)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	w := DefaultWeighted()
+	old := testNow.Add(-365 * 24 * time.Hour)
+	tests := []struct {
+		name string
+		in   db.ScoringInput
+		want int
+	}{
+		// Без оценок доля равна априорной 0.1, а бонус новизны полный
+		{name: "new empty profile", in: db.ScoringInput{CreatedAt: testNow}, want: 100 + 150},
+		{name: "new full profile", in: db.ScoringInput{CreatedAt: testNow, HasPhoto: true, HasBio: true}, want: 100 + 150 + 100 + 50},
+		// Через период полураспада остаётся половина бонуса новизны
+		{name: "week old", in: db.ScoringInput{CreatedAt: testNow.Add(-7 * 24 * time.Hour)}, want: 100 + 75},
+		{name: "created in the future", in: db.ScoringInput{CreatedAt: testNow.Add(time.Hour)}, want: 100 + 150},
+		// (10 + 20*0.1) / (10 + 20) = 0.4
+		{name: "liked by everyone", in: db.ScoringInput{Views: 10, LikesReceived: 10, CreatedAt: old}, want: 400},
+		// Лайков больше учтённых показов: показами считаются лайки,
+		// (5 + 2) / (5 + 20) = 0.28
+		{name: "likes without views", in: db.ScoringInput{LikesReceived: 5, CreatedAt: old}, want: 280},
+		// (0 + 2) / (100 + 20)
+		{name: "ignored profile", in: db.ScoringInput{Views: 100, CreatedAt: old}, want: 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Score(&tt.in, testNow); got != tt.want {
+				t.Errorf("Score = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedSmoothsSmallSamples(t *testing.T) {
+	w := DefaultWeighted()
+	old := testNow.Add(-365 * 24 * time.Hour)
+	lucky := w.Score(&db.ScoringInput{Views: 1, LikesReceived: 1, CreatedAt: old}, testNow)
+	popular := w.Score(&db.ScoringInput{Views: 200, LikesReceived: 100, CreatedAt: old}, testNow)
+	if lucky >= popular {
+		t.Errorf("one lucky like (%d) outranks 100 likes of 200 views (%d)", lucky, popular)
+	}
+}
+
+func TestExperiment(t *testing.T) {
+	for _, percent := range []int{0, -10} {
+		e := NewExperiment(percent)
+		for id := int64(1); id <= 1000; id++ {
+			if e.Order(id) != db.OrderByRating {
+				t.Fatalf("NewExperiment(%d): user %d got the new formula", percent, id)
+			}
+		}
+	}
+	for _, percent := range []int{100, 150} {
+		e := NewExperiment(percent)
+		for id := int64(1); id <= 1000; id++ {
+			if e.Order(id) != db.OrderByScore {
+				t.Fatalf("NewExperiment(%d): user %d got the old formula", percent, id)
+			}
+		}
+	}
+}
+
+func TestExperimentSplit(t *testing.T) {
+	e := NewExperiment(30)
+	const users = 10000
+	score := 0
+	for id := int64(1); id <= users; id++ {
+		order := e.Order(id)
+		if order != e.Order(id) {
+			t.Fatalf("user %d switches between groups", id)
+		}
+		if order == db.OrderByScore {
+			score++
+		}
+	}
+	if share := float64(score) / users; share < 0.27 || share > 0.33 {
+		t.Errorf("new formula share = %.3f, want about 0.30", share)
+	}
+
+	// Расширение эксперимента не переводит пользователей обратно
+	wider := NewExperiment(60)
+	for id := int64(1); id <= users; id++ {
+		if e.Order(id) == db.OrderByScore && wider.Order(id) != db.OrderByScore {
+			t.Fatalf("user %d left the new formula when the experiment grew", id)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package drafts
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/tg/models"
+)
+
+const draftTTL = 24 * time.Hour
+
+const (
+	FieldUsername = "username"
+	FieldGender   = "gender"
+	FieldAge      = "age"
+	FieldCityID   = "city_id"
+	FieldBio      = "bio"
+	FieldPhoto    = "photo"
+	FieldLat      = "lat"
+	FieldLon      = "lon"
+	FieldEditing  = "editing"
+
+	FieldPrefGender      = "gender"
+	FieldPrefMinAge      = "min_age"
+	FieldPrefMaxAge      = "max_age"
+	FieldPrefMaxDistance = "max_distance"
+
+	FieldLikeToUserID  = "to_user_id"
+	FieldLikeMessageID = "message_id"
+)
+
+// Drafts — типизированная обёртка над Store для мастеров профиля и
+// настроек поиска.
+type Drafts struct {
+	store Store
+}
+
+func New(store Store) *Drafts {
+	return &Drafts{store: store}
+}
+
+func (d *Drafts) SetProfileField(ctx context.Context, userID int64, field, value string) error {
+	return d.store.SetField(ctx, userID, KindProfile, field, value, draftTTL)
+}
+
+func (d *Drafts) SetPreferencesField(ctx context.Context, userID int64, field, value string) error {
+	return d.store.SetField(ctx, userID, KindPreferences, field, value, draftTTL)
+}
+
+// Profile возвращает черновик профиля. Пустой черновик означает, что мастер
+// не начинался или уже завершён.
+func (d *Drafts) Profile(ctx context.Context, userID int64) (*models.TempUserData, error) {
+	fields, err := d.store.GetAll(ctx, userID, KindProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.TempUserData{
+		Gender:    fields[FieldGender],
+		IsEditing: fields[FieldEditing] == "1",
+	}
+	if v, ok := fields[FieldUsername]; ok {
+		data.Username = &v
+	}
+	if v, ok := fields[FieldAge]; ok {
+		data.Age, _ = strconv.Atoi(v)
+	}
+	if v, ok := fields[FieldCityID]; ok {
+		if cityID, err := strconv.ParseInt(v, 10, 64); err == nil {
+			data.CityID = &cityID
+		}
+	}
+	if v, ok := fields[FieldBio]; ok {
+		data.Bio = &v
+	}
+	if v, ok := fields[FieldPhoto]; ok {
+		data.ProfilePhoto = &v
+	}
+	lat, latErr := strconv.ParseFloat(fields[FieldLat], 64)
+	lon, lonErr := strconv.ParseFloat(fields[FieldLon], 64)
+	if latErr == nil && lonErr == nil {
+		data.Latitude, data.Longitude = &lat, &lon
+	}
+	return data, nil
+}
+
+func (d *Drafts) Preferences(ctx context.Context, userID int64) (*models.TempUserPreferencesData, error) {
+	fields, err := d.store.GetAll(ctx, userID, KindPreferences)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.TempUserPreferencesData{
+		Gender: fields[FieldPrefGender],
+	}
+	data.MinAge, _ = strconv.Atoi(fields[FieldPrefMinAge])
+	data.MaxAge, _ = strconv.Atoi(fields[FieldPrefMaxAge])
+	data.MaxDistance, _ = strconv.Atoi(fields[FieldPrefMaxDistance])
+	return data, nil
+}
+
+// ClearProfileLocation убирает из черновика геопозицию, если город выбран
+// по названию, а не определён по точке.
+func (d *Drafts) ClearProfileLocation(ctx context.Context, userID int64) error {
+	return d.store.DeleteFields(ctx, userID, KindProfile, FieldLat, FieldLon)
+}
+
+func (d *Drafts) DeleteProfile(ctx context.Context, userID int64) error {
+	return d.store.Delete(ctx, userID, KindProfile)
+}
+
+func (d *Drafts) DeletePreferences(ctx context.Context, userID int64) error {
+	return d.store.Delete(ctx, userID, KindPreferences)
+}
+
+// SetLikeMessageTarget запоминает, кому пользователь пишет сообщение к лайку,
+// и карточку, которую нужно убрать после отправки.
+func (d *Drafts) SetLikeMessageTarget(ctx context.Context, userID, toUserID, messageID int64) error {
+	if err := d.store.SetField(ctx, userID, KindLikeMessage, FieldLikeToUserID, strconv.FormatInt(toUserID, 10), draftTTL); err != nil {
+		return err
+	}
+	return d.store.SetField(ctx, userID, KindLikeMessage, FieldLikeMessageID, strconv.FormatInt(messageID, 10), draftTTL)
+}
+
+// LikeMessageTarget возвращает адресата лайка с сообщением; toUserID == 0,
+// если черновика нет.
+func (d *Drafts) LikeMessageTarget(ctx context.Context, userID int64) (toUserID, messageID int64, err error) {
+	fields, err := d.store.GetAll(ctx, userID, KindLikeMessage)
+	if err != nil {
+		return 0, 0, err
+	}
+	toUserID, _ = strconv.ParseInt(fields[FieldLikeToUserID], 10, 64)
+	messageID, _ = strconv.ParseInt(fields[FieldLikeMessageID], 10, 64)
+	return toUserID, messageID, nil
+}
+
+func (d *Drafts) DeleteLikeMessageTarget(ctx context.Context, userID int64) error {
+	return d.store.Delete(ctx, userID, KindLikeMessage)
+}
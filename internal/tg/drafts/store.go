@@ -0,0 +1,144 @@
+package drafts
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const draftKeyVersion = "v1"
+
+type Kind string
+
+const (
+	KindProfile     Kind = "profile"
+	KindPreferences Kind = "preferences"
+	KindLikeMessage Kind = "like_message"
+)
+
+// Store хранит черновики мастеров как набор строковых полей.
+// Каждое изменение поля атомарно и продлевает TTL всего черновика.
+type Store interface {
+	SetField(ctx context.Context, userID int64, kind Kind, field, value string, ttl time.Duration) error
+	GetAll(ctx context.Context, userID int64, kind Kind) (map[string]string, error)
+	DeleteFields(ctx context.Context, userID int64, kind Kind, fields ...string) error
+	Delete(ctx context.Context, userID int64, kind Kind) error
+}
+
+type RedisStore struct {
+	redis *redis.Client
+}
+
+func NewRedisStore(redisClient *redis.Client) *RedisStore {
+	return &RedisStore{redis: redisClient}
+}
+
+func (s *RedisStore) SetField(ctx context.Context, userID int64, kind Kind, field, value string, ttl time.Duration) error {
+	key := draftKey(userID, kind)
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, value)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) GetAll(ctx context.Context, userID int64, kind Kind) (map[string]string, error) {
+	return s.redis.HGetAll(ctx, draftKey(userID, kind)).Result()
+}
+
+func (s *RedisStore) DeleteFields(ctx context.Context, userID int64, kind Kind, fields ...string) error {
+	return s.redis.HDel(ctx, draftKey(userID, kind), fields...).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, userID int64, kind Kind) error {
+	return s.redis.Del(ctx, draftKey(userID, kind)).Err()
+}
+
+func draftKey(userID int64, kind Kind) string {
+	return "draft:" + draftKeyVersion + ":" + string(kind) + ":user:" + strconv.FormatInt(userID, 10)
+}
+
+type memoryKey struct {
+	userID int64
+	kind   Kind
+}
+
+type memoryDraft struct {
+	fields    map[string]string
+	expiresAt time.Time
+}
+
+// MemoryStore — реализация Store в памяти процесса для тестов.
+type MemoryStore struct {
+	mu     sync.Mutex
+	drafts map[memoryKey]*memoryDraft
+	now    func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		drafts: make(map[memoryKey]*memoryDraft),
+		now:    time.Now,
+	}
+}
+
+func (s *MemoryStore) SetField(ctx context.Context, userID int64, kind Kind, field, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := memoryKey{userID: userID, kind: kind}
+	draft, ok := s.drafts[key]
+	if !ok || s.expired(draft) {
+		draft = &memoryDraft{fields: make(map[string]string)}
+		s.drafts[key] = draft
+	}
+	draft.fields[field] = value
+	draft.expiresAt = s.now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) GetAll(ctx context.Context, userID int64, kind Kind) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := memoryKey{userID: userID, kind: kind}
+	draft, ok := s.drafts[key]
+	if !ok {
+		return map[string]string{}, nil
+	}
+	if s.expired(draft) {
+		delete(s.drafts, key)
+		return map[string]string{}, nil
+	}
+	fields := make(map[string]string, len(draft.fields))
+	for k, v := range draft.fields {
+		fields[k] = v
+	}
+	return fields, nil
+}
+
+func (s *MemoryStore) DeleteFields(ctx context.Context, userID int64, kind Kind, fields ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	draft, ok := s.drafts[memoryKey{userID: userID, kind: kind}]
+	if !ok {
+		return nil
+	}
+	for _, field := range fields {
+		delete(draft.fields, field)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, userID int64, kind Kind) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.drafts, memoryKey{userID: userID, kind: kind})
+	return nil
+}
+
+func (s *MemoryStore) expired(draft *memoryDraft) bool {
+	return s.now().After(draft.expiresAt)
+}
@@ -0,0 +1,230 @@
+package drafts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	userID = 42
+	ttl    = 24 * time.Hour
+)
+
+// testStore — хранилище черновиков и способ перевести его часы вперёд.
+type testStore struct {
+	Store
+	advance func(d time.Duration)
+}
+
+func newRedisTestStore(t *testing.T) (testStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return testStore{Store: NewRedisStore(client), advance: mr.FastForward}, mr
+}
+
+func newMemoryTestStore() testStore {
+	store := NewMemoryStore()
+	now := time.Date(2025, 4, 21, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	return testStore{Store: store, advance: func(d time.Duration) { now = now.Add(d) }}
+}
+
+// forEachStore прогоняет тест на обеих реализациях Store.
+func forEachStore(t *testing.T, test func(t *testing.T, s testStore)) {
+	t.Run("redis", func(t *testing.T) {
+		s, _ := newRedisTestStore(t)
+		test(t, s)
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryTestStore())
+	})
+}
+
+func mustGetAll(t *testing.T, s Store, userID int64, kind Kind) map[string]string {
+	t.Helper()
+	fields, err := s.GetAll(context.Background(), userID, kind)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	return fields
+}
+
+func mustSetField(t *testing.T, s Store, userID int64, kind Kind, field, value string) {
+	t.Helper()
+	if err := s.SetField(context.Background(), userID, kind, field, value, ttl); err != nil {
+		t.Fatalf("SetField(%s): %v", field, err)
+	}
+}
+
+func TestGetAllEmptyDraft(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		fields := mustGetAll(t, s, userID, KindProfile)
+		if fields == nil || len(fields) != 0 {
+			t.Errorf("fields = %#v, want empty map", fields)
+		}
+	})
+}
+
+func TestSetFieldKeepsDraftsSeparate(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldGender, "male")
+		mustSetField(t, s, userID, KindPreferences, FieldPrefGender, "female")
+		mustSetField(t, s, userID+1, KindProfile, FieldGender, "female")
+		mustSetField(t, s, userID, KindProfile, FieldAge, "25")
+
+		profile := mustGetAll(t, s, userID, KindProfile)
+		if len(profile) != 2 || profile[FieldGender] != "male" || profile[FieldAge] != "25" {
+			t.Errorf("profile = %v, want gender=male age=25", profile)
+		}
+		if prefs := mustGetAll(t, s, userID, KindPreferences); prefs[FieldPrefGender] != "female" || len(prefs) != 1 {
+			t.Errorf("preferences = %v, want gender=female", prefs)
+		}
+		if other := mustGetAll(t, s, userID+1, KindProfile); other[FieldGender] != "female" || len(other) != 1 {
+			t.Errorf("other user's profile = %v, want gender=female", other)
+		}
+	})
+}
+
+func TestSetFieldOverwritesValue(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldBio, "old")
+		mustSetField(t, s, userID, KindProfile, FieldBio, "new")
+
+		if got := mustGetAll(t, s, userID, KindProfile)[FieldBio]; got != "new" {
+			t.Errorf("bio = %q, want %q", got, "new")
+		}
+	})
+}
+
+func TestDraftExpiresAfterTTL(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldGender, "male")
+		s.advance(ttl + time.Second)
+
+		if fields := mustGetAll(t, s, userID, KindProfile); len(fields) != 0 {
+			t.Errorf("fields = %v after TTL, want empty", fields)
+		}
+
+		// Истёкший черновик не должен «воскрешать» старые поля
+		mustSetField(t, s, userID, KindProfile, FieldAge, "30")
+		if fields := mustGetAll(t, s, userID, KindProfile); len(fields) != 1 || fields[FieldAge] != "30" {
+			t.Errorf("fields = %v, want only age=30", fields)
+		}
+	})
+}
+
+func TestSetFieldRefreshesTTLOfWholeDraft(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldGender, "male")
+		s.advance(ttl - time.Hour)
+		mustSetField(t, s, userID, KindProfile, FieldAge, "25")
+		s.advance(ttl - time.Hour)
+
+		fields := mustGetAll(t, s, userID, KindProfile)
+		if fields[FieldGender] != "male" || fields[FieldAge] != "25" {
+			t.Errorf("fields = %v, want both fields kept by the refreshed TTL", fields)
+		}
+
+		s.advance(2 * time.Hour)
+		if fields := mustGetAll(t, s, userID, KindProfile); len(fields) != 0 {
+			t.Errorf("fields = %v after refreshed TTL, want empty", fields)
+		}
+	})
+}
+
+func TestDeleteFieldsRemovesOnlyGivenFields(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldLat, "55.75")
+		mustSetField(t, s, userID, KindProfile, FieldLon, "37.62")
+		mustSetField(t, s, userID, KindProfile, FieldCityID, "1")
+
+		if err := s.DeleteFields(context.Background(), userID, KindProfile, FieldLat, FieldLon); err != nil {
+			t.Fatalf("DeleteFields: %v", err)
+		}
+		if fields := mustGetAll(t, s, userID, KindProfile); len(fields) != 1 || fields[FieldCityID] != "1" {
+			t.Errorf("fields = %v, want only city_id=1", fields)
+		}
+		if err := s.DeleteFields(context.Background(), userID+1, KindProfile, FieldLat); err != nil {
+			t.Errorf("DeleteFields on missing draft: %v", err)
+		}
+	})
+}
+
+func TestDeleteRemovesOnlyOneDraft(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		mustSetField(t, s, userID, KindProfile, FieldGender, "male")
+		mustSetField(t, s, userID, KindLikeMessage, FieldLikeToUserID, "7")
+
+		if err := s.Delete(context.Background(), userID, KindProfile); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if fields := mustGetAll(t, s, userID, KindProfile); len(fields) != 0 {
+			t.Errorf("profile = %v after Delete, want empty", fields)
+		}
+		if fields := mustGetAll(t, s, userID, KindLikeMessage); fields[FieldLikeToUserID] != "7" {
+			t.Errorf("like message draft = %v, want to_user_id=7", fields)
+		}
+	})
+}
+
+// Параллельные изменения разных полей не должны затирать друг друга, как
+// было бы при чтении и перезаписи черновика целиком.
+func TestConcurrentSetFieldKeepsAllFields(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		const n = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				field := fmt.Sprintf("field_%d", i)
+				errs <- s.SetField(context.Background(), userID, KindProfile, field, fmt.Sprint(i), ttl)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("SetField: %v", err)
+			}
+		}
+
+		fields := mustGetAll(t, s, userID, KindProfile)
+		if len(fields) != n {
+			t.Fatalf("got %d fields, want %d: %v", len(fields), n, fields)
+		}
+		for i := 0; i < n; i++ {
+			if got := fields[fmt.Sprintf("field_%d", i)]; got != fmt.Sprint(i) {
+				t.Errorf("field_%d = %q, want %q", i, got, fmt.Sprint(i))
+			}
+		}
+	})
+}
+
+func TestRedisStoreKeyAndTTL(t *testing.T) {
+	s, mr := newRedisTestStore(t)
+	mustSetField(t, s, userID, KindPreferences, FieldPrefMinAge, "18")
+
+	key := "draft:v1:preferences:user:42"
+	if got := mr.HGet(key, FieldPrefMinAge); got != "18" {
+		t.Errorf("HGet(%s) = %q, want %q", key, got, "18")
+	}
+	if got := mr.TTL(key); got != ttl {
+		t.Errorf("TTL = %v, want %v", got, ttl)
+	}
+
+	mr.FastForward(time.Hour)
+	mustSetField(t, s, userID, KindPreferences, FieldPrefMaxAge, "30")
+	if got := mr.TTL(key); got != ttl {
+		t.Errorf("TTL after SetField = %v, want refreshed %v", got, ttl)
+	}
+}
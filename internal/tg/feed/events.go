@@ -0,0 +1,38 @@
+package feed
+
+import (
+	"context"
+	"errors"
+
+	"github.com/agent-yandex/dating-bot/internal/events"
+)
+
+// Subscribe пересобирает ленты, на которые повлияло событие. Подписывать
+// нужно на синхронную шину, чтобы следующая анкета бралась из новой ленты.
+func (f *Feed) Subscribe(bus events.Bus) {
+	events.On(bus, func(ctx context.Context, e events.UserBlocked) error {
+		return f.Invalidate(ctx, e.BlockerID, e.BlockedID)
+	})
+	// Лайкнутая анкета пропадает из выдачи, но могла уже лежать в очереди,
+	// если лайк поставлен не из ленты
+	events.On(bus, func(ctx context.Context, e events.LikeCreated) error {
+		return f.InvalidateQueued(ctx, e.FromUserID, e.ToUserID)
+	})
+	events.On(bus, func(ctx context.Context, e events.MatchCreated) error {
+		return errors.Join(
+			f.InvalidateQueued(ctx, e.UserID, e.PartnerID),
+			f.InvalidateQueued(ctx, e.PartnerID, e.UserID),
+		)
+	})
+	events.On(bus, func(ctx context.Context, e events.PreferencesChanged) error {
+		return f.Reset(ctx, e.UserID)
+	})
+	// Смена города или пола меняет и подходящие анкеты, и расстояния, а
+	// у тех, кому анкета уже загружена, в очереди лежит её старая версия
+	events.On(bus, func(ctx context.Context, e events.ProfileUpdated) error {
+		if err := f.Reset(ctx, e.UserID); err != nil {
+			return err
+		}
+		return f.InvalidateViewers(ctx, e.UserID)
+	})
+}
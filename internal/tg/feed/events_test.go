@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"go.uber.org/zap"
+)
+
+func TestProfileUpdateRefreshesOtherFeeds(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(map[int64]int{10: 2, 11: 1})
+	f, _ := newTestFeed(t, source, 10, 10)
+	bus := events.NewSyncBus(zap.NewNop())
+	f.Subscribe(bus)
+
+	// У зрителя загружена страница с анкетами 10 и 11
+	if profile, err := f.Current(ctx, viewerID); err != nil || profile.ID != 10 {
+		t.Fatalf("Current = %v, %v", profile, err)
+	}
+
+	source.setRating(11, 50)
+	if err := bus.Publish(ctx, events.ProfileUpdated{UserID: 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Очередь пересобрана: зритель видит анкету 11 уже в новой версии
+	shown := browse(t, f, nil)
+	if len(shown) != 2 || shown[0] != 11 {
+		t.Fatalf("shown = %v, want the updated profile 11 first", shown)
+	}
+}
+
+func TestProfileUpdateSkipsViewersWhoMovedOn(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(map[int64]int{10: 2, 11: 1})
+	f, mr := newTestFeed(t, source, 10, 10)
+	bus := events.NewSyncBus(zap.NewNop())
+	f.Subscribe(bus)
+
+	if _, err := f.Current(ctx, viewerID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Advance(ctx, viewerID, 10, ActionNone); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(f.key("viewers", 10)) {
+		members, _ := mr.Members(f.key("viewers", 10))
+		t.Fatalf("viewers of a passed profile = %v, want none", members)
+	}
+
+	if err := bus.Publish(ctx, events.ProfileUpdated{UserID: 10}); err != nil {
+		t.Fatal(err)
+	}
+	// Лента зрителя не тронута: анкета 11 осталась в загруженной очереди
+	queueKey, _, err := f.pageKeys(ctx, viewerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists(queueKey) {
+		t.Error("feed was invalidated for a profile the viewer had already passed")
+	}
+}
+
+func TestLikeOutsideFeedDropsQueuedProfile(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(map[int64]int{10: 3, 11: 2, 12: 1})
+	f, _ := newTestFeed(t, source, 10, 10)
+	bus := events.NewSyncBus(zap.NewNop())
+	f.Subscribe(bus)
+
+	if profile, err := f.Current(ctx, viewerID); err != nil || profile.ID != 10 {
+		t.Fatalf("Current = %v, %v", profile, err)
+	}
+	queueKey, _, err := f.pageKeys(ctx, viewerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Лайк текущей карточки из ленты не пересобирает её: карточку уберёт
+	// обработчик свайпа
+	if err := bus.Publish(ctx, events.LikeCreated{FromUserID: viewerID, ToUserID: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if after, _, _ := f.pageKeys(ctx, viewerID); after != queueKey {
+		t.Fatal("feed was invalidated by a like of the current card")
+	}
+
+	// Анкету 11 лайкнули из списка «Кто меня лайкнул», и база её больше не
+	// выдаёт; анкету 12 — ответным лайком, образовав пару
+	source.hide(11)
+	source.hide(12)
+	if err := bus.Publish(ctx, events.LikeCreated{FromUserID: viewerID, ToUserID: 11}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, events.MatchCreated{UserID: viewerID, PartnerID: 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	if shown := browse(t, f, nil); len(shown) != 1 || shown[0] != 10 {
+		t.Errorf("shown = %v, want only 10", shown)
+	}
+}
@@ -0,0 +1,368 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/cache"
+	"github.com/agent-yandex/dating-bot/internal/config"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/scoring"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const feedKeyVersion = "v3"
+
+// Source — запросы к базе, из которых собирается лента. Обе выборки
+// пропускают анкеты, отмеченные в Views.
+type Source interface {
+	SelectCandidateIDs(ctx context.Context, id int64, order db.SearchOrder, limit uint64) ([]int64, error)
+	SelectUsersByIDs(ctx context.Context, id int64, ids []int64) ([]*db.SearchResult, error)
+}
+
+// Views — просмотренные анкеты в базе.
+type Views interface {
+	Insert(ctx context.Context, fromUserID, toUserID int64, ttl time.Duration) error
+	Delete(ctx context.Context, fromUserID, toUserID int64) error
+	DeleteAllByUser(ctx context.Context, fromUserID int64) (int64, error)
+}
+
+// Feed — лента анкет поиска. Порядок выдачи фиксируется снимком: при первом
+// запросе в Redis сохраняются id подходящих анкет в порядке рейтинга, и
+// страницы дальше берутся из снимка. Рейтинг меняется со временем, поэтому
+// курсор по нему пропускал бы и повторял анкеты, а снимок от этого не
+// зависит. Когда снимок заканчивается, он собирается заново сверху без уже
+// показанных анкет: их запоминает таблица просмотров, и фильтрует база, так
+// что запрос не растёт с числом пролистанных анкет. Для каждого пользователя
+// в Redis хранятся снимок и очередь загруженных карточек текущей страницы, а
+// для каждой анкеты — зрители: пользователи, у которых она лежит в очереди,
+// чтобы при её изменении сбросить их ленты. Снимок и очередь лежат в версионном
+// пространстве пользователя: сброс ленты — один INCR, а страница, которую
+// дозагружали во время сброса, попадает в старую версию и не читается.
+type Feed struct {
+	redis *redis.Client
+	// pages — версионные пространства со снимком и очередью
+	pages *cache.Versioned
+	users Source
+	views Views
+	// experiment выбирает формулу сортировки выдачи для пользователя
+	experiment   scoring.Experiment
+	pageSize     int
+	snapshotSize int
+	ttl          time.Duration
+	seenTTL      time.Duration
+	// undoWindow — сколько времени после свайпа его можно отменить
+	undoWindow time.Duration
+	logger     *zap.Logger
+}
+
+func New(redisClient *redis.Client, users Source, views Views, experiment scoring.Experiment, cfg config.SearchConfig, logger *zap.Logger) *Feed {
+	return &Feed{
+		redis:        redisClient,
+		pages:        cache.NewVersioned(redisClient, "feed:"+feedKeyVersion+":pages", cfg.ResultsTTL),
+		users:        users,
+		views:        views,
+		experiment:   experiment,
+		pageSize:     cfg.PageSize,
+		snapshotSize: cfg.SnapshotSize,
+		ttl:          cfg.ResultsTTL,
+		seenTTL:      cfg.SeenTTL,
+		undoWindow:   cfg.UndoWindow,
+		logger:       logger,
+	}
+}
+
+// Current возвращает анкету, которую пользователь видит сейчас, подгружая
+// следующую страницу при необходимости. nil означает, что лента закончилась.
+func (f *Feed) Current(ctx context.Context, userID int64) (*db.SearchResult, error) {
+	for {
+		queueKey, snapshotKey, err := f.pageKeys(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := f.head(ctx, queueKey)
+		if err != nil || profile != nil {
+			return profile, err
+		}
+
+		filled, err := f.fill(ctx, userID, queueKey, snapshotKey)
+		if err != nil {
+			return nil, err
+		}
+		if !filled {
+			return nil, nil
+		}
+	}
+}
+
+// head возвращает первую карточку очереди или nil, если очередь пуста.
+func (f *Feed) head(ctx context.Context, queueKey string) (*db.SearchResult, error) {
+	raw, err := f.redis.LIndex(ctx, queueKey, 0).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed queue: %w", err)
+	}
+	profile := &db.SearchResult{}
+	if err := json.Unmarshal([]byte(raw), profile); err != nil {
+		return nil, fmt.Errorf("failed to decode feed item: %w", err)
+	}
+	return profile, nil
+}
+
+// Advance отмечает анкету просмотренной и убирает её из головы очереди.
+// Если action не ActionNone, свайп записывается в историю для отмены.
+func (f *Feed) Advance(ctx context.Context, userID, profileID int64, action Action) error {
+	if err := f.views.Insert(ctx, userID, profileID, f.seenTTL); err != nil {
+		return fmt.Errorf("failed to mark profile as seen: %w", err)
+	}
+
+	current, err := f.Current(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Очередь могла быть пересобрана, пока карточка была на экране
+	if current == nil || current.ID != profileID {
+		return nil
+	}
+	queueKey, _, err := f.pageKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPop(ctx, queueKey)
+		pipe.SRem(ctx, f.key("viewers", profileID), userID)
+		if action == ActionNone {
+			return nil
+		}
+		return f.recordSwipe(ctx, pipe, userID, &Swipe{
+			Action:  action,
+			Profile: *current,
+			At:      time.Now(),
+		})
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to pop feed queue: %w", err)
+	}
+	return nil
+}
+
+// Invalidate сбрасывает снимок и загруженные карточки, сохраняя просмотры:
+// следующая выдача начнётся сверху без повторов.
+func (f *Feed) Invalidate(ctx context.Context, userIDs ...int64) error {
+	if err := f.pages.Invalidate(ctx, userIDs...); err != nil {
+		return fmt.Errorf("failed to invalidate feed: %w", err)
+	}
+	return nil
+}
+
+// Reset полностью сбрасывает ленту, включая показанные анкеты. История
+// свайпов сохраняется, чтобы последний свайп можно было отменить.
+func (f *Feed) Reset(ctx context.Context, userID int64) error {
+	if err := f.Invalidate(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := f.views.DeleteAllByUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to reset feed: %w", err)
+	}
+	return nil
+}
+
+// fill загружает в очередь следующую страницу снимка, пропуская анкеты,
+// которые перестали подходить или уже просмотрены. Возвращает false, если
+// выдача исчерпана.
+func (f *Feed) fill(ctx context.Context, userID int64, queueKey, snapshotKey string) (bool, error) {
+	resnapshotted := false
+	for {
+		ids, err := f.nextIDs(ctx, snapshotKey)
+		if err != nil {
+			return false, err
+		}
+		if len(ids) == 0 {
+			// Свежий снимок уже разобран целиком: новых анкет нет
+			if resnapshotted {
+				return false, nil
+			}
+			count, err := f.snapshot(ctx, userID, snapshotKey)
+			if err != nil {
+				return false, err
+			}
+			if count == 0 {
+				return false, nil
+			}
+			resnapshotted = true
+			continue
+		}
+
+		page, err := f.users.SelectUsersByIDs(ctx, userID, ids)
+		if err != nil {
+			return false, err
+		}
+		items := make([]interface{}, 0, len(page))
+		queued := make([]int64, 0, len(page))
+		for _, profile := range page {
+			raw, err := json.Marshal(profile)
+			if err != nil {
+				return false, fmt.Errorf("failed to encode feed item: %w", err)
+			}
+			items = append(items, raw)
+			queued = append(queued, profile.ID)
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		_, err = f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.RPush(ctx, queueKey, items...)
+			pipe.Expire(ctx, queueKey, f.ttl)
+			f.pages.Extend(ctx, pipe, userID)
+			for _, profileID := range queued {
+				f.addViewer(ctx, pipe, profileID, userID)
+			}
+			return nil
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to store feed page: %w", err)
+		}
+		f.logger.Debug("Feed page loaded",
+			zap.Int64("user_id", userID),
+			zap.Int("count", len(items)))
+		return true, nil
+	}
+}
+
+// addViewer запоминает, что анкета profileID лежит в очереди userID.
+// Зритель убирается из множества, когда листает анкету дальше; если он
+// просто бросил ленту, множество истекает вместе с очередью.
+func (f *Feed) addViewer(ctx context.Context, pipe redis.Pipeliner, profileID, userID int64) {
+	viewersKey := f.key("viewers", profileID)
+	pipe.SAdd(ctx, viewersKey, userID)
+	pipe.Expire(ctx, viewersKey, f.ttl)
+}
+
+// InvalidateViewers сбрасывает ленты всех, у кого в очереди лежит анкета
+// profileID, например после её изменения: карточки в очереди хранят анкету
+// целиком, и без сброса зрители увидели бы старую версию.
+func (f *Feed) InvalidateViewers(ctx context.Context, profileID int64) error {
+	viewersKey := f.key("viewers", profileID)
+	var members *redis.StringSliceCmd
+	_, err := f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		members = pipe.SMembers(ctx, viewersKey)
+		pipe.Del(ctx, viewersKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read profile viewers: %w", err)
+	}
+	viewers := make([]int64, 0, len(members.Val()))
+	for _, v := range members.Val() {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to decode profile viewer: %w", err)
+		}
+		viewers = append(viewers, id)
+	}
+	return f.Invalidate(ctx, viewers...)
+}
+
+// InvalidateQueued сбрасывает ленту viewerID, если анкета profileID лежит в
+// её очереди, например после лайка из списка «Кто меня лайкнул»: такая
+// анкета больше не подходит. Текущую карточку не трогает — её уберёт
+// обработчик свайпа.
+func (f *Feed) InvalidateQueued(ctx context.Context, viewerID, profileID int64) error {
+	queued, err := f.redis.SIsMember(ctx, f.key("viewers", profileID), viewerID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read profile viewers: %w", err)
+	}
+	if !queued {
+		return nil
+	}
+	queueKey, _, err := f.pageKeys(ctx, viewerID)
+	if err != nil {
+		return err
+	}
+	current, err := f.head(ctx, queueKey)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.ID == profileID {
+		return nil
+	}
+	return f.Invalidate(ctx, viewerID)
+}
+
+// snapshot фиксирует порядок выдачи: сохраняет id подходящих анкет, кроме
+// уже показанных. Возвращает число анкет в снимке.
+func (f *Feed) snapshot(ctx context.Context, userID int64, snapshotKey string) (int, error) {
+	order := f.experiment.Order(userID)
+	ids, err := f.users.SelectCandidateIDs(ctx, userID, order, uint64(f.snapshotSize))
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	_, err = f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, snapshotKey)
+		pipe.RPush(ctx, snapshotKey, values...)
+		pipe.Expire(ctx, snapshotKey, f.ttl)
+		f.pages.Extend(ctx, pipe, userID)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store feed snapshot: %w", err)
+	}
+	f.logger.Debug("Feed snapshot taken",
+		zap.Int64("user_id", userID),
+		zap.String("order", string(order)),
+		zap.Int("count", len(ids)))
+	return len(ids), nil
+}
+
+// nextIDs забирает из снимка id следующей страницы.
+func (f *Feed) nextIDs(ctx context.Context, snapshotKey string) ([]int64, error) {
+	var head *redis.StringSliceCmd
+	_, err := f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		head = pipe.LRange(ctx, snapshotKey, 0, int64(f.pageSize)-1)
+		pipe.LTrim(ctx, snapshotKey, int64(f.pageSize), -1)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed snapshot: %w", err)
+	}
+	ids := make([]int64, 0, len(head.Val()))
+	for _, v := range head.Val() {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode feed snapshot: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// pageKeys возвращает ключи очереди и снимка в текущей версии ленты
+// пользователя.
+func (f *Feed) pageKeys(ctx context.Context, userID int64) (queueKey, snapshotKey string, err error) {
+	keys, err := f.pages.Keys(ctx, userID, "queue", "snapshot")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read feed version: %w", err)
+	}
+	return keys[0], keys[1], nil
+}
+
+func (f *Feed) key(kind string, userID int64) string {
+	return "feed:" + feedKeyVersion + ":" + kind + ":user:" + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,247 @@
+package feed
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/config"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/scoring"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const viewerID = 1
+
+// fakeSource — анкеты в памяти с изменяемым рейтингом и просмотры
+// единственного зрителя. Порядок и фильтры повторяют запросы к базе:
+// сначала больший рейтинг, при равенстве — id.
+type fakeSource struct {
+	mu      sync.Mutex
+	ratings map[int64]int
+	hidden  map[int64]bool
+	viewed  map[int64]bool
+}
+
+func newFakeSource(ratings map[int64]int) *fakeSource {
+	return &fakeSource{ratings: ratings, hidden: make(map[int64]bool), viewed: make(map[int64]bool)}
+}
+
+func (s *fakeSource) SelectCandidateIDs(_ context.Context, _ int64, _ db.SearchOrder, limit uint64) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int64
+	for id := range s.ratings {
+		if s.matches(id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		return cmp.Or(cmp.Compare(s.ratings[b], s.ratings[a]), cmp.Compare(a, b))
+	})
+	return ids[:min(len(ids), int(limit))], nil
+}
+
+func (s *fakeSource) SelectUsersByIDs(_ context.Context, _ int64, ids []int64) ([]*db.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []*db.SearchResult
+	for _, id := range ids {
+		if s.matches(id) {
+			results = append(results, &db.SearchResult{User: db.User{ID: id, Rating: s.ratings[id]}})
+		}
+	}
+	return results, nil
+}
+
+// matches сообщает, попадает ли анкета в выдачу.
+func (s *fakeSource) matches(id int64) bool {
+	_, ok := s.ratings[id]
+	return ok && !s.hidden[id] && !s.viewed[id]
+}
+
+func (s *fakeSource) Insert(_ context.Context, _, toUserID int64, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.viewed[toUserID] = true
+	return nil
+}
+
+func (s *fakeSource) Delete(_ context.Context, _, toUserID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.viewed, toUserID)
+	return nil
+}
+
+func (s *fakeSource) DeleteAllByUser(context.Context, int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := int64(len(s.viewed))
+	clear(s.viewed)
+	return count, nil
+}
+
+func (s *fakeSource) setRating(id int64, rating int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ratings[id] = rating
+}
+
+func (s *fakeSource) hide(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hidden[id] = true
+}
+
+func newTestFeed(t *testing.T, source *fakeSource, pageSize, snapshotSize int) (*Feed, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return New(client, source, source, scoring.NewExperiment(0), config.SearchConfig{
+		PageSize:     pageSize,
+		SnapshotSize: snapshotSize,
+		ResultsTTL:   30 * time.Minute,
+		SeenTTL:      24 * time.Hour,
+		UndoWindow:   5 * time.Minute,
+	}, zap.NewNop()), mr
+}
+
+// browse листает ленту до конца и возвращает id показанных анкет. После
+// каждой анкеты вызывается between, чтобы тест мог изменить данные.
+func browse(t *testing.T, f *Feed, between func(shown int64)) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	var shown []int64
+	for {
+		profile, err := f.Current(ctx, viewerID)
+		if err != nil {
+			t.Fatalf("Current: %v", err)
+		}
+		if profile == nil {
+			return shown
+		}
+		shown = append(shown, profile.ID)
+		if len(shown) > 100 {
+			t.Fatalf("feed does not end: %v", shown)
+		}
+		if err := f.Advance(ctx, viewerID, profile.ID, ActionNone); err != nil {
+			t.Fatalf("Advance: %v", err)
+		}
+		if between != nil {
+			between(profile.ID)
+		}
+	}
+}
+
+func assertShownOnce(t *testing.T, shown []int64, want ...int64) {
+	t.Helper()
+	sorted := slices.Clone(shown)
+	slices.Sort(sorted)
+	slices.Sort(want)
+	if !slices.Equal(sorted, want) {
+		t.Errorf("shown = %v, want each of %v exactly once", shown, want)
+	}
+}
+
+func TestFeedNoDuplicatesOrGapsWhileRatingsChange(t *testing.T) {
+	ratings := make(map[int64]int)
+	var all []int64
+	for id := int64(10); id < 20; id++ {
+		ratings[id] = int(id)
+		all = append(all, id)
+	}
+	source := newFakeSource(ratings)
+	f, _ := newTestFeed(t, source, 3, 100)
+
+	// Показанные анкеты взлетают в рейтинге, а непоказанные падают: курсор
+	// по рейтингу на таких данных и повторял бы, и пропускал анкеты
+	next := 100
+	shown := browse(t, f, func(shown int64) {
+		next++
+		source.setRating(shown, next)
+		for _, id := range all {
+			if id%2 == 0 && id != shown {
+				source.setRating(id, -next)
+			}
+		}
+	})
+
+	assertShownOnce(t, shown, all...)
+	if !slices.Equal(shown, []int64{19, 18, 17, 16, 15, 14, 13, 12, 11, 10}) {
+		t.Errorf("shown = %v, want the order from the moment the feed was opened", shown)
+	}
+}
+
+func TestFeedResnapshotsWhenSnapshotIsExhausted(t *testing.T) {
+	source := newFakeSource(map[int64]int{10: 5, 11: 4, 12: 3, 13: 2, 14: 1})
+	f, _ := newTestFeed(t, source, 2, 2)
+
+	shown := browse(t, f, nil)
+	assertShownOnce(t, shown, 10, 11, 12, 13, 14)
+
+	// Анкета, появившаяся после конца ленты, попадает в новый снимок
+	source.setRating(15, 0)
+	shown = browse(t, f, nil)
+	assertShownOnce(t, shown, 15)
+}
+
+func TestFeedSkipsProfilesThatStopMatching(t *testing.T) {
+	source := newFakeSource(map[int64]int{10: 3, 11: 2, 12: 1})
+	f, _ := newTestFeed(t, source, 1, 10)
+
+	shown := browse(t, f, func(shown int64) {
+		if shown == 10 {
+			source.hide(11)
+		}
+	})
+	if !slices.Equal(shown, []int64{10, 12}) {
+		t.Errorf("shown = %v, want [10 12]", shown)
+	}
+}
+
+func TestFeedInvalidateAndReset(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(map[int64]int{10: 2, 11: 1})
+	f, _ := newTestFeed(t, source, 10, 10)
+
+	if shown := browse(t, f, nil); len(shown) != 2 {
+		t.Fatalf("shown = %v", shown)
+	}
+
+	// Invalidate пересобирает ленту, но не показывает анкеты повторно
+	if err := f.Invalidate(ctx, viewerID); err != nil {
+		t.Fatal(err)
+	}
+	if shown := browse(t, f, nil); len(shown) != 0 {
+		t.Errorf("after Invalidate shown = %v, want none", shown)
+	}
+
+	if err := f.Reset(ctx, viewerID); err != nil {
+		t.Fatal(err)
+	}
+	assertShownOnce(t, browse(t, f, nil), 10, 11)
+}
+
+func TestFeedCorruptSnapshotIsAnError(t *testing.T) {
+	source := newFakeSource(map[int64]int{10: 1})
+	f, mr := newTestFeed(t, source, 10, 10)
+
+	ctx := context.Background()
+	_, snapshotKey, err := f.pageKeys(ctx, viewerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mr.RPush(snapshotKey, "not-an-id"); err != nil {
+		t.Fatal(err)
+	}
+	if profile, err := f.Current(ctx, viewerID); err == nil {
+		t.Fatalf("Current = %v, want an error", profile)
+	}
+}
@@ -0,0 +1,122 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/go-redis/redis/v8"
+)
+
+// historyDepth — сколько последних свайпов можно отменить подряд.
+const historyDepth = 10
+
+// Action — действие пользователя над анкетой в ленте.
+type Action string
+
+const (
+	// ActionNone — анкета пролистана без действия, которое можно отменить
+	// (например, лайк образовал пару).
+	ActionNone Action = ""
+	ActionLike Action = "like"
+	// ActionPass — дизлайк, сохранённый как пропуск.
+	ActionPass Action = "pass"
+)
+
+// Swipe — запись истории свайпов: что сделал пользователь и с какой анкетой.
+type Swipe struct {
+	Action  Action          `json:"action"`
+	Profile db.SearchResult `json:"profile"`
+	At      time.Time       `json:"at"`
+}
+
+// CanUndo сообщает, есть ли у пользователя свайп, который ещё можно отменить.
+func (f *Feed) CanUndo(ctx context.Context, userID int64) (bool, error) {
+	swipe, err := f.lastSwipe(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return swipe != nil, nil
+}
+
+// PopSwipe снимает последний свайп из истории. nil означает, что отменять
+// нечего или окно отмены уже прошло. Свайп снимается одной командой, поэтому
+// при двойном нажатии отмены его получит только один запрос.
+func (f *Feed) PopSwipe(ctx context.Context, userID int64) (*Swipe, error) {
+	raw, err := f.redis.LPop(ctx, f.key("history", userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pop swipe history: %w", err)
+	}
+	return f.decodeSwipe(raw)
+}
+
+// Rewind возвращает анкету в голову очереди и убирает её из показанных,
+// чтобы она снова стала текущей.
+func (f *Feed) Rewind(ctx context.Context, userID int64, profile *db.SearchResult) error {
+	raw, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode feed item: %w", err)
+	}
+	if err := f.views.Delete(ctx, userID, profile.ID); err != nil {
+		return fmt.Errorf("failed to rewind feed: %w", err)
+	}
+	queueKey, _, err := f.pageKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, queueKey, raw)
+		pipe.Expire(ctx, queueKey, f.ttl)
+		f.pages.Extend(ctx, pipe, userID)
+		f.addViewer(ctx, pipe, profile.ID, userID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rewind feed: %w", err)
+	}
+	return nil
+}
+
+// recordSwipe добавляет свайп в историю. Записи старше окна отмены
+// отбрасываются при чтении, а весь список истекает вместе с окном.
+func (f *Feed) recordSwipe(ctx context.Context, pipe redis.Pipeliner, userID int64, swipe *Swipe) error {
+	raw, err := json.Marshal(swipe)
+	if err != nil {
+		return fmt.Errorf("failed to encode swipe: %w", err)
+	}
+	historyKey := f.key("history", userID)
+	pipe.LPush(ctx, historyKey, raw)
+	pipe.LTrim(ctx, historyKey, 0, historyDepth-1)
+	pipe.Expire(ctx, historyKey, f.undoWindow)
+	return nil
+}
+
+func (f *Feed) lastSwipe(ctx context.Context, userID int64) (*Swipe, error) {
+	raw, err := f.redis.LIndex(ctx, f.key("history", userID), 0).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read swipe history: %w", err)
+	}
+	return f.decodeSwipe(raw)
+}
+
+// decodeSwipe разбирает запись истории; свайп старше окна отмены
+// считается отсутствующим.
+func (f *Feed) decodeSwipe(raw string) (*Swipe, error) {
+	swipe := &Swipe{}
+	if err := json.Unmarshal([]byte(raw), swipe); err != nil {
+		return nil, fmt.Errorf("failed to decode swipe: %w", err)
+	}
+	if time.Since(swipe.At) > f.undoWindow {
+		return nil, nil
+	}
+	return swipe, nil
+}
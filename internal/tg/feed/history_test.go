@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/go-redis/redis/v8"
+)
+
+func recordTestSwipe(t *testing.T, f *Feed, profileID int64, at time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return f.recordSwipe(ctx, pipe, viewerID, &Swipe{
+			Action:  ActionLike,
+			Profile: db.SearchResult{User: db.User{ID: profileID}},
+			At:      at,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPopSwipeReturnsSwipesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newTestFeed(t, newFakeSource(nil), 10, 10)
+	recordTestSwipe(t, f, 10, time.Now())
+	recordTestSwipe(t, f, 11, time.Now())
+
+	for _, want := range []int64{11, 10} {
+		swipe, err := f.PopSwipe(ctx, viewerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if swipe == nil || swipe.Profile.ID != want {
+			t.Fatalf("PopSwipe = %+v, want profile %d", swipe, want)
+		}
+	}
+	if swipe, err := f.PopSwipe(ctx, viewerID); err != nil || swipe != nil {
+		t.Errorf("PopSwipe on empty history = %+v, %v", swipe, err)
+	}
+}
+
+func TestPopSwipeIgnoresExpiredSwipe(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newTestFeed(t, newFakeSource(nil), 10, 10)
+	recordTestSwipe(t, f, 10, time.Now().Add(-time.Hour))
+
+	if ok, err := f.CanUndo(ctx, viewerID); err != nil || ok {
+		t.Errorf("CanUndo = %v, %v; want false", ok, err)
+	}
+	if swipe, err := f.PopSwipe(ctx, viewerID); err != nil || swipe != nil {
+		t.Errorf("PopSwipe = %+v, %v; want nil", swipe, err)
+	}
+}
+
+func TestConcurrentPopSwipeTakesSwipeOnce(t *testing.T) {
+	f, _ := newTestFeed(t, newFakeSource(nil), 10, 10)
+	recordTestSwipe(t, f, 10, time.Now())
+
+	var popped atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			swipe, err := f.PopSwipe(context.Background(), viewerID)
+			if err != nil {
+				t.Error(err)
+			}
+			if swipe != nil {
+				popped.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := popped.Load(); n != 1 {
+		t.Errorf("swipe was popped %d times, want 1", n)
+	}
+}
+
+func TestRewindReturnsViewedProfile(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newTestFeed(t, newFakeSource(map[int64]int{10: 2, 11: 1}), 10, 10)
+
+	profile, err := f.Current(ctx, viewerID)
+	if err != nil || profile == nil || profile.ID != 10 {
+		t.Fatalf("Current = %v, %v", profile, err)
+	}
+	if err := f.Advance(ctx, viewerID, 10, ActionLike); err != nil {
+		t.Fatal(err)
+	}
+	swipe, err := f.PopSwipe(ctx, viewerID)
+	if err != nil || swipe == nil {
+		t.Fatalf("PopSwipe = %+v, %v", swipe, err)
+	}
+	if err := f.Rewind(ctx, viewerID, &swipe.Profile); err != nil {
+		t.Fatal(err)
+	}
+
+	// Просмотр снят: анкета остаётся в ленте и после её пересборки
+	if err := f.Invalidate(ctx, viewerID); err != nil {
+		t.Fatal(err)
+	}
+	if shown := browse(t, f, nil); len(shown) != 2 || shown[0] != 10 {
+		t.Errorf("shown = %v, want the rewound profile 10 first", shown)
+	}
+}
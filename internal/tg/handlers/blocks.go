@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const blockedPageSize = 10
+
+func (h *CallbackHandler) handleBlock(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64, report bool) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	// Жалоба сохраняется для модераторов; её потеря не должна мешать блокировке
+	if report {
+		if err := h.db.Reports.Insert(context.Background(), userID, profileID); err != nil {
+			h.logger.Error("Failed to save report",
+				zap.Int64("reporter_id", userID),
+				zap.Int64("reported_id", profileID),
+				zap.Error(err))
+		}
+	}
+
+	_, err := h.db.Blocks.Insert(context.Background(), &db.Block{
+		BlockerID: userID,
+		BlockedID: profileID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+			h.logger.Error("Failed to insert block",
+				zap.Int64("blocker_id", userID),
+				zap.Int64("blocked_id", profileID),
+				zap.Error(err))
+			_, _ = b.SendMessage(chatID, "Произошла ошибка при блокировке пользователя.", nil)
+			return err
+		}
+	}
+
+	// Удаляем лайки в обе стороны, чтобы заблокированный не появился в «Кто меня лайкнул»
+	for _, pair := range [][2]int64{{userID, profileID}, {profileID, userID}} {
+		if err := h.db.Likes.DeleteByIDs(context.Background(), pair[0], pair[1]); err != nil {
+			h.logger.Error("Failed to delete like on block",
+				zap.Int64("from_user_id", pair[0]),
+				zap.Int64("to_user_id", pair[1]),
+				zap.Error(err))
+		}
+	}
+	if err := h.db.Matches.Delete(context.Background(), userID, profileID); err != nil {
+		h.logger.Error("Failed to delete match on block",
+			zap.Int64("blocker_id", userID),
+			zap.Int64("blocked_id", profileID),
+			zap.Error(err))
+	}
+	h.publish(events.UserBlocked{BlockerID: userID, BlockedID: profileID})
+
+	_, err = b.DeleteMessage(chatID, messageID, nil)
+	if err != nil {
+		h.logger.Warn("Failed to delete message",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+
+	text := "Пользователь заблокирован 🚫"
+	if report {
+		text = "Спасибо! Жалоба отправлена, пользователь заблокирован 🚫"
+	}
+	_, _ = b.SendMessage(chatID, text, nil)
+
+	// Кэш сброшен, поэтому текущий индекс уже указывает на следующую анкету,
+	// а лента поиска пересоберётся без заблокированного
+	if h.stateMgr.Get(userID) == states.StateViewLikes {
+		currentIndex := h.stateMgr.GetLikesCurrentIndex(userID)
+		offset := uint64(currentIndex/h.search.LikesPageSize) * uint64(h.search.LikesPageSize)
+		profiles, err := h.getLikeResults(userID, offset)
+		if err != nil {
+			_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке следующего профиля.", nil)
+			return err
+		}
+		return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%h.search.LikesPageSize)
+	}
+
+	return h.sendCurrentProfile(b, chatID, userID)
+}
+
+func (h *CallbackHandler) handleUnblock(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64, page int) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	err := h.db.Blocks.Delete(context.Background(), &db.Block{
+		BlockerID: userID,
+		BlockedID: profileID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to delete block",
+			zap.Int64("blocker_id", userID),
+			zap.Int64("blocked_id", profileID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при разблокировке пользователя.", nil)
+		return err
+	}
+	h.invalidateUserCaches(userID, profileID)
+
+	return h.editBlockedPage(b, chatID, messageID, userID, page)
+}
+
+func (h *CallbackHandler) handleBlockedPage(b *gotgbot.Bot, ctx *ext.Context, userID int64, page int) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+	return h.editBlockedPage(b, chatID, messageID, userID, page)
+}
+
+func (h *CallbackHandler) editBlockedPage(b *gotgbot.Bot, chatID, messageID, userID int64, page int) error {
+	text, keyboard, err := h.blockedPage(userID, page)
+	if err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке списка.", nil)
+		return err
+	}
+	_, _, err = b.EditMessageText(text, &gotgbot.EditMessageTextOpts{
+		ChatId:      chatID,
+		MessageId:   messageID,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		h.logger.Warn("Failed to edit blocked list",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// blockedPage собирает страницу списка заблокированных с кнопками разблокировки
+// и навигации. Если страница опустела после разблокировки, показывается предыдущая.
+func (h *CallbackHandler) blockedPage(userID int64, page int) (string, gotgbot.InlineKeyboardMarkup, error) {
+	if page < 0 {
+		page = 0
+	}
+
+	var users []*db.User
+	for {
+		// Берём на одну запись больше, чтобы понять, есть ли следующая страница
+		var err error
+		users, err = h.db.Blocks.GetBlockedUsers(context.Background(), userID, uint64(page*blockedPageSize), blockedPageSize+1)
+		if err != nil {
+			h.logger.Error("Failed to fetch blocked users",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+			return "", gotgbot.InlineKeyboardMarkup{}, err
+		}
+		if len(users) > 0 || page == 0 {
+			break
+		}
+		page--
+	}
+
+	if len(users) == 0 {
+		return "Список заблокированных пуст.", gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{},
+		}, nil
+	}
+
+	hasNext := len(users) > blockedPageSize
+	if hasNext {
+		users = users[:blockedPageSize]
+	}
+
+	keyboard := make([][]gotgbot.InlineKeyboardButton, 0, len(users)+1)
+	for _, u := range users {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: "🔓 " + displayName(u), CallbackData: fmt.Sprintf("unblock:%d:%d", u.ID, page)},
+		})
+	}
+
+	var nav []gotgbot.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, gotgbot.InlineKeyboardButton{Text: "◀️", CallbackData: fmt.Sprintf("blocked_page:%d", page-1)})
+	}
+	if hasNext {
+		nav = append(nav, gotgbot.InlineKeyboardButton{Text: "▶️", CallbackData: fmt.Sprintf("blocked_page:%d", page+1)})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+
+	text := fmt.Sprintf("Заблокированные пользователи (стр. %d).\nНажмите на пользователя, чтобы разблокировать:", page+1)
+	return text, gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}, nil
+}
+
+// invalidateUserCaches сбрасывает ленту поиска и кэш лайков для указанных пользователей.
+func (h *CallbackHandler) invalidateUserCaches(userIDs ...int64) {
+	if err := h.feed.Invalidate(context.Background(), userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate search feed",
+			zap.Int64s("user_ids", userIDs),
+			zap.Error(err))
+	}
+	h.invalidateLikes(userIDs...)
+}
+
+// invalidateLikes сбрасывает кэш страниц «Кто меня лайкнул».
+func (h *CallbackHandler) invalidateLikes(userIDs ...int64) {
+	if err := h.likes.Invalidate(context.Background(), userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate likes cache",
+			zap.Int64s("user_ids", userIDs),
+			zap.Error(err))
+	}
+}
+
+func (h *MessageHandler) handleViewBlocked(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	text, keyboard, err := h.callback.blockedPage(userID, 0)
+	if err != nil {
+		_, err = b.SendMessage(chatID, "Произошла ошибка при загрузке списка. Попробуйте позже.", nil)
+		return err
+	}
+	_, err = b.SendMessage(chatID, text, &gotgbot.SendMessageOpts{
+		ReplyMarkup: keyboard,
+	})
+	return err
+}
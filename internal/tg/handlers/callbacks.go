@@ -2,39 +2,66 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"html"
 	"strings"
-	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/agent-yandex/dating-bot/internal/config"
 	"github.com/agent-yandex/dating-bot/internal/db"
 	"github.com/agent-yandex/dating-bot/internal/deps"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	storage "github.com/agent-yandex/dating-bot/internal/minio"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/agent-yandex/dating-bot/internal/premium"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/feed"
+	"github.com/agent-yandex/dating-bot/internal/tg/likescache"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"github.com/go-redis/redis/v8"
-	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 )
 
-const (
-	searchResultsTTL = 30 * time.Minute
-)
-
 type CallbackHandler struct {
 	stateMgr *states.Manager
 	db       *deps.DB
 	redis    *redis.Client
+	storage  storage.FileStorage
+	drafts   *drafts.Drafts
+	matching *matching.Service
+	premium  *premium.Service
+	feed     *feed.Feed
+	likes    *likescache.Cache
+	notifier *notify.Service
+	sender   notify.Sender
+	events   events.Publisher
+	limiter  ratelimit.Limiter
+	search   config.SearchConfig
+	limits   config.RateLimitConfig
 	logger   *zap.Logger
 }
 
-func NewCallbackHandler(stateMgr *states.Manager, db *deps.DB, redis *redis.Client, logger *zap.Logger) *CallbackHandler {
+func NewCallbackHandler(stateMgr *states.Manager, db *deps.DB, redis *redis.Client, storage storage.FileStorage, drafts *drafts.Drafts, matching *matching.Service, premium *premium.Service, feed *feed.Feed, likes *likescache.Cache, notifier *notify.Service, sender notify.Sender, publisher events.Publisher, limiter ratelimit.Limiter, search config.SearchConfig, limits config.RateLimitConfig, logger *zap.Logger) *CallbackHandler {
 	return &CallbackHandler{
 		stateMgr: stateMgr,
 		db:       db,
 		redis:    redis,
+		storage:  storage,
+		drafts:   drafts,
+		matching: matching,
+		premium:  premium,
+		feed:     feed,
+		likes:    likes,
+		notifier: notifier,
+		sender:   sender,
+		events:   publisher,
+		limiter:  limiter,
+		search:   search,
+		limits:   limits,
 		logger:   logger,
 	}
 }
@@ -50,16 +77,29 @@ func (h *CallbackHandler) callbackHandler(b *gotgbot.Bot, ctx *ext.Context) erro
 
 	userID := ctx.CallbackQuery.From.Id
 
+	var answer *gotgbot.AnswerCallbackQueryOpts
 	defer func() {
-		_, _ = b.AnswerCallbackQuery(ctx.CallbackQuery.Id, nil)
+		_, _ = b.AnswerCallbackQuery(ctx.CallbackQuery.Id, answer)
 	}()
 
+	if res, ok := h.allow(userID, "callback", h.limits.Callbacks, h.limits.Window); !ok {
+		answer = &gotgbot.AnswerCallbackQueryOpts{
+			Text: fmt.Sprintf("Слишком много нажатий. Попробуйте через %s.", FormatWait(res.RetryAfter)),
+		}
+		return nil
+	}
+
 	switch {
 	case strings.HasPrefix(ctx.CallbackQuery.Data, "like:"):
 		var profileID int64
 		fmt.Sscanf(ctx.CallbackQuery.Data, "like:%d", &profileID)
 		return h.handleLike(b, ctx, userID, profileID)
 
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "like_msg:"):
+		var profileID int64
+		fmt.Sscanf(ctx.CallbackQuery.Data, "like_msg:%d", &profileID)
+		return h.handleLikeWithMessage(b, ctx, userID, profileID)
+
 	case strings.HasPrefix(ctx.CallbackQuery.Data, "dislike:"):
 		var profileID int64
 		fmt.Sscanf(ctx.CallbackQuery.Data, "dislike:%d", &profileID)
@@ -75,121 +115,93 @@ func (h *CallbackHandler) callbackHandler(b *gotgbot.Bot, ctx *ext.Context) erro
 		fmt.Sscanf(ctx.CallbackQuery.Data, "dislike_like:%d", &profileID)
 		return h.handleDislikeFromLikes(b, ctx, userID, profileID)
 
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "block:"):
+		var profileID int64
+		fmt.Sscanf(ctx.CallbackQuery.Data, "block:%d", &profileID)
+		return h.handleBlock(b, ctx, userID, profileID, false)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "report:"):
+		var profileID int64
+		fmt.Sscanf(ctx.CallbackQuery.Data, "report:%d", &profileID)
+		return h.handleBlock(b, ctx, userID, profileID, true)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "unblock:"):
+		var profileID int64
+		var page int
+		fmt.Sscanf(ctx.CallbackQuery.Data, "unblock:%d:%d", &profileID, &page)
+		return h.handleUnblock(b, ctx, userID, profileID, page)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "blocked_page:"):
+		var page int
+		fmt.Sscanf(ctx.CallbackQuery.Data, "blocked_page:%d", &page)
+		return h.handleBlockedPage(b, ctx, userID, page)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "unmatch:"):
+		var profileID int64
+		var page int
+		fmt.Sscanf(ctx.CallbackQuery.Data, "unmatch:%d:%d", &profileID, &page)
+		return h.handleUnmatch(b, ctx, userID, profileID, page)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "matches_page:"):
+		var page int
+		fmt.Sscanf(ctx.CallbackQuery.Data, "matches_page:%d", &page)
+		return h.handleMatchesPage(b, ctx, userID, page)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "city:"):
+		var cityID int64
+		fmt.Sscanf(ctx.CallbackQuery.Data, "city:%d", &cityID)
+		return h.handleCityChoice(b, ctx, userID, cityID)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "notify_likes:"):
+		var enabled bool
+		fmt.Sscanf(ctx.CallbackQuery.Data, "notify_likes:%t", &enabled)
+		return h.handleNotifyLikesToggle(b, ctx, userID, enabled)
+
+	case strings.HasPrefix(ctx.CallbackQuery.Data, "mutual:"):
+		var enabled bool
+		fmt.Sscanf(ctx.CallbackQuery.Data, "mutual:%t", &enabled)
+		return h.handleMutualToggle(b, ctx, userID, enabled)
+
+	case ctx.CallbackQuery.Data == "reset_passes":
+		return h.handleResetPasses(b, ctx, userID)
+
+	case ctx.CallbackQuery.Data == "undo":
+		return h.handleUndo(b, ctx, userID)
+
+	case ctx.CallbackQuery.Data == notify.ViewLikesCallback:
+		return h.showLikes(b, ctx.CallbackQuery.Message.GetChat().Id, userID)
+
 	default:
 		return nil
 	}
 }
 
+const alreadyLikedText = "Вы уже лайкнули этого пользователя."
+
 func (h *CallbackHandler) handleLike(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
 	chatID := ctx.CallbackQuery.Message.GetChat().Id
 	messageID := ctx.CallbackQuery.Message.GetMessageId()
 
-	like := &db.Like{
-		FromUserID: userID,
-		ToUserID:   profileID,
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+	quota, ok := h.reserveLikeQuota(b, chatID, userID)
+	if !ok {
+		return nil
 	}
 
-	_, err := h.db.Likes.Insert(context.Background(), like)
+	result, err := h.processLike(b, chatID, userID, profileID, nil, quota)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			h.logger.Info("User already liked profile",
-				zap.Int64("from_user_id", userID),
-				zap.Int64("to_user_id", profileID))
-			_, _ = b.SendMessage(chatID, "Вы уже лайкнули этого пользователя.", nil)
-		} else {
-			h.logger.Error("Failed to insert like",
-				zap.Int64("from_user_id", userID),
-				zap.Int64("to_user_id", profileID),
-				zap.Error(err))
-			_, _ = b.SendMessage(chatID, "Произошла ошибка при сохранении лайка.", nil)
-			return err
-		}
-	} else {
-		// Обновляем рейтинг пользователя, получившего лайк
-		if err := h.db.Users.UpdateRating(context.Background(), profileID); err != nil {
-			h.logger.Error("Failed to update rating",
-				zap.Int64("user_id", profileID),
-				zap.Error(err))
-			// Продолжаем, так как ошибка не критична для пользовательского опыта
-		}
+		return err
 	}
-
-	mutualLikes, err := h.db.Likes.GetAllByToUserID(context.Background(), userID)
-	if err != nil {
-		h.logger.Error("Failed to check mutual likes",
-			zap.Int64("user_id", userID),
-			zap.Error(err))
-	} else {
-		for _, l := range mutualLikes {
-			if l.FromUserID == profileID {
-				// Удаляем лайк от profileID к userID
-				err = h.db.Likes.DeleteByIDs(context.Background(), profileID, userID)
-				if err != nil {
-					h.logger.Error("Failed to delete like",
-						zap.Int64("from_user_id", profileID),
-						zap.Int64("to_user_id", userID),
-						zap.Error(err))
-					// Продолжаем, так как лайк уже записан
-				}
-
-				// Удаляем лайк от userID к profileID
-				err = h.db.Likes.DeleteByIDs(context.Background(), userID, profileID)
-				if err != nil {
-					h.logger.Error("Failed to delete mutual like",
-						zap.Int64("from_user_id", userID),
-						zap.Int64("to_user_id", profileID),
-						zap.Error(err))
-					// Продолжаем, так как первый лайк уже удалён
-				}
-
-				// Обновляем рейтинг обоих пользователей
-				for _, uid := range []int64{userID, profileID} {
-					if err := h.db.Users.UpdateRating(context.Background(), uid); err != nil {
-						h.logger.Error("Failed to update rating",
-							zap.Int64("user_id", uid),
-							zap.Error(err))
-						// Продолжаем, так как ошибка не критична
-					}
-				}
-
-				// Очищаем кэш Redis для списка лайков обоих пользователей
-				ctx := context.Background()
-				for _, uid := range []int64{userID, profileID} {
-					keys, err := h.redis.Keys(ctx, fmt.Sprintf("likes:%d:*", uid)).Result()
-					if err != nil {
-						h.logger.Error("Failed to get Redis keys for likes cache",
-							zap.Int64("user_id", uid),
-							zap.Error(err))
-					} else if len(keys) > 0 {
-						if err := h.redis.Del(ctx, keys...).Err(); err != nil {
-							h.logger.Error("Failed to delete Redis keys for likes cache",
-								zap.Int64("user_id", uid),
-								zap.Error(err))
-						} else {
-							h.logger.Info("Cleared likes cache",
-								zap.Int64("user_id", uid),
-								zap.Strings("keys", keys))
-						}
-					}
-				}
-
-				// Отправляем уведомления о взаимном лайке
-				err = h.notifyMutualLikeWithLinks(b, userID, profileID)
-				if err != nil {
-					h.logger.Error("Failed to notify mutual like",
-						zap.Int64("user1_id", userID),
-						zap.Int64("user2_id", profileID),
-						zap.Error(err))
-				}
-				break
-			}
-		}
+	if result == matching.AlreadyLiked {
+		_, _ = b.SendMessage(chatID, alreadyLikedText, nil)
 	}
 
-	_, err = b.DeleteMessage(chatID, messageID, nil)
+	return h.showNextSearchProfile(b, chatID, messageID, userID, profileID, likeAction(result))
+}
+
+// showNextSearchProfile удаляет просмотренную карточку, отмечает анкету
+// показанной, запоминает свайп для отмены и выводит следующую.
+func (h *CallbackHandler) showNextSearchProfile(b *gotgbot.Bot, chatID, messageID, userID, profileID int64, action feed.Action) error {
+	_, err := b.DeleteMessage(chatID, messageID, nil)
 	if err != nil {
 		h.logger.Warn("Failed to delete message",
 			zap.Int64("chat_id", chatID),
@@ -197,150 +209,97 @@ func (h *CallbackHandler) handleLike(b *gotgbot.Bot, ctx *ext.Context, userID, p
 			zap.Error(err))
 	}
 
-	currentIndex := h.stateMgr.GetCurrentIndex(userID) + 1
-	offset := uint64(currentIndex/50) * 50
-	profiles, err := h.getSearchResults(userID, offset)
-	if err != nil {
-		h.logger.Error("Failed to get next profiles",
+	if err := h.db.ProfileStats.IncrementViews(context.Background(), profileID); err != nil {
+		h.logger.Error("Failed to update profile stats",
+			zap.Int64("user_id", profileID),
+			zap.Error(err))
+	}
+
+	if err := h.feed.Advance(context.Background(), userID, profileID, action); err != nil {
+		h.logger.Error("Failed to advance search feed",
 			zap.Int64("user_id", userID),
 			zap.Error(err))
 		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке следующей анкеты.", nil)
 		return err
 	}
 
-	h.stateMgr.SetCurrentIndex(userID, currentIndex)
-
-	return h.sendProfile(b, chatID, userID, profiles, currentIndex%50)
+	return h.sendCurrentProfile(b, chatID, userID)
 }
 
-func (h *CallbackHandler) handleDislike(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
-	chatID := ctx.CallbackQuery.Message.GetChat().Id
-	messageID := ctx.CallbackQuery.Message.GetMessageId()
+// processLike сохраняет лайк через сервис матчинга и публикует событие о
+// нём; кэши, статистику и уведомления обновляют подписчики. Повторный или
+// несохранённый лайк квоту не тратит: занятое под него место возвращается.
+// О результате пользователю сообщает вызывающий обработчик.
+func (h *CallbackHandler) processLike(b *gotgbot.Bot, chatID, userID, profileID int64, message *string, quota likeQuota) (matching.Result, error) {
+	result, err := h.matching.Like(context.Background(), &db.Like{
+		FromUserID: userID,
+		ToUserID:   profileID,
+		Message:    message,
+	})
+	if err != nil {
+		h.refundLikeQuota(userID, quota)
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при сохранении лайка.", nil)
+		return 0, err
+	}
+	if result == matching.AlreadyLiked {
+		h.refundLikeQuota(userID, quota)
+	}
 
-	h.logger.Info("User disliked profile",
-		zap.Int64("user_id", userID),
-		zap.Int64("profile_id", profileID))
+	var event events.Event
+	switch result {
+	case matching.Liked:
+		event = events.LikeCreated{FromUserID: userID, ToUserID: profileID, HasMessage: message != nil}
+	case matching.Matched:
+		event = events.MatchCreated{UserID: userID, PartnerID: profileID}
+	}
+	if event != nil {
+		h.publish(event)
+	}
+	return result, nil
+}
 
-	_, err := b.DeleteMessage(chatID, messageID, nil)
-	if err != nil {
-		h.logger.Warn("Failed to delete message",
-			zap.Int64("chat_id", chatID),
-			zap.Int64("message_id", messageID),
+// publish публикует доменное событие. Побочные эффекты не должны срывать
+// действие пользователя, поэтому ошибки подписчиков только логируются.
+func (h *CallbackHandler) publish(e events.Event) {
+	if err := h.events.Publish(context.Background(), e); err != nil {
+		h.logger.Error("Failed to publish event",
+			zap.String("event", e.Name()),
 			zap.Error(err))
 	}
+}
 
-	currentIndex := h.stateMgr.GetCurrentIndex(userID) + 1
-	offset := uint64(currentIndex/50) * 50
-	profiles, err := h.getSearchResults(userID, offset)
-	if err != nil {
-		h.logger.Error("Failed to get next profiles",
+func (h *CallbackHandler) handleDislike(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	action := feed.ActionPass
+	if err := h.db.Passes.Insert(context.Background(), userID, profileID, h.search.PassTTL); err != nil {
+		h.logger.Error("Failed to save pass",
 			zap.Int64("user_id", userID),
+			zap.Int64("profile_id", profileID),
 			zap.Error(err))
-		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке следующей анкеты.", nil)
-		return err
+		// Продолжаем: анкета всё равно не вернётся до сброса ленты
+		action = feed.ActionNone
 	}
 
-	h.stateMgr.SetCurrentIndex(userID, currentIndex)
-
-	return h.sendProfile(b, chatID, userID, profiles, currentIndex%50)
+	return h.showNextSearchProfile(b, chatID, messageID, userID, profileID, action)
 }
 
 func (h *CallbackHandler) handleLikeFromLikes(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
 	chatID := ctx.CallbackQuery.Message.GetChat().Id
 	messageID := ctx.CallbackQuery.Message.GetMessageId()
 
-	// Записываем лайк текущего пользователя
-	like := &db.Like{
-		FromUserID: userID,
-		ToUserID:   profileID,
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+	quota, ok := h.reserveLikeQuota(b, chatID, userID)
+	if !ok {
+		return nil
 	}
 
-	_, err := h.db.Likes.Insert(context.Background(), like)
+	result, err := h.processLike(b, chatID, userID, profileID, nil, quota)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			h.logger.Info("User already liked profile",
-				zap.Int64("from_user_id", userID),
-				zap.Int64("to_user_id", profileID))
-			_, _ = b.SendMessage(chatID, "Вы уже лайкнули этого пользователя.", nil)
-		} else {
-			h.logger.Error("Failed to insert like",
-				zap.Int64("from_user_id", userID),
-				zap.Int64("to_user_id", profileID),
-				zap.Error(err))
-			_, _ = b.SendMessage(chatID, "Произошла ошибка при сохранении лайка.", nil)
-			return err
-		}
-	} else {
-		// Обновляем рейтинг пользователя, получившего лайк
-		if err := h.db.Users.UpdateRating(context.Background(), profileID); err != nil {
-			h.logger.Error("Failed to update rating",
-				zap.Int64("user_id", profileID),
-				zap.Error(err))
-			// Продолжаем, так как ошибка не критична
-		}
-
-		// Удаляем лайк от profileID к userID
-		err = h.db.Likes.DeleteByIDs(context.Background(), profileID, userID)
-		if err != nil {
-			h.logger.Error("Failed to delete like",
-				zap.Int64("from_user_id", profileID),
-				zap.Int64("to_user_id", userID),
-				zap.Error(err))
-			// Продолжаем, так как лайк уже записан
-		}
-
-		// Удаляем лайк от userID к profileID
-		err = h.db.Likes.DeleteByIDs(context.Background(), userID, profileID)
-		if err != nil {
-			h.logger.Error("Failed to delete mutual like",
-				zap.Int64("from_user_id", userID),
-				zap.Int64("to_user_id", profileID),
-				zap.Error(err))
-			// Продолжаем, так как первый лайк уже удалён
-		}
-
-		// Обновляем рейтинг обоих пользователей
-		for _, uid := range []int64{userID, profileID} {
-			if err := h.db.Users.UpdateRating(context.Background(), uid); err != nil {
-				h.logger.Error("Failed to update rating",
-					zap.Int64("user_id", uid),
-					zap.Error(err))
-				// Продолжаем, так как ошибка не критична
-			}
-		}
-
-		// Очищаем кэш Redis для списка лайков обоих пользователей
-		ctx := context.Background()
-		for _, uid := range []int64{userID, profileID} {
-			keys, err := h.redis.Keys(ctx, fmt.Sprintf("likes:%d:*", uid)).Result()
-			if err != nil {
-				h.logger.Error("Failed to get Redis keys for likes cache",
-					zap.Int64("user_id", uid),
-					zap.Error(err))
-			} else if len(keys) > 0 {
-				if err := h.redis.Del(ctx, keys...).Err(); err != nil {
-					h.logger.Error("Failed to delete Redis keys for likes cache",
-						zap.Int64("user_id", uid),
-						zap.Error(err))
-				} else {
-					h.logger.Info("Cleared likes cache",
-						zap.Int64("user_id", uid),
-						zap.Strings("keys", keys))
-				}
-			}
-		}
-
-		// Отправляем уведомления о взаимном лайке
-		err = h.notifyMutualLikeWithLinks(b, userID, profileID)
-		if err != nil {
-			h.logger.Error("Failed to notify mutual like",
-				zap.Int64("user1_id", userID),
-				zap.Int64("user2_id", profileID),
-				zap.Error(err))
-		}
+		return err
+	}
+	if result == matching.AlreadyLiked {
+		_, _ = b.SendMessage(chatID, alreadyLikedText, nil)
 	}
 
 	_, err = b.DeleteMessage(chatID, messageID, nil)
@@ -352,7 +311,7 @@ func (h *CallbackHandler) handleLikeFromLikes(b *gotgbot.Bot, ctx *ext.Context,
 	}
 
 	currentIndex := h.stateMgr.GetLikesCurrentIndex(userID) + 1
-	offset := uint64(currentIndex/10) * 10
+	offset := uint64(currentIndex/h.search.LikesPageSize) * uint64(h.search.LikesPageSize)
 	profiles, err := h.getLikeResults(userID, offset)
 	if err != nil {
 		h.logger.Error("Failed to get next like profiles",
@@ -364,7 +323,7 @@ func (h *CallbackHandler) handleLikeFromLikes(b *gotgbot.Bot, ctx *ext.Context,
 
 	h.stateMgr.SetLikesCurrentIndex(userID, currentIndex)
 
-	return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%10)
+	return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%h.search.LikesPageSize)
 }
 
 func (h *CallbackHandler) handleDislikeFromLikes(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
@@ -381,14 +340,6 @@ func (h *CallbackHandler) handleDislikeFromLikes(b *gotgbot.Bot, ctx *ext.Contex
 		return err
 	}
 
-	// Обновляем рейтинг пользователя, чей лайк удалён
-	if err := h.db.Users.UpdateRating(context.Background(), userID); err != nil {
-		h.logger.Error("Failed to update rating",
-			zap.Int64("user_id", userID),
-			zap.Error(err))
-		// Продолжаем, так как ошибка не критична
-	}
-
 	_, err = b.DeleteMessage(chatID, messageID, nil)
 	if err != nil {
 		h.logger.Warn("Failed to delete message",
@@ -398,7 +349,7 @@ func (h *CallbackHandler) handleDislikeFromLikes(b *gotgbot.Bot, ctx *ext.Contex
 	}
 
 	currentIndex := h.stateMgr.GetLikesCurrentIndex(userID) + 1
-	offset := uint64(currentIndex/10) * 10
+	offset := uint64(currentIndex/h.search.LikesPageSize) * uint64(h.search.LikesPageSize)
 	profiles, err := h.getLikeResults(userID, offset)
 	if err != nil {
 		h.logger.Error("Failed to get next like profiles",
@@ -410,10 +361,12 @@ func (h *CallbackHandler) handleDislikeFromLikes(b *gotgbot.Bot, ctx *ext.Contex
 
 	h.stateMgr.SetLikesCurrentIndex(userID, currentIndex)
 
-	return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%10)
+	return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%h.search.LikesPageSize)
 }
 
-func (h *CallbackHandler) notifyMutualLikeWithLinks(b *gotgbot.Bot, userID1, userID2 int64) error {
+// notifyMutualLikeWithLinks ставит в очередь сообщения о взаимном лайке
+// обоим пользователям.
+func (h *CallbackHandler) notifyMutualLikeWithLinks(userID1, userID2 int64) error {
 	user1, err := h.db.Users.GetByID(context.Background(), userID1)
 	if err != nil {
 		return err
@@ -448,9 +401,10 @@ func (h *CallbackHandler) notifyMutualLikeWithLinks(b *gotgbot.Bot, userID1, use
 		}
 	}
 	user2Profile := FormatProfile(user2, cityName)
-	_, err = b.SendMessage(user1ChatID,
-		fmt.Sprintf("Взаимный лайк! 💕 Вы понравились %s!\n\n%s\nСвязаться: %s", user2Name, user2Profile, user2Link),
-		&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+	err = h.sender.Send(context.Background(), user1ChatID, notify.Message{
+		Text:      fmt.Sprintf("Взаимный лайк! 💕 Вы понравились %s!\n\n%s\nСвязаться: %s", user2Name, user2Profile, user2Link),
+		ParseMode: "HTML",
+	})
 	if err != nil {
 		h.logger.Error("Failed to notify user1",
 			zap.Int64("user_id", userID1),
@@ -470,9 +424,10 @@ func (h *CallbackHandler) notifyMutualLikeWithLinks(b *gotgbot.Bot, userID1, use
 		}
 	}
 	user1Profile := FormatProfile(user1, cityName)
-	_, err = b.SendMessage(user2ChatID,
-		fmt.Sprintf("Взаимный лайк! 💕 Вы понравились %s!\n\n%s\nСвязаться: %s", user1Name, user1Profile, user1Link),
-		&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+	err = h.sender.Send(context.Background(), user2ChatID, notify.Message{
+		Text:      fmt.Sprintf("Взаимный лайк! 💕 Вы понравились %s!\n\n%s\nСвязаться: %s", user1Name, user1Profile, user1Link),
+		ParseMode: "HTML",
+	})
 	if err != nil {
 		h.logger.Error("Failed to notify user2",
 			zap.Int64("user_id", userID2),
@@ -482,46 +437,20 @@ func (h *CallbackHandler) notifyMutualLikeWithLinks(b *gotgbot.Bot, userID1, use
 	return nil
 }
 
-func (h *CallbackHandler) getSearchResults(userID int64, offset uint64) ([]*db.User, error) {
+func (h *CallbackHandler) getLikeResults(userID int64, offset uint64) ([]*db.LikeProfile, error) {
 	ctx := context.Background()
 
-	cacheKey := fmt.Sprintf("search:%d:%d", userID, offset)
-	cached, err := h.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var profiles []*db.User
-		if err := json.Unmarshal([]byte(cached), &profiles); err == nil {
-			return profiles, nil
-		}
-	}
-
-	profiles, err := h.db.Users.SelectUsers(ctx, userID, offset)
+	profiles, ok, err := h.likes.Page(ctx, userID, offset)
 	if err != nil {
-		h.logger.Error("Failed to fetch profiles",
+		h.logger.Warn("Failed to read likes cache",
 			zap.Int64("user_id", userID),
 			zap.Error(err))
-		return nil, err
 	}
-
-	if len(profiles) > 0 {
-		profilesJson, _ := json.Marshal(profiles)
-		h.redis.Set(ctx, cacheKey, profilesJson, searchResultsTTL)
+	if ok {
+		return profiles, nil
 	}
-	return profiles, nil
-}
 
-func (h *CallbackHandler) getLikeResults(userID int64, offset uint64) ([]*db.User, error) {
-	ctx := context.Background()
-
-	cacheKey := fmt.Sprintf("likes:%d:%d", userID, offset)
-	cached, err := h.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var profiles []*db.User
-		if err := json.Unmarshal([]byte(cached), &profiles); err == nil {
-			return profiles, nil
-		}
-	}
-
-	profiles, err := h.db.Likes.GetAllByToUserIDWithUsers(ctx, userID, offset, 10)
+	profiles, err = h.db.Likes.GetAllByToUserIDWithUsers(ctx, userID, offset, uint64(h.search.LikesPageSize))
 	if err != nil {
 		h.logger.Error("Failed to fetch like profiles",
 			zap.Int64("user_id", userID),
@@ -530,36 +459,43 @@ func (h *CallbackHandler) getLikeResults(userID int64, offset uint64) ([]*db.Use
 	}
 
 	if len(profiles) > 0 {
-		profilesJson, _ := json.Marshal(profiles)
-		h.redis.Set(ctx, cacheKey, profilesJson, searchResultsTTL)
+		if err := h.likes.Store(ctx, userID, offset, profiles); err != nil {
+			h.logger.Warn("Failed to store likes cache",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+		}
 	}
 	return profiles, nil
 }
 
-func (h *CallbackHandler) sendProfile(b *gotgbot.Bot, chatID, userID int64, profiles []*db.User, currentIndex int) error {
-	if currentIndex >= len(profiles) {
-		nextOffset := uint64((h.stateMgr.GetCurrentIndex(userID)/50)+1) * 50
-		nextProfiles, err := h.getSearchResults(userID, nextOffset)
-		if err != nil {
-			h.logger.Error("Failed to get next profiles",
+// sendCurrentProfile показывает текущую анкету ленты поиска. Когда лента
+// исчерпана, она сбрасывается, и следующий поиск начнётся сначала.
+func (h *CallbackHandler) sendCurrentProfile(b *gotgbot.Bot, chatID, userID int64) error {
+	profile, err := h.feed.Current(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get search feed",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке анкет.", nil)
+		return err
+	}
+
+	if profile == nil {
+		if err := h.feed.Reset(context.Background(), userID); err != nil {
+			h.logger.Error("Failed to reset search feed",
 				zap.Int64("user_id", userID),
 				zap.Error(err))
-			_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке анкет.", nil)
-			return err
-		}
-
-		if len(nextProfiles) == 0 {
-			h.stateMgr.ResetCurrentIndex(userID)
-			_, err := b.SendMessage(chatID, "Больше анкет не найдено.", nil)
-			return err
 		}
-
-		currentIndex = 0
-		h.stateMgr.SetCurrentIndex(userID, int(nextOffset))
-		profiles = nextProfiles
+		_, err := b.SendMessage(chatID, "Больше анкет не найдено.", &gotgbot.SendMessageOpts{
+			ReplyMarkup: h.undoKeyboard(userID, nil),
+		})
+		return err
 	}
 
-	profile := profiles[currentIndex]
+	return h.sendProfile(b, chatID, userID, profile)
+}
+
+func (h *CallbackHandler) sendProfile(b *gotgbot.Bot, chatID, userID int64, profile *db.SearchResult) error {
 	var cityName string
 	if profile.CityID != nil {
 		city, err := h.db.Cities.GetByID(context.Background(), *profile.CityID)
@@ -571,19 +507,23 @@ func (h *CallbackHandler) sendProfile(b *gotgbot.Bot, chatID, userID int64, prof
 			cityName = city.Name
 		}
 	}
-	profileText := FormatProfile(profile, cityName)
+	profileText := FormatProfile(&profile.User, cityName) + "\nРасстояние: " + FormatDistance(profile.DistanceKm)
 
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{
 			{Text: "❤️ Лайк", CallbackData: fmt.Sprintf("like:%d", profile.ID)},
 			{Text: "👎 Дизлайк", CallbackData: fmt.Sprintf("dislike:%d", profile.ID)},
 		},
+		{
+			{Text: "💌 Лайк с сообщением", CallbackData: fmt.Sprintf("like_msg:%d", profile.ID)},
+		},
+		{
+			{Text: "🚫 Заблокировать", CallbackData: fmt.Sprintf("block:%d", profile.ID)},
+			{Text: "⚠️ Пожаловаться", CallbackData: fmt.Sprintf("report:%d", profile.ID)},
+		},
 	}
 
-	_, err := b.SendMessage(chatID, profileText, &gotgbot.SendMessageOpts{
-		ParseMode:   "HTML",
-		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
-	})
+	err := h.sendCard(b, chatID, &profile.User, profileText, h.undoKeyboard(userID, keyboard))
 	if err != nil {
 		h.logger.Error("Failed to send profile",
 			zap.Int64("chat_id", chatID),
@@ -593,9 +533,9 @@ func (h *CallbackHandler) sendProfile(b *gotgbot.Bot, chatID, userID int64, prof
 	return err
 }
 
-func (h *CallbackHandler) sendLikeProfile(b *gotgbot.Bot, chatID, userID int64, profiles []*db.User, currentIndex int) error {
+func (h *CallbackHandler) sendLikeProfile(b *gotgbot.Bot, chatID, userID int64, profiles []*db.LikeProfile, currentIndex int) error {
 	if currentIndex >= len(profiles) {
-		nextOffset := uint64((h.stateMgr.GetLikesCurrentIndex(userID)/10)+1) * 10
+		nextOffset := uint64((h.stateMgr.GetLikesCurrentIndex(userID)/h.search.LikesPageSize)+1) * uint64(h.search.LikesPageSize)
 		nextProfiles, err := h.getLikeResults(userID, nextOffset)
 		if err != nil {
 			h.logger.Error("Failed to get next like profiles",
@@ -607,24 +547,8 @@ func (h *CallbackHandler) sendLikeProfile(b *gotgbot.Bot, chatID, userID int64,
 
 		if len(nextProfiles) == 0 {
 			h.stateMgr.ResetLikesCurrentIndex(userID)
-			ctx := context.Background()
-			keys, err := h.redis.Keys(ctx, fmt.Sprintf("likes:%d:*", userID)).Result()
-			if err != nil {
-				h.logger.Error("Failed to get Redis keys for likes cache",
-					zap.Int64("user_id", userID),
-					zap.Error(err))
-			} else if len(keys) > 0 {
-				if err := h.redis.Del(ctx, keys...).Err(); err != nil {
-					h.logger.Error("Failed to delete Redis keys for likes cache",
-						zap.Int64("user_id", userID),
-						zap.Error(err))
-				} else {
-					h.logger.Info("Cleared likes cache",
-						zap.Int64("user_id", userID),
-						zap.Strings("keys", keys))
-				}
-			}
-			_, err = b.SendMessage(chatID, "Больше лайков не найдено.", nil)
+			h.invalidateLikes(userID)
+			_, err := b.SendMessage(chatID, "Больше лайков не найдено.", nil)
 			return err
 		}
 
@@ -645,19 +569,27 @@ func (h *CallbackHandler) sendLikeProfile(b *gotgbot.Bot, chatID, userID int64,
 			cityName = city.Name
 		}
 	}
-	profileText := FormatProfile(profile, cityName)
+	profileText := FormatProfile(&profile.User, cityName)
+	if profile.DistanceKm != nil {
+		profileText += "\nРасстояние: " + FormatDistance(*profile.DistanceKm)
+	}
+	if profile.LikeMessage != nil && *profile.LikeMessage != "" {
+		profileText += "\n\n💌 " + html.EscapeString(*profile.LikeMessage)
+	}
 
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{
 			{Text: "❤️ Лайк", CallbackData: fmt.Sprintf("like_like:%d", profile.ID)},
 			{Text: "👎 Дизлайк", CallbackData: fmt.Sprintf("dislike_like:%d", profile.ID)},
 		},
+		{
+			{Text: "🚫 Заблокировать", CallbackData: fmt.Sprintf("block:%d", profile.ID)},
+			{Text: "⚠️ Пожаловаться", CallbackData: fmt.Sprintf("report:%d", profile.ID)},
+		},
 	}
 
-	_, err := b.SendMessage(chatID, profileText, &gotgbot.SendMessageOpts{
-		ParseMode:   "HTML",
-		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
-	})
+	// Кнопка отмены относится к свайпам поиска и в списке лайков не нужна
+	err := h.sendCard(b, chatID, &profile.User, profileText, gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard})
 	if err != nil {
 		h.logger.Error("Failed to send like profile",
 			zap.Int64("chat_id", chatID),
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"go.uber.org/zap"
+)
+
+const citySuggestionsLimit = 5
+
+// cityKeyboard строит кнопки выбора города из найденных вариантов.
+func cityKeyboard(cities []*db.City) gotgbot.InlineKeyboardMarkup {
+	keyboard := make([][]gotgbot.InlineKeyboardButton, 0, len(cities))
+	for _, city := range cities {
+		text := cityTitle(city.Name)
+		if city.Population > 0 {
+			text = fmt.Sprintf("%s (%d чел.)", text, city.Population)
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: text, CallbackData: fmt.Sprintf("city:%d", city.ID)},
+		})
+	}
+	return gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// cityTitle делает заглавной первую букву названия: в таблице cities
+// названия хранятся в нижнем регистре.
+func cityTitle(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError {
+		return name
+	}
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func (h *CallbackHandler) handleCityChoice(b *gotgbot.Bot, ctx *ext.Context, userID, cityID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	// Кнопки из старых сообщений игнорируем, если мастер уже на другом шаге.
+	if h.stateMgr.Get(userID) != states.StateEditCity {
+		return nil
+	}
+
+	city, err := h.db.Cities.GetByID(context.Background(), cityID)
+	if err != nil {
+		h.logger.Error("Failed to get chosen city",
+			zap.Int64("user_id", userID),
+			zap.Int64("city_id", cityID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при выборе города. Попробуйте снова:", nil)
+		return err
+	}
+
+	err = h.drafts.SetProfileField(context.Background(), userID, drafts.FieldCityID, strconv.FormatInt(city.ID, 10))
+	if err == nil {
+		// Точка от прежней геопозиции относится к другому городу
+		err = h.drafts.ClearProfileLocation(context.Background(), userID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to access draft", zap.Int64("user_id", userID), zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка. Попробуйте снова.", nil)
+		return err
+	}
+
+	_, _, err = b.EditMessageText("Город: "+cityTitle(city.Name)+" ✅", &gotgbot.EditMessageTextOpts{
+		ChatId:    chatID,
+		MessageId: messageID,
+	})
+	if err != nil {
+		h.logger.Warn("Failed to edit city message",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+
+	return h.promptProfileStep(b, chatID, userID, states.StateEditBio)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+)
+
+func callbackUpdate(userID int64, data string) *ext.Context {
+	return ext.NewContext(&gotgbot.Update{
+		CallbackQuery: &gotgbot.CallbackQuery{
+			Id:   "cb",
+			From: gotgbot.User{Id: userID},
+			Data: data,
+			Message: &gotgbot.Message{
+				MessageId: 7,
+				Chat:      gotgbot.Chat{Id: userID, Type: "private"},
+			},
+		},
+	}, nil)
+}
+
+func TestHandleCityChoiceDropsDraftLocation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	const userID = 42
+
+	env.cities.cities[2] = &db.City{ID: 2, Name: "казань"}
+	// Геопозиция была отправлена раньше и определила другой город
+	for field, value := range map[string]string{
+		drafts.FieldCityID: "1",
+		drafts.FieldLat:    "55.75",
+		drafts.FieldLon:    "37.62",
+	} {
+		if err := env.drafts.SetProfileField(ctx, userID, field, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.states.Set(userID, states.StateEditCity)
+
+	if err := env.callback.handleCityChoice(env.tg.bot, callbackUpdate(userID, "city:2"), userID, 2); err != nil {
+		t.Fatalf("handleCityChoice: %v", err)
+	}
+
+	draft, err := env.drafts.Profile(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft.CityID == nil || *draft.CityID != 2 {
+		t.Errorf("draft city = %v, want 2", draft.CityID)
+	}
+	if draft.Latitude != nil || draft.Longitude != nil {
+		t.Error("draft kept the location of the previous city")
+	}
+	if got := env.states.Get(userID); got != states.StateEditBio {
+		t.Errorf("state = %v, want %v", got, states.StateEditBio)
+	}
+}
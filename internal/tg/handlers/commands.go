@@ -2,31 +2,47 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
 	"github.com/agent-yandex/dating-bot/internal/deps"
+	"github.com/agent-yandex/dating-bot/internal/scheduler"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"go.uber.org/zap"
 )
 
 type CommandHandler struct {
-	db       *deps.DB
-	logger   *zap.Logger
-	stateMgr *states.Manager
+	db        *deps.DB
+	logger    *zap.Logger
+	stateMgr  *states.Manager
+	scheduler *scheduler.Scheduler
+	admins    map[int64]bool
 }
 
-func NewCommandHandler(stateMgr *states.Manager, db *deps.DB, logger *zap.Logger) *CommandHandler {
+func NewCommandHandler(stateMgr *states.Manager, db *deps.DB, sched *scheduler.Scheduler, adminIDs []int64, logger *zap.Logger) *CommandHandler {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
 	return &CommandHandler{
-		db:       db,
-		logger:   logger,
-		stateMgr: stateMgr,
+		db:        db,
+		logger:    logger,
+		stateMgr:  stateMgr,
+		scheduler: sched,
+		admins:    admins,
 	}
 }
 
 func (h *CommandHandler) RegisterCommands(d *ext.Dispatcher) {
 	d.AddHandler(handlers.NewCommand("start", h.handleStart))
+	d.AddHandler(handlers.NewCommand("jobs", h.handleJobs))
+	d.AddHandler(handlers.NewCommand("reports", h.handleReports))
+	d.AddHandler(handlers.NewCommand("resolve", h.handleResolve))
 }
 
 func (h *CommandHandler) handleStart(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -55,6 +71,9 @@ func (h *CommandHandler) handleStart(b *gotgbot.Bot, ctx *ext.Context) error {
 			{{Text: "Посмотреть настройки поиска"}},
 			{{Text: "Поиск анкет"}},
 			{{Text: "Кто меня лайкнул"}},
+			{{Text: "Мои пары"}},
+			{{Text: "Заблокированные"}},
+			{{Text: premiumText}},
 		}
 	}
 
@@ -66,3 +85,102 @@ func (h *CommandHandler) handleStart(b *gotgbot.Bot, ctx *ext.Context) error {
 	})
 	return err
 }
+
+// handleJobs показывает администратору состояние фоновых задач на этом
+// экземпляре бота.
+func (h *CommandHandler) handleJobs(b *gotgbot.Bot, ctx *ext.Context) error {
+	if !h.admins[ctx.EffectiveUser.Id] {
+		return nil
+	}
+
+	leader, jobs := h.scheduler.Status()
+	var sb strings.Builder
+	if leader {
+		sb.WriteString("Экземпляр является лидером: задачи выполняются здесь.\n")
+	} else {
+		sb.WriteString("Экземпляр не лидер: задачи выполняет другой экземпляр.\n")
+	}
+	for _, job := range jobs {
+		fmt.Fprintf(&sb, "\n<b>%s</b> (каждые %s)\n", job.Name, job.Interval)
+		switch {
+		case job.Running:
+			fmt.Fprintf(&sb, "Выполняется с %s\n", job.LastStart.Format(time.DateTime))
+		case job.Runs == 0:
+			sb.WriteString("Ещё не запускалась\n")
+		default:
+			fmt.Fprintf(&sb, "Последний запуск: %s, длился %s\n",
+				job.LastStart.Format(time.DateTime), job.LastDuration.Round(time.Millisecond))
+		}
+		fmt.Fprintf(&sb, "Запусков: %d, ошибок: %d\n", job.Runs, job.Failures)
+		if job.LastError != "" {
+			fmt.Fprintf(&sb, "Ошибка: %s\n", html.EscapeString(job.LastError))
+		}
+		if leader && !job.NextRun.IsZero() {
+			fmt.Fprintf(&sb, "Следующий запуск: %s\n", job.NextRun.Format(time.DateTime))
+		}
+	}
+
+	_, err := b.SendMessage(ctx.EffectiveChat.Id, sb.String(), &gotgbot.SendMessageOpts{ParseMode: "HTML"})
+	return err
+}
+
+// reportsListLimit — сколько пользователей с жалобами показывает /reports.
+const reportsListLimit = 20
+
+// handleReports показывает администратору пользователей с открытыми
+// жалобами. Профиль можно открыть по ссылке, а жалобы закрыть командой
+// /resolve <id>.
+func (h *CommandHandler) handleReports(b *gotgbot.Bot, ctx *ext.Context) error {
+	if !h.admins[ctx.EffectiveUser.Id] {
+		return nil
+	}
+	chatID := ctx.EffectiveChat.Id
+
+	reports, err := h.db.Reports.ListOpen(context.Background(), reportsListLimit)
+	if err != nil {
+		_, err := b.SendMessage(chatID, "Не удалось загрузить жалобы.", nil)
+		return err
+	}
+	if len(reports) == 0 {
+		_, err := b.SendMessage(chatID, "Открытых жалоб нет.", nil)
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Открытые жалобы</b>\n")
+	for _, r := range reports {
+		fmt.Fprintf(&sb, "\n<a href=\"tg://user?id=%d\">%d</a>: %d, последняя %s",
+			r.ReportedID, r.ReportedID, r.Count, r.LastReportAt.Format(time.DateTime))
+	}
+	sb.WriteString("\n\nЗакрыть жалобы: /resolve &lt;id&gt;")
+
+	_, err = b.SendMessage(chatID, sb.String(), &gotgbot.SendMessageOpts{ParseMode: "HTML"})
+	return err
+}
+
+// handleResolve закрывает открытые жалобы на пользователя.
+func (h *CommandHandler) handleResolve(b *gotgbot.Bot, ctx *ext.Context) error {
+	if !h.admins[ctx.EffectiveUser.Id] {
+		return nil
+	}
+	chatID := ctx.EffectiveChat.Id
+
+	args := ctx.Args()
+	var reportedID int64
+	if len(args) != 2 {
+		_, err := b.SendMessage(chatID, "Использование: /resolve <id>", nil)
+		return err
+	}
+	if _, err := fmt.Sscanf(args[1], "%d", &reportedID); err != nil {
+		_, err := b.SendMessage(chatID, "Использование: /resolve <id>", nil)
+		return err
+	}
+
+	count, err := h.db.Reports.Resolve(context.Background(), reportedID)
+	if err != nil {
+		_, err := b.SendMessage(chatID, "Не удалось закрыть жалобы.", nil)
+		return err
+	}
+	_, err = b.SendMessage(chatID, fmt.Sprintf("Закрыто жалоб: %d.", count), nil)
+	return err
+}
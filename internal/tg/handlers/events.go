@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/agent-yandex/dating-bot/internal/events"
+)
+
+// SubscribeNotifications уведомляет пользователей о лайках и парах.
+func (h *CallbackHandler) SubscribeNotifications(bus events.Bus) {
+	events.On(bus, func(ctx context.Context, e events.LikeCreated) error {
+		return h.notifier.LikeReceived(ctx, e.ToUserID)
+	})
+	events.On(bus, func(ctx context.Context, e events.LikeWithdrawn) error {
+		return h.notifier.LikeWithdrawn(ctx, e.ToUserID)
+	})
+	events.On(bus, func(_ context.Context, e events.MatchCreated) error {
+		return h.notifyMutualLikeWithLinks(e.UserID, e.PartnerID)
+	})
+}
@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/config"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/deps"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	storage "github.com/agent-yandex/dating-bot/internal/minio"
+	"github.com/agent-yandex/dating-bot/internal/premium"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"github.com/agent-yandex/dating-bot/internal/scoring"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/feed"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// fakeUsers хранит пользователей в памяти. Методы, которые тестам не
+// нужны, достаются от встроенного nil-интерфейса и паникуют при вызове.
+type fakeUsers struct {
+	db.UserQuery
+
+	mu     sync.Mutex
+	users  map[int64]*db.User
+	points map[int64]*db.Point
+	// views — просмотры, которые выдача пропускает
+	views *fakeViews
+}
+
+func newFakeUsers(views *fakeViews) *fakeUsers {
+	return &fakeUsers{
+		users:  make(map[int64]*db.User),
+		points: make(map[int64]*db.Point),
+		views:  views,
+	}
+}
+
+func (f *fakeUsers) GetByID(_ context.Context, id int64) (*db.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUsers) Insert(_ context.Context, user *db.User) (*db.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copied := *user
+	f.users[user.ID] = &copied
+	return user, nil
+}
+
+func (f *fakeUsers) Update(_ context.Context, user *db.User, id int64) (*db.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copied := *user
+	copied.ID = id
+	if old, ok := f.users[id]; ok {
+		copied.ProfilePhoto = old.ProfilePhoto
+	}
+	f.users[id] = &copied
+	return &copied, nil
+}
+
+func (f *fakeUsers) UpdateProfilePhoto(_ context.Context, id int64, photo *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, ok := f.users[id]; ok {
+		user.ProfilePhoto = photo
+	}
+	return nil
+}
+
+func (f *fakeUsers) UpdateLocation(_ context.Context, id int64, point *db.Point) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.points[id] = point
+	return nil
+}
+
+// SelectCandidateIDs отдаёт всех активных пользователей, кроме самого
+// пользователя и просмотренных им анкет, в порядке id.
+func (f *fakeUsers) SelectCandidateIDs(_ context.Context, id int64, _ db.SearchOrder, limit uint64) ([]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []int64
+	for candidate, user := range f.users {
+		if candidate != id && user.IsActive && !f.views.has(id, candidate) {
+			ids = append(ids, candidate)
+		}
+	}
+	slices.Sort(ids)
+	return ids[:min(len(ids), int(limit))], nil
+}
+
+func (f *fakeUsers) SelectUsersByIDs(_ context.Context, viewerID int64, ids []int64) ([]*db.SearchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var results []*db.SearchResult
+	for _, id := range ids {
+		if user, ok := f.users[id]; ok && user.IsActive && !f.views.has(viewerID, id) {
+			results = append(results, &db.SearchResult{User: *user})
+		}
+	}
+	return results, nil
+}
+
+func (f *fakeUsers) user(id int64) *db.User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.users[id]
+}
+
+// fakeViews — просмотренные анкеты в памяти.
+type fakeViews struct {
+	mu   sync.Mutex
+	seen map[likePair]bool
+}
+
+func newFakeViews() *fakeViews {
+	return &fakeViews{seen: make(map[likePair]bool)}
+}
+
+func (f *fakeViews) Insert(_ context.Context, fromUserID, toUserID int64, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen[likePair{fromUserID, toUserID}] = true
+	return nil
+}
+
+func (f *fakeViews) Delete(_ context.Context, fromUserID, toUserID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.seen, likePair{fromUserID, toUserID})
+	return nil
+}
+
+func (f *fakeViews) DeleteAllByUser(_ context.Context, fromUserID int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var count int64
+	for pair := range f.seen {
+		if pair.from == fromUserID {
+			delete(f.seen, pair)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeViews) has(fromUserID, toUserID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seen[likePair{fromUserID, toUserID}]
+}
+
+// fakePreferences — настройки поиска в памяти.
+type fakePreferences struct {
+	db.UserPreferencesQuery
+
+	mu    sync.Mutex
+	prefs map[int64]*db.UserPreference
+}
+
+func newFakePreferences() *fakePreferences {
+	return &fakePreferences{prefs: make(map[int64]*db.UserPreference)}
+}
+
+func (f *fakePreferences) Insert(_ context.Context, id int64) (*db.UserPreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pref := &db.UserPreference{UserID: id}
+	f.prefs[id] = pref
+	return pref, nil
+}
+
+// fakeCities — справочник городов в памяти.
+type fakeCities struct {
+	db.CityQuery
+
+	cities map[int64]*db.City
+}
+
+func (f *fakeCities) GetByID(_ context.Context, id int64) (*db.City, error) {
+	city, ok := f.cities[id]
+	if !ok {
+		return nil, errors.New("city not found")
+	}
+	return city, nil
+}
+
+// fakeProfileStats считает просмотры анкет.
+type fakeProfileStats struct {
+	db.ProfileStatsQuery
+
+	mu    sync.Mutex
+	views map[int64]int
+}
+
+func (f *fakeProfileStats) IncrementViews(_ context.Context, userID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.views[userID]++
+	return nil
+}
+
+type likePair struct{ from, to int64 }
+
+// fakeMatching — лайки и пары в памяти. Транзакции выполняются по одной.
+type fakeMatching struct {
+	mu      sync.Mutex
+	likes   map[likePair]bool
+	matches map[likePair]bool
+	// fail — ошибка, которой завершаются транзакции
+	fail error
+}
+
+func newFakeMatching() *fakeMatching {
+	return &fakeMatching{
+		likes:   make(map[likePair]bool),
+		matches: make(map[likePair]bool),
+	}
+}
+
+func (f *fakeMatching) InTx(ctx context.Context, fn func(ctx context.Context, tx matching.Tx) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return f.fail
+	}
+	return fn(ctx, f)
+}
+
+func (f *fakeMatching) LockUsers(context.Context, ...int64) error { return nil }
+
+func (f *fakeMatching) HasLike(_ context.Context, from, to int64) (bool, error) {
+	return f.likes[likePair{from, to}], nil
+}
+
+func (f *fakeMatching) InsertLike(_ context.Context, like *db.Like) (bool, error) {
+	key := likePair{like.FromUserID, like.ToUserID}
+	if f.likes[key] {
+		return false, nil
+	}
+	f.likes[key] = true
+	return true, nil
+}
+
+func (f *fakeMatching) DeleteLike(_ context.Context, from, to int64) error {
+	delete(f.likes, likePair{from, to})
+	return nil
+}
+
+func (f *fakeMatching) InsertMatch(_ context.Context, match *db.Match) error {
+	f.matches[likePair{match.User1ID, match.User2ID}] = true
+	return nil
+}
+
+func (f *fakeMatching) hasLike(from, to int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.likes[likePair{from, to}]
+}
+
+func (f *fakeMatching) matchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.matches)
+}
+
+// fakePremium хранит сроки премиума и учтённые платежи.
+type fakePremium struct {
+	mu       sync.Mutex
+	until    map[int64]time.Time
+	payments map[string]*premium.Payment
+}
+
+func newFakePremium() *fakePremium {
+	return &fakePremium{
+		until:    make(map[int64]time.Time),
+		payments: make(map[string]*premium.Payment),
+	}
+}
+
+func (f *fakePremium) InTx(ctx context.Context, fn func(ctx context.Context, tx premium.Tx) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fn(ctx, f)
+}
+
+func (f *fakePremium) Until(_ context.Context, userID int64) (*time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lookup(userID), nil
+}
+
+func (f *fakePremium) ExpireStale(context.Context) (int64, error) { return 0, nil }
+
+func (f *fakePremium) LockUser(_ context.Context, userID int64) (*time.Time, error) {
+	return f.lookup(userID), nil
+}
+
+func (f *fakePremium) SetUntil(_ context.Context, userID int64, until *time.Time) error {
+	if until == nil {
+		delete(f.until, userID)
+		return nil
+	}
+	f.until[userID] = *until
+	return nil
+}
+
+func (f *fakePremium) InsertPayment(_ context.Context, payment *premium.Payment, _ *time.Time) (bool, error) {
+	if _, ok := f.payments[payment.TelegramChargeID]; ok {
+		return false, nil
+	}
+	f.payments[payment.TelegramChargeID] = payment
+	return true, nil
+}
+
+func (f *fakePremium) lookup(userID int64) *time.Time {
+	until, ok := f.until[userID]
+	if !ok {
+		return nil
+	}
+	return &until
+}
+
+// testEnv собирает обработчики поверх поддельного Bot API и хранилищ в
+// памяти. Зависимости, которые нужны не всем тестам, тесты задают сами.
+type testEnv struct {
+	tg       *fakeTelegram
+	users    *fakeUsers
+	views    *fakeViews
+	prefs    *fakePreferences
+	cities   *fakeCities
+	stats    *fakeProfileStats
+	matches  *fakeMatching
+	premium  *fakePremium
+	redis    *miniredis.Miniredis
+	feed     *feed.Feed
+	db       *deps.DB
+	states   *states.Manager
+	drafts   *drafts.Drafts
+	storage  *storage.MemoryStorage
+	limiter  *ratelimit.MemoryLimiter
+	bus      *events.SyncBus
+	callback *CallbackHandler
+	message  *MessageHandler
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	logger := zap.NewNop()
+	env := &testEnv{
+		tg:      newFakeTelegram(t),
+		views:   newFakeViews(),
+		prefs:   newFakePreferences(),
+		cities:  &fakeCities{cities: make(map[int64]*db.City)},
+		stats:   &fakeProfileStats{views: make(map[int64]int)},
+		matches: newFakeMatching(),
+		premium: newFakePremium(),
+		redis:   miniredis.RunT(t),
+		states:  states.NewManager(nil, states.NewMemoryStore(), logger),
+		drafts:  drafts.New(drafts.NewMemoryStore()),
+		storage: storage.NewMemoryStorage(),
+		limiter: ratelimit.NewMemoryLimiter(),
+		bus:     events.NewSyncBus(logger),
+	}
+	env.users = newFakeUsers(env.views)
+	env.db = &deps.DB{
+		Users:           env.users,
+		UserPreferences: env.prefs,
+		Cities:          env.cities,
+		ProfileStats:    env.stats,
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: env.redis.Addr()})
+	t.Cleanup(func() { _ = redisClient.Close() })
+	search := config.SearchConfig{
+		PageSize:      10,
+		SnapshotSize:  100,
+		LikesPageSize: 10,
+		ResultsTTL:    30 * time.Minute,
+		SeenTTL:       24 * time.Hour,
+		PassTTL:       24 * time.Hour,
+		UndoWindow:    5 * time.Minute,
+	}
+	env.feed = feed.New(redisClient, env.users, env.views, scoring.NewExperiment(0), search, logger)
+	env.callback = &CallbackHandler{
+		stateMgr: env.states,
+		db:       env.db,
+		storage:  env.storage,
+		drafts:   env.drafts,
+		matching: matching.NewService(env.matches, logger),
+		premium: premium.NewService(env.premium, premium.Plan{
+			Title:    "Премиум",
+			Payload:  "premium_30d",
+			Stars:    250,
+			Duration: 30 * 24 * time.Hour,
+		}, logger),
+		feed:    env.feed,
+		events:  env.bus,
+		limiter: env.limiter,
+		search:  search,
+		limits: config.RateLimitConfig{
+			Window:     time.Minute,
+			Callbacks:  100,
+			Messages:   100,
+			Likes:      100,
+			DailyLikes: 100,
+		},
+		logger: logger,
+	}
+	env.message = NewMessageHandler(env.states, env.db, nil, env.storage, env.drafts, env.callback, logger)
+	return env
+}
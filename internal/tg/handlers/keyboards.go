@@ -11,7 +11,25 @@ func GetMainReplyKeyboard() gotgbot.ReplyKeyboardMarkup {
 			{{Text: "Изменить настройки поиска"}},
 			{{Text: "Поиск анкет"}},
 			{{Text: "Кто меня лайкнул"}},
+			{{Text: "Мои пары"}},
+			{{Text: "Заблокированные"}},
+			{{Text: premiumText}},
 		},
 		ResizeKeyboard: true,
 	}
 }
+
+const (
+	resumeText  = "Продолжить"
+	restartText = "Начать заново"
+)
+
+func resumeKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{
+			{{Text: resumeText}, {Text: restartText}},
+		},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"go.uber.org/zap"
+)
+
+const (
+	maxLikeMessageLength = 200
+	cancelText           = "Отмена"
+)
+
+func (h *CallbackHandler) handleLikeWithMessage(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	if err := h.drafts.SetLikeMessageTarget(context.Background(), userID, profileID, messageID); err != nil {
+		h.logger.Error("Failed to save like message target",
+			zap.Int64("user_id", userID),
+			zap.Int64("profile_id", profileID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка. Попробуйте позже.", nil)
+		return err
+	}
+
+	h.stateMgr.Set(userID, states.StateEditLikeMessage)
+	_, err := b.SendMessage(chatID, "Напишите сообщение к лайку (до 200 символов):", &gotgbot.SendMessageOpts{
+		ReplyMarkup: gotgbot.ReplyKeyboardMarkup{
+			Keyboard: [][]gotgbot.KeyboardButton{
+				{{Text: cancelText}},
+			},
+			ResizeKeyboard:  true,
+			OneTimeKeyboard: true,
+		},
+	})
+	return err
+}
+
+func (h *MessageHandler) handleLikeMessage(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	profileID, cardMessageID, err := h.drafts.LikeMessageTarget(context.Background(), userID)
+	if err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
+
+	if ctx.Message.Text == cancelText || profileID == 0 {
+		if err := h.drafts.DeleteLikeMessageTarget(context.Background(), userID); err != nil {
+			h.logger.Error("Failed to delete like message target", zap.Int64("user_id", userID), zap.Error(err))
+		}
+		h.stateMgr.Set(userID, states.StateSearching)
+		_, err := b.SendMessage(chatID, "Сообщение отменено.", &gotgbot.SendMessageOpts{
+			ReplyMarkup: GetMainReplyKeyboard(),
+		})
+		return err
+	}
+
+	note := sanitizeLikeMessage(ctx.Message.Text)
+	if note == "" {
+		_, err := b.SendMessage(chatID, "Сообщение не должно быть пустым. Попробуйте снова:", nil)
+		return err
+	}
+	if utf8.RuneCountInString(note) > maxLikeMessageLength {
+		_, err := b.SendMessage(chatID, "Сообщение слишком длинное (макс. 200 символов). Попробуйте снова:", nil)
+		return err
+	}
+
+	quota, ok := h.callback.reserveLikeQuota(b, chatID, userID)
+	if !ok {
+		// Сообщение не отправлено: возвращаемся к поиску, карточка остаётся на экране
+		if err := h.drafts.DeleteLikeMessageTarget(context.Background(), userID); err != nil {
+			h.logger.Error("Failed to delete like message target", zap.Int64("user_id", userID), zap.Error(err))
+		}
+		h.stateMgr.Set(userID, states.StateSearching)
+		return nil
+	}
+
+	result, err := h.callback.processLike(b, chatID, userID, profileID, &note, quota)
+	if err != nil {
+		return err
+	}
+	if err := h.drafts.DeleteLikeMessageTarget(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to delete like message target", zap.Int64("user_id", userID), zap.Error(err))
+	}
+
+	h.stateMgr.Set(userID, states.StateSearching)
+	_, _ = b.SendMessage(chatID, likeMessageResultText(result), &gotgbot.SendMessageOpts{
+		ReplyMarkup: GetMainReplyKeyboard(),
+	})
+	return h.callback.showNextSearchProfile(b, chatID, cardMessageID, userID, profileID, likeAction(result))
+}
+
+// likeMessageResultText сообщает, что стало с лайком и запиской. Записка
+// доставляется только вместе с новым лайком: при взаимной симпатии
+// собеседники получают контакты друг друга, а повторный лайк не сохраняется.
+func likeMessageResultText(result matching.Result) string {
+	switch result {
+	case matching.AlreadyLiked:
+		return "Вы уже лайкнули этого пользователя, сообщение не отправлено."
+	case matching.Matched:
+		return "Это взаимная симпатия 💕 Сообщение не понадобилось: контакты придут следующим сообщением, напишите напрямую."
+	default:
+		return "Лайк с сообщением отправлен 💌"
+	}
+}
+
+// sanitizeLikeMessage убирает управляющие символы и лишние пробелы.
+// HTML-экранирование выполняется при выводе.
+func sanitizeLikeMessage(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
@@ -8,14 +11,26 @@ import (
 )
 
 func (h *MessageHandler) handleViewLikes(b *gotgbot.Bot, ctx *ext.Context) error {
-	userID := ctx.Message.From.Id
-	chatID := ctx.Message.Chat.Id
+	return h.callback.showLikes(b, ctx.Message.Chat.Id, ctx.Message.From.Id)
+}
+
+// showLikes открывает просмотр «Кто меня лайкнул» с текущей позиции.
+func (h *CallbackHandler) showLikes(b *gotgbot.Bot, chatID, userID int64) error {
+	isPremium, err := h.premium.IsActive(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get premium status", zap.Int64("user_id", userID), zap.Error(err))
+		_, err = b.SendMessage(chatID, "Произошла ошибка при загрузке лайков. Попробуйте позже.", nil)
+		return err
+	}
+	if !isPremium {
+		return h.sendLikesTeaser(b, chatID, userID)
+	}
 
 	h.stateMgr.Set(userID, states.StateViewLikes)
 
 	currentIndex := h.stateMgr.GetLikesCurrentIndex(userID)
-	offset := uint64(currentIndex/10) * 10
-	profiles, err := h.callback.getLikeResults(userID, offset)
+	offset := uint64(currentIndex/h.search.LikesPageSize) * uint64(h.search.LikesPageSize)
+	profiles, err := h.getLikeResults(userID, offset)
 	if err != nil {
 		h.logger.Error("Failed to get like results",
 			zap.Int64("user_id", userID),
@@ -30,5 +45,22 @@ func (h *MessageHandler) handleViewLikes(b *gotgbot.Bot, ctx *ext.Context) error
 		return err
 	}
 
-	return h.callback.sendLikeProfile(b, chatID, userID, profiles, currentIndex%10)
+	return h.sendLikeProfile(b, chatID, userID, profiles, currentIndex%h.search.LikesPageSize)
+}
+
+// sendLikesTeaser показывает пользователю без премиума только число лайков
+// и предлагает премиум: сами анкеты он встретит в поиске.
+func (h *CallbackHandler) sendLikesTeaser(b *gotgbot.Bot, chatID, userID int64) error {
+	count, err := h.db.Likes.CountByToUserID(context.Background(), userID)
+	if err != nil {
+		_, err = b.SendMessage(chatID, "Произошла ошибка при загрузке лайков. Попробуйте позже.", nil)
+		return err
+	}
+	if count == 0 {
+		_, err = b.SendMessage(chatID, "Пока вас никто не лайкнул.", nil)
+		return err
+	}
+
+	_, _ = b.SendMessage(chatID, fmt.Sprintf("Вас лайкнули: %d ❤️\nЭти анкеты будут попадаться вам в поиске, а с премиумом ⭐ вы увидите их сразу.", count), nil)
+	return h.sendPremiumInvoice(b, chatID)
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"go.uber.org/zap"
+)
+
+func locationKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{
+			{{Text: "📍 Отправить геопозицию", RequestLocation: true}},
+		},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
+	}
+}
+
+// handleLocation принимает геопозицию на шаге выбора города: город
+// определяется как ближайший к точке, а сама точка сохраняется огрублённой.
+func (h *MessageHandler) handleLocation(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	if h.stateMgr.Get(userID) != states.StateEditCity {
+		return nil
+	}
+	if !h.callback.allowMessage(b, chatID, userID) {
+		return nil
+	}
+
+	point := db.Point{
+		Latitude:  ctx.Message.Location.Latitude,
+		Longitude: ctx.Message.Location.Longitude,
+	}.Coarse()
+
+	city, err := h.db.Cities.GetNearest(context.Background(), point)
+	if err != nil {
+		h.logger.Error("Failed to find nearest city", zap.Int64("user_id", userID), zap.Error(err))
+		_, err := b.SendMessage(chatID, "Не удалось определить город. Введите его название:", nil)
+		return err
+	}
+
+	fields := map[string]string{
+		drafts.FieldCityID: strconv.FormatInt(city.ID, 10),
+		drafts.FieldLat:    strconv.FormatFloat(point.Latitude, 'f', 2, 64),
+		drafts.FieldLon:    strconv.FormatFloat(point.Longitude, 'f', 2, 64),
+	}
+	for field, value := range fields {
+		if err := h.drafts.SetProfileField(context.Background(), userID, field, value); err != nil {
+			return h.draftError(b, chatID, userID, err)
+		}
+	}
+
+	_, _ = b.SendMessage(chatID, "Ближайший город: "+cityTitle(city.Name)+" ✅", nil)
+	return h.callback.promptProfileStep(b, chatID, userID, states.StateEditBio)
+}
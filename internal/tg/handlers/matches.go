@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"go.uber.org/zap"
+)
+
+const matchesPageSize = 10
+
+func (h *MessageHandler) handleViewMatches(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	text, keyboard, err := h.callback.matchesPage(userID, 0)
+	if err != nil {
+		_, err = b.SendMessage(chatID, "Произошла ошибка при загрузке пар. Попробуйте позже.", nil)
+		return err
+	}
+	_, err = b.SendMessage(chatID, text, &gotgbot.SendMessageOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: keyboard,
+	})
+	return err
+}
+
+func (h *CallbackHandler) handleMatchesPage(b *gotgbot.Bot, ctx *ext.Context, userID int64, page int) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+	return h.editMatchesPage(b, chatID, messageID, userID, page)
+}
+
+func (h *CallbackHandler) handleUnmatch(b *gotgbot.Bot, ctx *ext.Context, userID, profileID int64, page int) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	if err := h.db.Matches.Delete(context.Background(), userID, profileID); err != nil {
+		h.logger.Error("Failed to delete match",
+			zap.Int64("user_id", userID),
+			zap.Int64("profile_id", profileID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при удалении пары.", nil)
+		return err
+	}
+
+	return h.editMatchesPage(b, chatID, messageID, userID, page)
+}
+
+func (h *CallbackHandler) editMatchesPage(b *gotgbot.Bot, chatID, messageID, userID int64, page int) error {
+	text, keyboard, err := h.matchesPage(userID, page)
+	if err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке пар.", nil)
+		return err
+	}
+	_, _, err = b.EditMessageText(text, &gotgbot.EditMessageTextOpts{
+		ChatId:      chatID,
+		MessageId:   messageID,
+		ParseMode:   "HTML",
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		h.logger.Warn("Failed to edit matches list",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// matchesPage собирает страницу списка пар со ссылками для связи и кнопками
+// удаления пары. Если страница опустела, показывается предыдущая.
+func (h *CallbackHandler) matchesPage(userID int64, page int) (string, gotgbot.InlineKeyboardMarkup, error) {
+	if page < 0 {
+		page = 0
+	}
+
+	var users []*db.User
+	for {
+		var err error
+		users, err = h.db.Matches.ListByUser(context.Background(), userID, uint64(page*matchesPageSize), matchesPageSize+1)
+		if err != nil {
+			h.logger.Error("Failed to fetch matches",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+			return "", gotgbot.InlineKeyboardMarkup{}, err
+		}
+		if len(users) > 0 || page == 0 {
+			break
+		}
+		page--
+	}
+
+	if len(users) == 0 {
+		return "У вас пока нет пар. Ставьте лайки в поиске анкет!", gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{},
+		}, nil
+	}
+
+	hasNext := len(users) > matchesPageSize
+	if hasNext {
+		users = users[:matchesPageSize]
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Ваши пары 💕 (стр. %d):\n", page+1))
+	keyboard := make([][]gotgbot.InlineKeyboardButton, 0, len(users)+1)
+	for i, u := range users {
+		name := displayName(u)
+		text.WriteString(fmt.Sprintf("\n%d. <a href=\"%s\">%s</a>", page*matchesPageSize+i+1, contactLink(u), html.EscapeString(name)))
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: "💔 Удалить пару: " + name, CallbackData: fmt.Sprintf("unmatch:%d:%d", u.ID, page)},
+		})
+	}
+
+	var nav []gotgbot.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, gotgbot.InlineKeyboardButton{Text: "◀️", CallbackData: fmt.Sprintf("matches_page:%d", page-1)})
+	}
+	if hasNext {
+		nav = append(nav, gotgbot.InlineKeyboardButton{Text: "▶️", CallbackData: fmt.Sprintf("matches_page:%d", page+1)})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+
+	return text.String(), gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}, nil
+}
+
+func displayName(user *db.User) string {
+	if user.Username != nil {
+		return *user.Username
+	}
+	return "Пользователь"
+}
+
+// contactLink возвращает ссылку на личный чат с пользователем.
+func contactLink(user *db.User) string {
+	if user.TgUsername != "" {
+		return "https://t.me/" + user.TgUsername
+	}
+	return fmt.Sprintf("tg://user?id=%d", user.ID)
+}
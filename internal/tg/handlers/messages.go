@@ -6,34 +6,34 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
 	"github.com/agent-yandex/dating-bot/internal/deps"
-	"github.com/agent-yandex/dating-bot/internal/tg/models"
+	storage "github.com/agent-yandex/dating-bot/internal/minio"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
 type MessageHandler struct {
-	stateMgr            *states.Manager
-	db                  *deps.DB
-	logger              *zap.Logger
-	redis               *redis.Client
-	callback            *CallbackHandler
-	tempUserData        map[int64]*models.TempUserData
-	tempUserPreferences map[int64]*models.TempUserPreferencesData
-	isEditing           map[int64]bool
+	stateMgr *states.Manager
+	db       *deps.DB
+	logger   *zap.Logger
+	redis    *redis.Client
+	storage  storage.FileStorage
+	callback *CallbackHandler
+	drafts   *drafts.Drafts
 }
 
-func NewMessageHandler(stateMgr *states.Manager, db *deps.DB, redis *redis.Client, callback *CallbackHandler, logger *zap.Logger) *MessageHandler {
+func NewMessageHandler(stateMgr *states.Manager, db *deps.DB, redis *redis.Client, storage storage.FileStorage, drafts *drafts.Drafts, callback *CallbackHandler, logger *zap.Logger) *MessageHandler {
 	return &MessageHandler{
-		stateMgr:            stateMgr,
-		db:                  db,
-		redis:               redis,
-		callback:            callback,
-		logger:              logger,
-		tempUserData:        make(map[int64]*models.TempUserData),
-		tempUserPreferences: make(map[int64]*models.TempUserPreferencesData),
-		isEditing:           make(map[int64]bool),
+		stateMgr: stateMgr,
+		db:       db,
+		redis:    redis,
+		storage:  storage,
+		drafts:   drafts,
+		callback: callback,
+		logger:   logger,
 	}
 }
 
@@ -44,6 +44,8 @@ func (h *MessageHandler) RegisterMessages(d *ext.Dispatcher) {
 		},
 		h.handleMessage,
 	))
+	d.AddHandler(handlers.NewMessage(message.Photo, h.handlePhoto))
+	d.AddHandler(handlers.NewMessage(message.Location, h.handleLocation))
 }
 
 func (h *MessageHandler) handleMessage(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -52,6 +54,9 @@ func (h *MessageHandler) handleMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	userID := ctx.Message.From.Id
+	if !h.callback.allowMessage(b, ctx.Message.Chat.Id, userID) {
+		return nil
+	}
 	currentState := h.stateMgr.Get(userID)
 
 	switch ctx.Message.Text {
@@ -67,9 +72,19 @@ func (h *MessageHandler) handleMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 		return h.handleSearching(b, ctx)
 	case "Кто меня лайкнул":
 		return h.handleViewLikes(b, ctx)
+	case "Мои пары":
+		return h.handleViewMatches(b, ctx)
+	case "Заблокированные":
+		return h.handleViewBlocked(b, ctx)
+	case premiumText:
+		return h.handleViewPremium(b, ctx)
 	}
 
 	switch currentState {
+	case states.StateResumeProfile:
+		return h.handleResumeProfile(b, ctx)
+	case states.StateResumePreferences:
+		return h.handleResumePreferences(b, ctx)
 	case states.StateEditName:
 		return h.handleName(b, ctx)
 	case states.StateEditGender:
@@ -80,6 +95,8 @@ func (h *MessageHandler) handleMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 		return h.handleCity(b, ctx)
 	case states.StateEditBio:
 		return h.handleBio(b, ctx)
+	case states.StateEditPhoto:
+		return h.handlePhotoText(b, ctx)
 	case states.StateEditPrefGender:
 		return h.handlePrefGender(b, ctx)
 	case states.StateEditPrefMinage:
@@ -90,7 +107,15 @@ func (h *MessageHandler) handleMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 		return h.handlePrefMaxDistance(b, ctx)
 	case states.StateSearching:
 		return h.handleSearching(b, ctx)
+	case states.StateEditLikeMessage:
+		return h.handleLikeMessage(b, ctx)
 	default:
 		return nil
 	}
 }
+
+func (h *MessageHandler) draftError(b *gotgbot.Bot, chatID, userID int64, err error) error {
+	h.logger.Error("Failed to access draft", zap.Int64("user_id", userID), zap.Error(err))
+	_, err = b.SendMessage(chatID, "Произошла ошибка. Попробуйте снова.", nil)
+	return err
+}
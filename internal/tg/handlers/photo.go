@@ -1 +1,161 @@
 package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+	"go.uber.org/zap"
+)
+
+const (
+	maxPhotoSize     = 10 << 20
+	photoDownloadTTL = 30 * time.Second
+	skipPhotoText    = "Пропустить"
+)
+
+func photoKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{
+			{{Text: skipPhotoText}},
+		},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
+	}
+}
+
+func (h *CallbackHandler) askPhoto(b *gotgbot.Bot, chatID int64) error {
+	_, err := b.SendMessage(chatID, "Отправьте фото для профиля 📷 (или нажмите «Пропустить»):", &gotgbot.SendMessageOpts{
+		ReplyMarkup: photoKeyboard(),
+	})
+	return err
+}
+
+func (h *MessageHandler) handlePhoto(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	if h.stateMgr.Get(userID) != states.StateEditPhoto {
+		return nil
+	}
+	if !h.callback.allowMessage(b, chatID, userID) {
+		return nil
+	}
+
+	largest := ctx.Message.Photo[0]
+	for _, size := range ctx.Message.Photo[1:] {
+		if size.Width*size.Height > largest.Width*largest.Height {
+			largest = size
+		}
+	}
+
+	data, err := h.downloadTelegramFile(b, largest.FileId)
+	if err != nil {
+		h.logger.Error("Failed to download photo", zap.Int64("user_id", userID), zap.Error(err))
+		_, err = b.SendMessage(chatID, "Не удалось загрузить фото. Попробуйте другое:", nil)
+		return err
+	}
+
+	objectName := fmt.Sprintf("profiles/%d/%s.jpg", userID, largest.FileUniqueId)
+	_, err = h.storage.UploadFile(context.Background(), objectName, bytes.NewReader(data), int64(len(data)), "image/jpeg")
+	if err != nil {
+		h.logger.Error("Failed to store photo", zap.Int64("user_id", userID), zap.Error(err))
+		_, err = b.SendMessage(chatID, "Не удалось сохранить фото. Попробуйте позже:", nil)
+		return err
+	}
+
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldPhoto, objectName); err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
+
+	return h.finalizeProfile(b, ctx)
+}
+
+func (h *MessageHandler) handlePhotoText(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Message.Text == skipPhotoText {
+		return h.finalizeProfile(b, ctx)
+	}
+	_, err := b.SendMessage(ctx.Message.Chat.Id, "Пожалуйста, отправьте фото или нажмите «Пропустить»:", &gotgbot.SendMessageOpts{
+		ReplyMarkup: photoKeyboard(),
+	})
+	return err
+}
+
+func (h *MessageHandler) downloadTelegramFile(b *gotgbot.Bot, fileID string) ([]byte, error) {
+	file, err := b.GetFile(fileID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if file.FileSize > maxPhotoSize {
+		return nil, fmt.Errorf("file is too large: %d bytes", file.FileSize)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), photoDownloadTTL)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL(b, nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > maxPhotoSize {
+		return nil, fmt.Errorf("file is too large: %d bytes", len(data))
+	}
+	return data, nil
+}
+
+// sendCard отправляет анкету пользователя: с фото, если оно загружено,
+// иначе обычным сообщением.
+func (h *CallbackHandler) sendCard(b *gotgbot.Bot, chatID int64, user *db.User, text string, markup gotgbot.ReplyMarkup) error {
+	if user.ProfilePhoto != nil && *user.ProfilePhoto != "" {
+		err := h.sendPhotoCard(b, chatID, *user.ProfilePhoto, text, markup)
+		if err == nil {
+			return nil
+		}
+		h.logger.Warn("Failed to send profile photo, falling back to text",
+			zap.Int64("user_id", user.ID),
+			zap.Error(err))
+	}
+
+	_, err := b.SendMessage(chatID, text, &gotgbot.SendMessageOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+// sendPhotoCard отправляет анкету с фото из хранилища.
+func (h *CallbackHandler) sendPhotoCard(b *gotgbot.Bot, chatID int64, photo, text string, markup gotgbot.ReplyMarkup) error {
+	reader, err := h.storage.GetFile(context.Background(), photo)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = b.SendPhoto(chatID, gotgbot.InputFileByReader("photo.jpg", reader), &gotgbot.SendPhotoOpts{
+		Caption:     text,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	return err
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
+	"github.com/agent-yandex/dating-bot/internal/tg/states"
+)
+
+func photoUpdate(userID int64, sizes ...gotgbot.PhotoSize) *ext.Context {
+	return ext.NewContext(&gotgbot.Update{
+		Message: &gotgbot.Message{
+			MessageId: 1,
+			From:      &gotgbot.User{Id: userID},
+			Chat:      gotgbot.Chat{Id: userID, Type: "private"},
+			Photo:     sizes,
+		},
+	}, nil)
+}
+
+func TestHandlePhotoStoresLargestSize(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	const userID = 42
+
+	env.tg.addFile("photos/small.jpg", []byte("small"))
+	env.tg.addFile("photos/large.jpg", []byte("large photo"))
+	_, _ = env.users.Insert(ctx, &db.User{ID: userID, Gender: "m", Age: 30, IsActive: true})
+	for field, value := range map[string]string{
+		drafts.FieldGender:  "m",
+		drafts.FieldAge:     "30",
+		drafts.FieldEditing: "1",
+	} {
+		if err := env.drafts.SetProfileField(ctx, userID, field, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.states.Set(userID, states.StateEditPhoto)
+
+	err := env.message.handlePhoto(env.tg.bot, photoUpdate(userID,
+		gotgbot.PhotoSize{FileId: "small", FileUniqueId: "u-small", Width: 90, Height: 90},
+		gotgbot.PhotoSize{FileId: "large", FileUniqueId: "u-large", Width: 1280, Height: 960},
+	))
+	if err != nil {
+		t.Fatalf("handlePhoto: %v", err)
+	}
+
+	objectName := "profiles/" + strconv.Itoa(userID) + "/u-large.jpg"
+	reader, err := env.storage.GetFile(ctx, objectName)
+	if err != nil {
+		t.Fatalf("photo was not stored: %v", err)
+	}
+	defer reader.Close()
+	if data, _ := io.ReadAll(reader); !bytes.Equal(data, []byte("large photo")) {
+		t.Errorf("stored %q, want the largest size", data)
+	}
+
+	user := env.users.user(userID)
+	if user.ProfilePhoto == nil || *user.ProfilePhoto != objectName {
+		t.Errorf("profile photo = %v, want %q", user.ProfilePhoto, objectName)
+	}
+	if got := env.states.Get(userID); got != states.StateDefault {
+		t.Errorf("state = %q, want default after the wizard", got)
+	}
+	if got := env.tg.lastText(); got != "Профиль обновлен! Что хотите сделать дальше?" {
+		t.Errorf("last message = %q", got)
+	}
+}
+
+func TestHandlePhotoIgnoredOutsideWizard(t *testing.T) {
+	env := newTestEnv(t)
+	env.tg.addFile("photos/p.jpg", []byte("photo"))
+
+	err := env.message.handlePhoto(env.tg.bot, photoUpdate(7, gotgbot.PhotoSize{FileId: "p", FileUniqueId: "u", Width: 10, Height: 10}))
+	if err != nil {
+		t.Fatalf("handlePhoto: %v", err)
+	}
+	if calls := env.tg.sent("getFile"); len(calls) != 0 {
+		t.Errorf("photo downloaded outside the photo step: %v", calls)
+	}
+}
+
+func TestHandlePhotoDownloadFailure(t *testing.T) {
+	env := newTestEnv(t)
+	const userID = 5
+	env.states.Set(userID, states.StateEditPhoto)
+
+	// Файла нет на сервере: скачивание завершится ошибкой
+	err := env.message.handlePhoto(env.tg.bot, photoUpdate(userID, gotgbot.PhotoSize{FileId: "missing", FileUniqueId: "m", Width: 10, Height: 10}))
+	if err != nil {
+		t.Fatalf("handlePhoto: %v", err)
+	}
+	if got := env.tg.lastText(); got != "Не удалось загрузить фото. Попробуйте другое:" {
+		t.Errorf("last message = %q", got)
+	}
+	if got := env.states.Get(userID); got != states.StateEditPhoto {
+		t.Errorf("state = %q, want the photo step to be repeated", got)
+	}
+}
+
+func TestSendCardWithPhoto(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	photo := "profiles/1/p.jpg"
+	if _, err := env.storage.UploadFile(ctx, photo, bytes.NewReader([]byte("jpeg")), 4, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := env.callback.sendCard(env.tg.bot, 2, &db.User{ID: 1, ProfilePhoto: &photo}, "card", nil)
+	if err != nil {
+		t.Fatalf("sendCard: %v", err)
+	}
+	calls := env.tg.sent("sendPhoto")
+	if len(calls) != 1 {
+		t.Fatalf("sendPhoto calls = %d, want 1", len(calls))
+	}
+	if got := calls[0].Files["photo"]; !bytes.Equal(got, []byte("jpeg")) {
+		t.Errorf("uploaded photo = %q", got)
+	}
+	if got := calls[0].Params["caption"]; got != "card" {
+		t.Errorf("caption = %q", got)
+	}
+	if open := env.storage.Open(); open != 0 {
+		t.Errorf("open photo readers = %d, want 0", open)
+	}
+}
+
+func TestSendCardClosesPhotoOnSendFailure(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	photo := "profiles/1/p.jpg"
+	if _, err := env.storage.UploadFile(ctx, photo, bytes.NewReader([]byte("jpeg")), 4, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	env.tg.fail("sendPhoto")
+
+	err := env.callback.sendCard(env.tg.bot, 2, &db.User{ID: 1, ProfilePhoto: &photo}, "card", nil)
+	if err != nil {
+		t.Fatalf("sendCard: %v", err)
+	}
+	if got := env.tg.lastText(); got != "card" {
+		t.Errorf("last message = %q, want the text fallback", got)
+	}
+	if open := env.storage.Open(); open != 0 {
+		t.Errorf("open photo readers = %d, want 0", open)
+	}
+}
+
+func TestSendCardFallsBackToText(t *testing.T) {
+	env := newTestEnv(t)
+	photo := "profiles/1/missing.jpg"
+
+	err := env.callback.sendCard(env.tg.bot, 2, &db.User{ID: 1, ProfilePhoto: &photo}, "card", nil)
+	if err != nil {
+		t.Fatalf("sendCard: %v", err)
+	}
+	if calls := env.tg.sent("sendPhoto"); len(calls) != 0 {
+		t.Errorf("sendPhoto calls = %d, want 0", len(calls))
+	}
+	if got := env.tg.lastText(); got != "card" {
+		t.Errorf("last message = %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/premium"
+	"go.uber.org/zap"
+)
+
+const premiumText = "Премиум ⭐"
+
+// noProfilePremiumText — ответ пользователю без анкеты: премиум хранится в
+// профиле, и оплату без него зачесть не к чему.
+const noProfilePremiumText = "Премиум оформляется к анкете. Создайте профиль, а затем возвращайтесь за премиумом."
+
+// PaymentHandler принимает оплату премиума в Telegram Stars.
+type PaymentHandler struct {
+	users   db.UserQuery
+	premium *premium.Service
+	logger  *zap.Logger
+}
+
+func NewPaymentHandler(users db.UserQuery, premium *premium.Service, logger *zap.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		users:   users,
+		premium: premium,
+		logger:  logger,
+	}
+}
+
+func (h *PaymentHandler) RegisterPayments(d *ext.Dispatcher) {
+	d.AddHandler(handlers.NewPreCheckoutQuery(nil, h.handlePreCheckout))
+	d.AddHandler(handlers.NewMessage(message.SuccessfulPayment, h.handleSuccessfulPayment))
+}
+
+// handlePreCheckout подтверждает оплату, только если счёт совпадает с
+// текущим тарифом (цена могла измениться, пока счёт висел в чате) и у
+// пользователя есть анкета, к которой будет выдан премиум.
+func (h *PaymentHandler) handlePreCheckout(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.PreCheckoutQuery
+
+	if err := h.premium.CheckInvoice(query.Currency, query.TotalAmount, query.InvoicePayload); err != nil {
+		h.logger.Warn("Rejected pre-checkout query",
+			zap.Int64("user_id", query.From.Id),
+			zap.String("currency", query.Currency),
+			zap.Int64("amount", query.TotalAmount),
+			zap.String("payload", query.InvoicePayload))
+		_, err := b.AnswerPreCheckoutQuery(query.Id, false, &gotgbot.AnswerPreCheckoutQueryOpts{
+			ErrorMessage: "Счёт устарел. Запросите новый в разделе «Премиум».",
+		})
+		return err
+	}
+
+	user, err := h.users.GetByID(context.Background(), query.From.Id)
+	if err != nil {
+		h.logger.Error("Failed to check payer profile", zap.Int64("user_id", query.From.Id), zap.Error(err))
+		_, err := b.AnswerPreCheckoutQuery(query.Id, false, &gotgbot.AnswerPreCheckoutQueryOpts{
+			ErrorMessage: "Не удалось проверить оплату. Попробуйте позже.",
+		})
+		return err
+	}
+	if user == nil {
+		h.logger.Warn("Rejected pre-checkout query without profile", zap.Int64("user_id", query.From.Id))
+		_, err := b.AnswerPreCheckoutQuery(query.Id, false, &gotgbot.AnswerPreCheckoutQueryOpts{
+			ErrorMessage: noProfilePremiumText,
+		})
+		return err
+	}
+
+	_, err = b.AnswerPreCheckoutQuery(query.Id, true, nil)
+	return err
+}
+
+func (h *PaymentHandler) handleSuccessfulPayment(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+	paid := ctx.Message.SuccessfulPayment
+
+	until, applied, err := h.premium.ApplyPayment(context.Background(), &premium.Payment{
+		UserID:           userID,
+		TelegramChargeID: paid.TelegramPaymentChargeId,
+		ProviderChargeID: paid.ProviderPaymentChargeId,
+		Currency:         paid.Currency,
+		Amount:           paid.TotalAmount,
+		Payload:          paid.InvoicePayload,
+	})
+	if err != nil {
+		_, _ = b.SendMessage(chatID, "Оплата получена, но премиум не удалось активировать. Мы разберёмся и свяжемся с вами или вернём Stars.", nil)
+		return err
+	}
+	if !applied {
+		return nil
+	}
+
+	_, err = b.SendMessage(chatID, fmt.Sprintf("Спасибо! Премиум ⭐ активен до %s.", until.Format("02.01.2006 15:04")), &gotgbot.SendMessageOpts{
+		ReplyMarkup: GetMainReplyKeyboard(),
+	})
+	return err
+}
+
+// sendPremiumInvoice отправляет счёт на покупку премиума.
+func (h *CallbackHandler) sendPremiumInvoice(b *gotgbot.Bot, chatID int64) error {
+	plan := h.premium.Plan()
+	_, err := b.SendInvoice(chatID, plan.Title, plan.Description, plan.Payload, premium.CurrencyStars,
+		[]gotgbot.LabeledPrice{{Label: plan.Title, Amount: plan.Stars}}, nil)
+	if err != nil {
+		h.logger.Error("Failed to send premium invoice",
+			zap.Int64("chat_id", chatID),
+			zap.Error(err))
+	}
+	return err
+}
+
+func (h *MessageHandler) handleViewPremium(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	user, err := h.db.Users.GetByID(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to fetch user profile", zap.Int64("user_id", userID), zap.Error(err))
+		_, err = b.SendMessage(chatID, "Произошла ошибка. Попробуйте позже.", nil)
+		return err
+	}
+	if user == nil {
+		_, err = b.SendMessage(chatID, noProfilePremiumText, nil)
+		return err
+	}
+
+	until, err := h.callback.premium.Until(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get premium status", zap.Int64("user_id", userID), zap.Error(err))
+		_, err = b.SendMessage(chatID, "Произошла ошибка. Попробуйте позже.", nil)
+		return err
+	}
+
+	text := "С премиумом ⭐ вы сразу видите, кто вас лайкнул, ставите лайки без суточного лимита, а ваша анкета показывается в поиске раньше других."
+	if until != nil {
+		text = fmt.Sprintf("Премиум ⭐ активен до %s. Оплата продлит его.", until.Format("02.01.2006 15:04"))
+	}
+	_, _ = b.SendMessage(chatID, text, nil)
+
+	return h.callback.sendPremiumInvoice(b, chatID)
+}
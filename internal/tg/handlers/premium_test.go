@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/premium"
+	"go.uber.org/zap"
+)
+
+const payerID = 5
+
+func preCheckoutUpdate(amount int64) *ext.Context {
+	return ext.NewContext(&gotgbot.Update{
+		PreCheckoutQuery: &gotgbot.PreCheckoutQuery{
+			Id:             "query-1",
+			From:           gotgbot.User{Id: payerID},
+			Currency:       premium.CurrencyStars,
+			TotalAmount:    amount,
+			InvoicePayload: "premium_30d",
+		},
+	}, nil)
+}
+
+func successfulPaymentUpdate(chargeID string, amount int64) *ext.Context {
+	return ext.NewContext(&gotgbot.Update{
+		Message: &gotgbot.Message{
+			MessageId: 1,
+			From:      &gotgbot.User{Id: payerID},
+			Chat:      gotgbot.Chat{Id: payerID, Type: "private"},
+			SuccessfulPayment: &gotgbot.SuccessfulPayment{
+				Currency:                premium.CurrencyStars,
+				TotalAmount:             amount,
+				InvoicePayload:          "premium_30d",
+				TelegramPaymentChargeId: chargeID,
+				ProviderPaymentChargeId: "provider-" + chargeID,
+			},
+		},
+	}, nil)
+}
+
+// newPaymentEnv создаёт обработчик оплаты для покупателя с анкетой.
+func newPaymentEnv(t *testing.T, env *testEnv) *PaymentHandler {
+	t.Helper()
+	if _, err := env.users.Insert(context.Background(), &db.User{ID: payerID, Gender: "f", Age: 25, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	return NewPaymentHandler(env.users, env.callback.premium, zap.NewNop())
+}
+
+func TestPreCheckoutChecksInvoice(t *testing.T) {
+	env := newTestEnv(t)
+	h := newPaymentEnv(t, env)
+
+	if err := h.handlePreCheckout(env.tg.bot, preCheckoutUpdate(100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.handlePreCheckout(env.tg.bot, preCheckoutUpdate(250)); err != nil {
+		t.Fatal(err)
+	}
+
+	answers := env.tg.sent("answerPreCheckoutQuery")
+	if len(answers) != 2 {
+		t.Fatalf("answers = %v", answers)
+	}
+	if answers[0].Params["ok"] != "false" || answers[0].Params["error_message"] == "" {
+		t.Errorf("outdated invoice answer = %v, want a rejection", answers[0].Params)
+	}
+	if answers[1].Params["ok"] != "true" {
+		t.Errorf("current invoice answer = %v, want ok", answers[1].Params)
+	}
+}
+
+func TestSuccessfulPaymentGrantsPremiumOnce(t *testing.T) {
+	env := newTestEnv(t)
+	h := newPaymentEnv(t, env)
+	ctx := context.Background()
+
+	if err := h.handleSuccessfulPayment(env.tg.bot, successfulPaymentUpdate("charge-1", 250)); err != nil {
+		t.Fatal(err)
+	}
+	first, err := env.callback.premium.Until(ctx, payerID)
+	if err != nil || first == nil {
+		t.Fatalf("premium is not active: %v, %v", first, err)
+	}
+	if !strings.HasPrefix(env.tg.lastText(), "Спасибо! Премиум ⭐ активен до") {
+		t.Errorf("reply = %q", env.tg.lastText())
+	}
+
+	// Telegram может доставить то же сообщение об оплате повторно
+	sent := len(env.tg.texts())
+	if err := h.handleSuccessfulPayment(env.tg.bot, successfulPaymentUpdate("charge-1", 250)); err != nil {
+		t.Fatal(err)
+	}
+	until, _ := env.callback.premium.Until(ctx, payerID)
+	if !until.Equal(*first) {
+		t.Errorf("repeated delivery moved premium from %v to %v", first, until)
+	}
+	if len(env.tg.texts()) != sent {
+		t.Errorf("repeated delivery was answered: %q", env.tg.lastText())
+	}
+}
+
+func TestSuccessfulPaymentAfterPriceChange(t *testing.T) {
+	env := newTestEnv(t)
+	h := newPaymentEnv(t, env)
+
+	// Счёт по старой цене прошёл проверку, а затем тариф сменился
+	if err := h.handleSuccessfulPayment(env.tg.bot, successfulPaymentUpdate("charge-1", 100)); err != nil {
+		t.Fatal(err)
+	}
+	active, err := env.callback.premium.IsActive(context.Background(), payerID)
+	if err != nil || !active {
+		t.Errorf("premium active = %v, %v; want the paid premium granted", active, err)
+	}
+	if _, ok := env.premium.payments["charge-1"]; !ok {
+		t.Error("charge was not recorded")
+	}
+}
+
+func TestPreCheckoutRejectsPayerWithoutProfile(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewPaymentHandler(env.users, env.callback.premium, zap.NewNop())
+
+	if err := h.handlePreCheckout(env.tg.bot, preCheckoutUpdate(250)); err != nil {
+		t.Fatal(err)
+	}
+	answers := env.tg.sent("answerPreCheckoutQuery")
+	if len(answers) != 1 || answers[0].Params["ok"] != "false" || answers[0].Params["error_message"] != noProfilePremiumText {
+		t.Errorf("answers = %v, want a rejection", answers)
+	}
+}
+
+func TestViewPremiumWithoutProfile(t *testing.T) {
+	env := newTestEnv(t)
+
+	update := ext.NewContext(&gotgbot.Update{
+		Message: &gotgbot.Message{
+			MessageId: 1,
+			From:      &gotgbot.User{Id: payerID},
+			Chat:      gotgbot.Chat{Id: payerID, Type: "private"},
+			Text:      premiumText,
+		},
+	}, nil)
+	if err := env.message.handleViewPremium(env.tg.bot, update); err != nil {
+		t.Fatal(err)
+	}
+	if invoices := env.tg.sent("sendInvoice"); len(invoices) != 0 {
+		t.Errorf("invoice sent to a user without profile: %v", invoices)
+	}
+	if got := env.tg.lastText(); got != noProfilePremiumText {
+		t.Errorf("reply = %q", got)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
 	"github.com/agent-yandex/dating-bot/internal/tg/models"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"go.uber.org/zap"
@@ -33,65 +35,153 @@ func (h *MessageHandler) handleViewProfile(b *gotgbot.Bot, ctx *ext.Context) err
 	}
 
 	profileText := FormatProfile(user, city.Name)
-	_, err = b.SendMessage(chatID, profileText, &gotgbot.SendMessageOpts{
-		ReplyMarkup: GetMainReplyKeyboard(),
-	})
-	return err
+	return h.callback.sendCard(b, chatID, user, profileText, GetMainReplyKeyboard())
 }
 
 func (h *MessageHandler) handleProfileCreation(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
 
+	draft, err := h.drafts.Profile(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to load profile draft", zap.Int64("user_id", userID), zap.Error(err))
+	} else if draft.Username != nil {
+		h.stateMgr.Set(userID, states.StateResumeProfile)
+		_, err := b.SendMessage(chatID, "Вы не закончили заполнение профиля. Продолжить с того места, где остановились?", &gotgbot.SendMessageOpts{
+			ReplyMarkup: resumeKeyboard(),
+		})
+		return err
+	}
+
+	return h.startProfileWizard(b, ctx)
+}
+
+func (h *MessageHandler) startProfileWizard(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
 	user, err := h.db.Users.GetByID(context.Background(), userID)
 	if err != nil {
 		h.logger.Error("Failed to check user existence", zap.Int64("user_id", userID), zap.Error(err))
 		_, err := b.SendMessage(chatID, "Произошла ошибка. Попробуйте снова.", nil)
 		return err
 	}
+
+	editing := "0"
+	text := "Отлично! Теперь введите ваше имя:"
 	if user != nil {
-		h.isEditing[userID] = true
-		h.stateMgr.Set(userID, states.StateEditName)
-		_, err := b.SendMessage(chatID, "У вас уже есть профиль. Давайте отредактируем его. Введите ваше имя:", &gotgbot.SendMessageOpts{
-			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-		})
-		return err
+		editing = "1"
+		text = "У вас уже есть профиль. Давайте отредактируем его. Введите ваше имя:"
+	}
+
+	if err := h.drafts.DeleteProfile(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to reset profile draft", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldEditing, editing); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
 
 	h.stateMgr.Set(userID, states.StateEditName)
-	h.isEditing[userID] = false
-	_, err = b.SendMessage(chatID, "Отлично! Теперь введите ваше имя:", &gotgbot.SendMessageOpts{
+	_, err = b.SendMessage(chatID, text, &gotgbot.SendMessageOpts{
 		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
 	})
 	return err
 }
 
-func (h *MessageHandler) handleName(b *gotgbot.Bot, ctx *ext.Context) error {
+func (h *MessageHandler) handleResumeProfile(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
-	input := ctx.Message.Text
 
-	if _, exists := h.tempUserData[userID]; !exists {
-		h.tempUserData[userID] = &models.TempUserData{}
+	switch ctx.Message.Text {
+	case resumeText:
+		draft, err := h.drafts.Profile(context.Background(), userID)
+		if err != nil {
+			return h.draftError(b, chatID, userID, err)
+		}
+		return h.callback.promptProfileStep(b, chatID, userID, nextProfileStep(draft))
+	case restartText:
+		return h.startProfileWizard(b, ctx)
+	default:
+		_, err := b.SendMessage(chatID, "Пожалуйста, выберите один из вариантов:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: resumeKeyboard(),
+		})
+		return err
+	}
+}
+
+// nextProfileStep определяет первый незаполненный шаг мастера профиля.
+func nextProfileStep(draft *models.TempUserData) states.State {
+	switch {
+	case draft.Username == nil:
+		return states.StateEditName
+	case draft.Gender == "":
+		return states.StateEditGender
+	case draft.Age == 0:
+		return states.StateEditAge
+	case draft.CityID == nil:
+		return states.StateEditCity
+	case draft.Bio == nil:
+		return states.StateEditBio
+	default:
+		return states.StateEditPhoto
+	}
+}
+
+func (h *CallbackHandler) promptProfileStep(b *gotgbot.Bot, chatID, userID int64, step states.State) error {
+	h.stateMgr.Set(userID, step)
+
+	var err error
+	switch step {
+	case states.StateEditName:
+		_, err = b.SendMessage(chatID, "Введите ваше имя:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	case states.StateEditGender:
+		_, err = b.SendMessage(chatID, "Укажите ваш пол:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: genderKeyboard(),
+		})
+	case states.StateEditAge:
+		_, err = b.SendMessage(chatID, "Введите ваш возраст (10-100):", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	case states.StateEditCity:
+		_, err = b.SendMessage(chatID, "Введите ваш город или отправьте геопозицию:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: locationKeyboard(),
+		})
+	case states.StateEditBio:
+		_, err = b.SendMessage(chatID, "Расскажите о себе (макс. 500 символов):", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	case states.StateEditPhoto:
+		err = h.askPhoto(b, chatID)
+	}
+	return err
+}
+
+func genderKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{
+			{{Text: "Мужской"}, {Text: "Женский"}},
+		},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
 	}
+}
+
+func (h *MessageHandler) handleName(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+	input := ctx.Message.Text
 
 	if len(input) > 50 {
 		_, err := b.SendMessage(chatID, "Имя слишком длинное (макс. 50 символов). Попробуйте снова:", nil)
 		return err
 	}
 
-	h.tempUserData[userID].Username = &input
-	h.stateMgr.Set(userID, states.StateEditGender)
-	_, err := b.SendMessage(chatID, "Укажите ваш пол:", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardMarkup{
-			Keyboard: [][]gotgbot.KeyboardButton{
-				{{Text: "Мужской"}, {Text: "Женский"}},
-			},
-			ResizeKeyboard:  true,
-			OneTimeKeyboard: true,
-		},
-	})
-	return err
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldUsername, input); err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
+	return h.callback.promptProfileStep(b, chatID, userID, states.StateEditGender)
 }
 
 func (h *MessageHandler) handleGender(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -107,27 +197,15 @@ func (h *MessageHandler) handleGender(b *gotgbot.Bot, ctx *ext.Context) error {
 		gender = "f"
 	default:
 		_, err := b.SendMessage(chatID, "Пожалуйста, выберите пол из предложенных вариантов:", &gotgbot.SendMessageOpts{
-			ReplyMarkup: gotgbot.ReplyKeyboardMarkup{
-				Keyboard: [][]gotgbot.KeyboardButton{
-					{{Text: "Мужской"}, {Text: "Женский"}},
-				},
-				ResizeKeyboard:  true,
-				OneTimeKeyboard: true,
-			},
+			ReplyMarkup: genderKeyboard(),
 		})
 		return err
 	}
 
-	if _, exists := h.tempUserData[userID]; !exists {
-		h.tempUserData[userID] = &models.TempUserData{}
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldGender, gender); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserData[userID].Gender = gender
-
-	h.stateMgr.Set(userID, states.StateEditAge)
-	_, err := b.SendMessage(chatID, "Введите ваш возраст (10-100):", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-	})
-	return err
+	return h.callback.promptProfileStep(b, chatID, userID, states.StateEditAge)
 }
 
 func (h *MessageHandler) handleAge(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -141,42 +219,29 @@ func (h *MessageHandler) handleAge(b *gotgbot.Bot, ctx *ext.Context) error {
 		return err
 	}
 
-	if _, exists := h.tempUserData[userID]; !exists {
-		h.tempUserData[userID] = &models.TempUserData{}
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldAge, strconv.Itoa(age)); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserData[userID].Age = age
-
-	h.stateMgr.Set(userID, states.StateEditCity)
-	_, err = b.SendMessage(chatID, "Введите ваш город:", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-	})
-	return err
+	return h.callback.promptProfileStep(b, chatID, userID, states.StateEditCity)
 }
 
 func (h *MessageHandler) handleCity(b *gotgbot.Bot, ctx *ext.Context) error {
-	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
 	input := ctx.Message.Text
 
-	cityID, err := h.db.Cities.GetIDByName(context.Background(), input)
+	cities, err := h.db.Cities.SearchByName(context.Background(), input, citySuggestionsLimit)
 	if err != nil {
 		h.logger.Error("Failed to find city", zap.String("city_name", input), zap.Error(err))
 		_, err := b.SendMessage(chatID, "Произошла ошибка при поиске города. Попробуйте снова:", nil)
 		return err
 	}
-	if cityID == 0 {
+	if len(cities) == 0 {
 		_, err := b.SendMessage(chatID, "Город не найден. Уточните название (например, Москва, Санкт-Петербург, Нижний Новгород):", nil)
 		return err
 	}
 
-	if _, exists := h.tempUserData[userID]; !exists {
-		h.tempUserData[userID] = &models.TempUserData{}
-	}
-	h.tempUserData[userID].CityID = &cityID
-
-	h.stateMgr.Set(userID, states.StateEditBio)
-	_, err = b.SendMessage(chatID, "Расскажите о себе (макс. 500 символов):", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+	_, err = b.SendMessage(chatID, "Выберите ваш город или введите название точнее:", &gotgbot.SendMessageOpts{
+		ReplyMarkup: cityKeyboard(cities),
 	})
 	return err
 }
@@ -191,18 +256,19 @@ func (h *MessageHandler) handleBio(b *gotgbot.Bot, ctx *ext.Context) error {
 		return err
 	}
 
-	if _, exists := h.tempUserData[userID]; !exists {
-		h.tempUserData[userID] = &models.TempUserData{}
+	if err := h.drafts.SetProfileField(context.Background(), userID, drafts.FieldBio, input); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserData[userID].Bio = &input
-
-	return h.finalizeProfile(b, ctx)
+	return h.callback.promptProfileStep(b, chatID, userID, states.StateEditPhoto)
 }
 
 func (h *MessageHandler) finalizeProfile(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
-	tempData := h.tempUserData[userID]
+	tempData, err := h.drafts.Profile(context.Background(), userID)
+	if err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
 
 	user := &db.User{
 		Username: tempData.Username,
@@ -213,9 +279,8 @@ func (h *MessageHandler) finalizeProfile(b *gotgbot.Bot, ctx *ext.Context) error
 		IsActive: true,
 	}
 
-	var err error
 	var successMessage string
-	if h.isEditing[userID] {
+	if tempData.IsEditing {
 		_, err = h.db.Users.Update(context.Background(), user, userID)
 		successMessage = "Профиль обновлен! Что хотите сделать дальше?"
 	} else {
@@ -232,8 +297,26 @@ func (h *MessageHandler) finalizeProfile(b *gotgbot.Bot, ctx *ext.Context) error
 		return err
 	}
 
-	delete(h.tempUserData, userID)
-	delete(h.isEditing, userID)
+	// Город, выбранный по названию, сбрасывает ранее сохранённую геопозицию
+	var point *db.Point
+	if tempData.Latitude != nil && tempData.Longitude != nil {
+		point = &db.Point{Latitude: *tempData.Latitude, Longitude: *tempData.Longitude}
+	}
+	if err := h.db.Users.UpdateLocation(context.Background(), userID, point); err != nil {
+		h.logger.Error("Failed to save location", zap.Int64("user_id", userID), zap.Error(err))
+	}
+
+	if tempData.ProfilePhoto != nil {
+		if err := h.db.Users.UpdateProfilePhoto(context.Background(), userID, tempData.ProfilePhoto); err != nil {
+			h.logger.Error("Failed to save profile photo", zap.Int64("user_id", userID), zap.Error(err))
+		}
+	}
+
+	h.callback.publish(events.ProfileUpdated{UserID: userID, Created: !tempData.IsEditing})
+
+	if err := h.drafts.DeleteProfile(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to delete profile draft", zap.Int64("user_id", userID), zap.Error(err))
+	}
 	h.stateMgr.Reset(userID)
 
 	_, err = b.SendMessage(chatID, successMessage, &gotgbot.SendMessageOpts{
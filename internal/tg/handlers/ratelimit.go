@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/agent-yandex/dating-bot/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// dailyQuotaWindow — окно суточной квоты лайков.
+const dailyQuotaWindow = 24 * time.Hour
+
+// allow проверяет лимит действия action для пользователя. Если лимитер
+// недоступен, действие разрешается: сбой Redis не должен останавливать бота.
+func (h *CallbackHandler) allow(userID int64, action string, limit int, window time.Duration) (ratelimit.Result, bool) {
+	res, ok, _ := h.reserve(userID, action, limit, window)
+	return res, ok
+}
+
+// reserve работает как allow и дополнительно сообщает, засчитано ли
+// действие: при сбое лимитера оно разрешается без списания, и возвращать
+// потом нечего.
+func (h *CallbackHandler) reserve(userID int64, action string, limit int, window time.Duration) (ratelimit.Result, bool, bool) {
+	res, err := h.limiter.Allow(context.Background(), rateKey(action, userID), limit, window)
+	if err != nil {
+		h.logger.Error("Failed to check rate limit",
+			zap.Int64("user_id", userID),
+			zap.String("action", action),
+			zap.Error(err))
+		return ratelimit.Result{Allowed: true}, true, false
+	}
+	if !res.Allowed {
+		h.logger.Warn("Rate limit exceeded",
+			zap.Int64("user_id", userID),
+			zap.String("action", action),
+			zap.Duration("retry_after", res.RetryAfter))
+	}
+	return res, res.Allowed, res.Allowed
+}
+
+// allowMessage ограничивает частоту сообщений. О превышении пользователь
+// узнаёт один раз за окно, чтобы бот сам не начал спамить в ответ.
+func (h *CallbackHandler) allowMessage(b *gotgbot.Bot, chatID, userID int64) bool {
+	res, ok := h.allow(userID, "message", h.limits.Messages, h.limits.Window)
+	if ok {
+		return true
+	}
+	if _, notify := h.allow(userID, "message_notice", 1, h.limits.Window); notify {
+		_, _ = b.SendMessage(chatID, fmt.Sprintf("Слишком много сообщений. Попробуйте через %s.", FormatWait(res.RetryAfter)), nil)
+	}
+	return false
+}
+
+// likeQuota — места, занятые новым лайком в лимитах.
+type likeQuota struct {
+	// minute — занято ли место в минутном лимите
+	minute bool
+	// daily — занято ли место в суточной квоте (у премиума её нет)
+	daily bool
+}
+
+// reserveLikeQuota занимает место для лайка в минутном лимите и, для
+// пользователей без премиума, в суточной квоте. Проверка и списание
+// атомарны, поэтому одновременные лайки не проходят лимит вдвоём. Если лайк
+// затем не сохранится или окажется повторным, место возвращает
+// refundLikeQuota. Если лайк сейчас недоступен, отправляет пользователю
+// сообщение и возвращает false.
+func (h *CallbackHandler) reserveLikeQuota(b *gotgbot.Bot, chatID, userID int64) (likeQuota, bool) {
+	var quota likeQuota
+	res, ok, charged := h.reserve(userID, "like", h.limits.Likes, h.limits.Window)
+	if !ok {
+		_, _ = b.SendMessage(chatID, fmt.Sprintf("Вы ставите лайки слишком часто. Попробуйте через %s.", FormatWait(res.RetryAfter)), nil)
+		return likeQuota{}, false
+	}
+	quota.minute = charged
+
+	isPremium, err := h.premium.IsActive(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get premium status", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	if isPremium {
+		return quota, true
+	}
+
+	res, ok, charged = h.reserve(userID, "like_daily", h.limits.DailyLikes, dailyQuotaWindow)
+	if !ok {
+		h.refundLikeQuota(userID, quota)
+		_, _ = b.SendMessage(chatID, fmt.Sprintf(
+			"Лимит лайков исчерпан: %d за сутки. Следующий лайк будет доступен через %s.\nС премиумом ⭐ лайки без ограничений.",
+			h.limits.DailyLikes, FormatWait(res.RetryAfter)), nil)
+		return likeQuota{}, false
+	}
+	quota.daily = charged
+	return quota, true
+}
+
+// refundLikeQuota возвращает места, занятые reserveLikeQuota.
+func (h *CallbackHandler) refundLikeQuota(userID int64, quota likeQuota) {
+	if quota.minute {
+		h.release(userID, "like")
+	}
+	if quota.daily {
+		h.release(userID, "like_daily")
+	}
+}
+
+// refundDailyLike возвращает в суточную квоту отменённый лайк. Минутный
+// лимит не возвращается: он защищает от частых нажатий, и отмена тоже
+// нажатие. Премиум лайки из квоты не списывает, возвращать ему нечего.
+func (h *CallbackHandler) refundDailyLike(userID int64) {
+	isPremium, err := h.premium.IsActive(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get premium status", zap.Int64("user_id", userID), zap.Error(err))
+		return
+	}
+	if !isPremium {
+		h.release(userID, "like_daily")
+	}
+}
+
+// release возвращает место, занятое действием.
+func (h *CallbackHandler) release(userID int64, action string) {
+	if err := h.limiter.Release(context.Background(), rateKey(action, userID)); err != nil {
+		h.logger.Error("Failed to release rate limited action",
+			zap.Int64("user_id", userID),
+			zap.String("action", action),
+			zap.Error(err))
+	}
+}
+
+func rateKey(action string, userID int64) string {
+	return fmt.Sprintf("%s:user:%d", action, userID)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// remaining возвращает, сколько действий action ещё доступно пользователю.
+func remaining(t *testing.T, env *testEnv, action string, limit int, window time.Duration) int {
+	t.Helper()
+	res, err := env.limiter.Peek(context.Background(), rateKey(action, searcherID), limit, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Remaining
+}
+
+func like(t *testing.T, env *testEnv, profileID int64) error {
+	t.Helper()
+	return env.callback.handleLike(env.tg.bot, callbackUpdate(searcherID, fmt.Sprintf("like:%d", profileID)), searcherID, profileID)
+}
+
+func TestRepeatedLikeDoesNotSpendQuota(t *testing.T) {
+	env := newSearchEnv(t, 2)
+	env.callback.limits.Likes = 5
+	env.callback.limits.DailyLikes = 5
+
+	for i := 0; i < 2; i++ {
+		if err := like(t, env, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := remaining(t, env, "like", 5, time.Minute); got != 4 {
+		t.Errorf("minute quota remaining = %d, want 4", got)
+	}
+	if got := remaining(t, env, "like_daily", 5, dailyQuotaWindow); got != 4 {
+		t.Errorf("daily quota remaining = %d, want 4", got)
+	}
+}
+
+func TestFailedLikeDoesNotSpendQuota(t *testing.T) {
+	env := newSearchEnv(t, 2)
+	env.callback.limits.Likes = 5
+	env.callback.limits.DailyLikes = 5
+	env.matches.fail = errors.New("database is down")
+
+	if err := like(t, env, 2); err == nil {
+		t.Fatal("like succeeded although it was not saved")
+	}
+	if got := remaining(t, env, "like", 5, time.Minute); got != 5 {
+		t.Errorf("minute quota remaining = %d, want 5", got)
+	}
+	if got := remaining(t, env, "like_daily", 5, dailyQuotaWindow); got != 5 {
+		t.Errorf("daily quota remaining = %d, want 5", got)
+	}
+}
+
+func TestDailyLimitRefusalKeepsMinuteQuota(t *testing.T) {
+	env := newSearchEnv(t, 2, 3)
+	env.callback.limits.Likes = 5
+	env.callback.limits.DailyLikes = 1
+
+	if err := like(t, env, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := like(t, env, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(env.tg.lastText(), "Лимит лайков исчерпан") {
+		t.Errorf("reply = %q, want the daily limit notice", env.tg.lastText())
+	}
+	if env.matches.hasLike(searcherID, 3) {
+		t.Error("like over the daily limit was saved")
+	}
+	if got := remaining(t, env, "like", 5, time.Minute); got != 4 {
+		t.Errorf("minute quota remaining = %d, want 4", got)
+	}
+}
+
+func TestMinuteLimit(t *testing.T) {
+	env := newSearchEnv(t, 2, 3)
+	now := time.Now()
+	env.limiter.SetClock(func() time.Time { return now })
+	env.callback.limits.Likes = 1
+
+	if err := like(t, env, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := like(t, env, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(env.tg.lastText(), "Вы ставите лайки слишком часто") {
+		t.Errorf("reply = %q, want the minute limit notice", env.tg.lastText())
+	}
+	if env.matches.hasLike(searcherID, 3) {
+		t.Fatal("like over the minute limit was saved")
+	}
+
+	now = now.Add(time.Minute)
+	if err := like(t, env, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !env.matches.hasLike(searcherID, 3) {
+		t.Error("like was refused after the window passed")
+	}
+}
+
+func TestPremiumLikesSkipDailyQuota(t *testing.T) {
+	env := newSearchEnv(t, 2, 3)
+	env.callback.limits.DailyLikes = 1
+	if _, err := env.callback.premium.Grant(context.Background(), searcherID, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int64{2, 3} {
+		if err := like(t, env, id); err != nil {
+			t.Fatal(err)
+		}
+		if !env.matches.hasLike(searcherID, id) {
+			t.Errorf("premium like %d was refused", id)
+		}
+	}
+	if got := remaining(t, env, "like_daily", 1, dailyQuotaWindow); got != 1 {
+		t.Errorf("daily quota remaining = %d, want 1", got)
+	}
+}
+
+func TestReservedQuotaBlocksConcurrentLike(t *testing.T) {
+	env := newSearchEnv(t, 2, 3)
+	env.callback.limits.Likes = 5
+	env.callback.limits.DailyLikes = 1
+
+	// Первый лайк занял последнее место и ещё сохраняется, когда приходит
+	// второй: второй не должен пройти ту же проверку
+	quota, ok := env.callback.reserveLikeQuota(env.tg.bot, searcherID, searcherID)
+	if !ok || !quota.minute || !quota.daily {
+		t.Fatalf("reserveLikeQuota = %+v, %v; want both limits reserved", quota, ok)
+	}
+	if err := like(t, env, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(env.tg.lastText(), "Лимит лайков исчерпан") {
+		t.Errorf("reply = %q, want the daily limit notice", env.tg.lastText())
+	}
+	if env.matches.hasLike(searcherID, 3) {
+		t.Error("concurrent like passed the daily limit")
+	}
+	// Отказ по суточной квоте вернул место в минутном лимите
+	if got := remaining(t, env, "like", 5, time.Minute); got != 4 {
+		t.Errorf("minute quota remaining = %d, want 4", got)
+	}
+
+	env.callback.refundLikeQuota(searcherID, quota)
+	if got := remaining(t, env, "like_daily", 1, dailyQuotaWindow); got != 1 {
+		t.Errorf("daily quota remaining after refund = %d, want 1", got)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/tg/drafts"
 	"github.com/agent-yandex/dating-bot/internal/tg/models"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
 	"go.uber.org/zap"
@@ -27,28 +29,188 @@ func (h *MessageHandler) handleViewUserPreferences(b *gotgbot.Bot, ctx *ext.Cont
 
 	userPrefText := FormatUserPref(userPref)
 	_, err = b.SendMessage(chatID, userPrefText, &gotgbot.SendMessageOpts{
-		ReplyMarkup: GetMainReplyKeyboard(),
+		ReplyMarkup: preferencesKeyboard(userPref),
 	})
 	return err
 }
 
+func preferencesKeyboard(pref *db.UserPreference) gotgbot.InlineKeyboardMarkup {
+	text := "🔁 Включить взаимный подбор"
+	if pref.MutualMatch {
+		text = "🔁 Выключить взаимный подбор"
+	}
+	notifyText := "🔔 Включить уведомления о лайках"
+	if pref.NotifyLikes {
+		notifyText = "🔕 Выключить уведомления о лайках"
+	}
+	return gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: text, CallbackData: fmt.Sprintf("mutual:%t", !pref.MutualMatch)}},
+			{{Text: notifyText, CallbackData: fmt.Sprintf("notify_likes:%t", !pref.NotifyLikes)}},
+			{{Text: "🔄 Сбросить пропущенные анкеты", CallbackData: "reset_passes"}},
+		},
+	}
+}
+
+func (h *CallbackHandler) handleMutualToggle(b *gotgbot.Bot, ctx *ext.Context, userID int64, enabled bool) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+
+	if err := h.db.UserPreferences.SetMutualMatch(context.Background(), userID, enabled); err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при сохранении настроек поиска.", nil)
+		return err
+	}
+
+	h.publish(events.PreferencesChanged{UserID: userID})
+
+	return h.refreshPreferences(b, ctx, userID)
+}
+
+func (h *CallbackHandler) handleNotifyLikesToggle(b *gotgbot.Bot, ctx *ext.Context, userID int64, enabled bool) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+
+	if err := h.db.UserPreferences.SetNotifyLikes(context.Background(), userID, enabled); err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при сохранении настроек.", nil)
+		return err
+	}
+
+	return h.refreshPreferences(b, ctx, userID)
+}
+
+// refreshPreferences перерисовывает сообщение с настройками поиска.
+func (h *CallbackHandler) refreshPreferences(b *gotgbot.Bot, ctx *ext.Context, userID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	userPref, err := h.db.UserPreferences.GetByUserID(context.Background(), userID)
+	if err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке настроек поиска.", nil)
+		return err
+	}
+	_, _, err = b.EditMessageText(FormatUserPref(userPref), &gotgbot.EditMessageTextOpts{
+		ChatId:      chatID,
+		MessageId:   messageID,
+		ReplyMarkup: preferencesKeyboard(userPref),
+	})
+	if err != nil {
+		h.logger.Warn("Failed to edit preferences message",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+	return nil
+}
+
+func (h *CallbackHandler) handleResetPasses(b *gotgbot.Bot, ctx *ext.Context, userID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+
+	count, err := h.db.Passes.DeleteAllByUser(context.Background(), userID)
+	if err != nil {
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при сбросе пропущенных анкет.", nil)
+		return err
+	}
+	if err := h.feed.Reset(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to reset search feed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+
+	_, err = b.SendMessage(chatID, fmt.Sprintf("Пропущенные анкеты снова будут показываться в поиске (%d).", count), nil)
+	return err
+}
+
 func (h *MessageHandler) handleUserPreferencesEdit(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
 
-	h.stateMgr.Set(userID, states.StateEditPrefGender)
-	_, err := b.SendMessage(chatID, "Укажите интересующий Вас пол:", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardMarkup{
-			Keyboard: [][]gotgbot.KeyboardButton{
-				{{Text: "Мужской"}, {Text: "Женский"}, {Text: "Любой"}},
-			},
-			ResizeKeyboard:  true,
-			OneTimeKeyboard: true,
-		},
-	})
+	draft, err := h.drafts.Preferences(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to load preferences draft", zap.Int64("user_id", userID), zap.Error(err))
+	} else if draft.Gender != "" {
+		h.stateMgr.Set(userID, states.StateResumePreferences)
+		_, err := b.SendMessage(chatID, "Вы не закончили изменение настроек поиска. Продолжить с того места, где остановились?", &gotgbot.SendMessageOpts{
+			ReplyMarkup: resumeKeyboard(),
+		})
+		return err
+	}
+
+	return h.startPreferencesWizard(b, chatID, userID)
+}
+
+func (h *MessageHandler) startPreferencesWizard(b *gotgbot.Bot, chatID, userID int64) error {
+	if err := h.drafts.DeletePreferences(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to reset preferences draft", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	return h.promptPreferencesStep(b, chatID, userID, states.StateEditPrefGender)
+}
+
+func (h *MessageHandler) handleResumePreferences(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.Message.From.Id
+	chatID := ctx.Message.Chat.Id
+
+	switch ctx.Message.Text {
+	case resumeText:
+		draft, err := h.drafts.Preferences(context.Background(), userID)
+		if err != nil {
+			return h.draftError(b, chatID, userID, err)
+		}
+		return h.promptPreferencesStep(b, chatID, userID, nextPreferencesStep(draft))
+	case restartText:
+		return h.startPreferencesWizard(b, chatID, userID)
+	default:
+		_, err := b.SendMessage(chatID, "Пожалуйста, выберите один из вариантов:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: resumeKeyboard(),
+		})
+		return err
+	}
+}
+
+// nextPreferencesStep определяет первый незаполненный шаг мастера настроек поиска.
+func nextPreferencesStep(draft *models.TempUserPreferencesData) states.State {
+	switch {
+	case draft.Gender == "":
+		return states.StateEditPrefGender
+	case draft.MinAge == 0:
+		return states.StateEditPrefMinage
+	case draft.MaxAge == 0:
+		return states.StateEditPrefMaxAge
+	default:
+		return states.StateEditPrefMaxDistance
+	}
+}
+
+func (h *MessageHandler) promptPreferencesStep(b *gotgbot.Bot, chatID, userID int64, step states.State) error {
+	h.stateMgr.Set(userID, step)
+
+	var err error
+	switch step {
+	case states.StateEditPrefGender:
+		_, err = b.SendMessage(chatID, "Укажите интересующий Вас пол:", &gotgbot.SendMessageOpts{
+			ReplyMarkup: prefGenderKeyboard(),
+		})
+	case states.StateEditPrefMinage:
+		_, err = b.SendMessage(chatID, "Введите минимальный возраст поиска (10-100):", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	case states.StateEditPrefMaxAge:
+		_, err = b.SendMessage(chatID, "Введите максимальный возраст поиска (10-100):", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	case states.StateEditPrefMaxDistance:
+		_, err = b.SendMessage(chatID, "Введите область поиска (в км):", &gotgbot.SendMessageOpts{
+			ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+		})
+	}
 	return err
 }
 
+func prefGenderKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{
+			{{Text: "Мужской"}, {Text: "Женский"}, {Text: "Любой"}},
+		},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
+	}
+}
+
 func (h *MessageHandler) handlePrefGender(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
@@ -64,27 +226,15 @@ func (h *MessageHandler) handlePrefGender(b *gotgbot.Bot, ctx *ext.Context) erro
 		gender = "a"
 	default:
 		_, err := b.SendMessage(chatID, "Пожалуйста, выберите пол из предложенных вариантов:", &gotgbot.SendMessageOpts{
-			ReplyMarkup: gotgbot.ReplyKeyboardMarkup{
-				Keyboard: [][]gotgbot.KeyboardButton{
-					{{Text: "Мужской"}, {Text: "Женский"}, {Text: "Любой"}},
-				},
-				ResizeKeyboard:  true,
-				OneTimeKeyboard: true,
-			},
+			ReplyMarkup: prefGenderKeyboard(),
 		})
 		return err
 	}
 
-	if _, exists := h.tempUserPreferences[userID]; !exists {
-		h.tempUserPreferences[userID] = &models.TempUserPreferencesData{}
+	if err := h.drafts.SetPreferencesField(context.Background(), userID, drafts.FieldPrefGender, gender); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserPreferences[userID].Gender = gender
-
-	h.stateMgr.Set(userID, states.StateEditPrefMinage)
-	_, err := b.SendMessage(chatID, "Введите минимальный возраст поиска (10-100):", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-	})
-	return err
+	return h.promptPreferencesStep(b, chatID, userID, states.StateEditPrefMinage)
 }
 
 func (h *MessageHandler) handlePrefMinAge(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -98,16 +248,10 @@ func (h *MessageHandler) handlePrefMinAge(b *gotgbot.Bot, ctx *ext.Context) erro
 		return err
 	}
 
-	if _, exists := h.tempUserPreferences[userID]; !exists {
-		h.tempUserPreferences[userID] = &models.TempUserPreferencesData{}
+	if err := h.drafts.SetPreferencesField(context.Background(), userID, drafts.FieldPrefMinAge, strconv.Itoa(minAge)); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserPreferences[userID].MinAge = minAge
-
-	h.stateMgr.Set(userID, states.StateEditPrefMaxAge)
-	_, err = b.SendMessage(chatID, "Введите максимальный возраст поиска (10-100):", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-	})
-	return err
+	return h.promptPreferencesStep(b, chatID, userID, states.StateEditPrefMaxAge)
 }
 
 func (h *MessageHandler) handlePrefMaxAge(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -115,22 +259,21 @@ func (h *MessageHandler) handlePrefMaxAge(b *gotgbot.Bot, ctx *ext.Context) erro
 	chatID := ctx.Message.Chat.Id
 	input := ctx.Message.Text
 
+	draft, err := h.drafts.Preferences(context.Background(), userID)
+	if err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
+
 	maxAge, err := strconv.Atoi(input)
-	if err != nil || maxAge < 10 || maxAge > 100 || maxAge < h.tempUserPreferences[userID].MinAge {
+	if err != nil || maxAge < 10 || maxAge > 100 || maxAge < draft.MinAge {
 		_, err := b.SendMessage(chatID, "Пожалуйста, введите корректный возраст (10-100)\nОн должен быть выше минимального:", nil)
 		return err
 	}
 
-	if _, exists := h.tempUserPreferences[userID]; !exists {
-		h.tempUserPreferences[userID] = &models.TempUserPreferencesData{}
+	if err := h.drafts.SetPreferencesField(context.Background(), userID, drafts.FieldPrefMaxAge, strconv.Itoa(maxAge)); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserPreferences[userID].MaxAge = maxAge
-
-	h.stateMgr.Set(userID, states.StateEditPrefMaxDistance)
-	_, err = b.SendMessage(chatID, "Введите область поиска (в км):", &gotgbot.SendMessageOpts{
-		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
-	})
-	return err
+	return h.promptPreferencesStep(b, chatID, userID, states.StateEditPrefMaxDistance)
 }
 
 func (h *MessageHandler) handlePrefMaxDistance(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -143,17 +286,19 @@ func (h *MessageHandler) handlePrefMaxDistance(b *gotgbot.Bot, ctx *ext.Context)
 		_, err = b.SendMessage(chatID, "Введите корректную область поиска, > 0:", nil)
 		return err
 	}
-	if _, exists := h.tempUserPreferences[userID]; !exists {
-		h.tempUserPreferences[userID] = &models.TempUserPreferencesData{}
+	if err := h.drafts.SetPreferencesField(context.Background(), userID, drafts.FieldPrefMaxDistance, strconv.Itoa(maxDistance)); err != nil {
+		return h.draftError(b, chatID, userID, err)
 	}
-	h.tempUserPreferences[userID].MaxDistance = maxDistance
 	return h.finalizeUserPreferences(b, ctx)
 }
 
 func (h *MessageHandler) finalizeUserPreferences(b *gotgbot.Bot, ctx *ext.Context) error {
 	userID := ctx.Message.From.Id
 	chatID := ctx.Message.Chat.Id
-	tempData := h.tempUserPreferences[userID]
+	tempData, err := h.drafts.Preferences(context.Background(), userID)
+	if err != nil {
+		return h.draftError(b, chatID, userID, err)
+	}
 
 	userPref := &db.UserPreference{
 		GenderPref:  tempData.Gender,
@@ -162,33 +307,20 @@ func (h *MessageHandler) finalizeUserPreferences(b *gotgbot.Bot, ctx *ext.Contex
 		MaxDistance: tempData.MaxDistance,
 	}
 
-	err := h.db.UserPreferences.Update(context.Background(), userPref, userID)
+	err = h.db.UserPreferences.Update(context.Background(), userPref, userID)
 	if err != nil {
 		h.logger.Error("Failed to save profile", zap.Int64("user_id", userID), zap.Error(err))
 		_, err = b.SendMessage(chatID, "Произошла ошибка при сохранении профиля. Попробуйте позже.", nil)
 		return err
 	}
 
-	h.stateMgr.ResetCurrentIndex(userID)
-	ctxRedis := context.Background()
-	keys, err := h.redis.Keys(ctxRedis, fmt.Sprintf("search:%d:*", userID)).Result()
-	if err != nil {
-		h.logger.Error("Failed to fetch search cache keys",
-			zap.Int64("user_id", userID),
-			zap.Error(err))
-	} else if len(keys) > 0 {
-		_, err = h.redis.Del(ctxRedis, keys...).Result()
-		if err != nil {
-			h.logger.Error("Failed to delete search cache",
-				zap.Int64("user_id", userID),
-				zap.Error(err))
-		}
-	}
+	h.callback.publish(events.PreferencesChanged{UserID: userID})
 
 	successMessage := "Настройки поиска обновлены! Что хотите сделать дальше?"
 
-	delete(h.tempUserPreferences, userID)
-	delete(h.isEditing, userID)
+	if err := h.drafts.DeletePreferences(context.Background(), userID); err != nil {
+		h.logger.Error("Failed to delete preferences draft", zap.Int64("user_id", userID), zap.Error(err))
+	}
 	h.stateMgr.Reset(userID)
 
 	_, err = b.SendMessage(chatID, successMessage, &gotgbot.SendMessageOpts{
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/agent-yandex/dating-bot/internal/tg/states"
@@ -13,9 +15,7 @@ func (h *MessageHandler) handleSearching(b *gotgbot.Bot, ctx *ext.Context) error
 
 	h.stateMgr.Set(userID, states.StateSearching)
 
-	currentIndex := h.stateMgr.GetCurrentIndex(userID)
-	offset := uint64(currentIndex/50) * 50
-	profiles, err := h.callback.getSearchResults(userID, offset)
+	profile, err := h.callback.feed.Current(context.Background(), userID)
 	if err != nil {
 		h.logger.Error("Failed to get search results",
 			zap.Int64("user_id", userID),
@@ -24,11 +24,13 @@ func (h *MessageHandler) handleSearching(b *gotgbot.Bot, ctx *ext.Context) error
 		return err
 	}
 
-	if len(profiles) == 0 {
-		h.stateMgr.ResetCurrentIndex(userID)
+	if profile == nil {
+		if err := h.callback.feed.Reset(context.Background(), userID); err != nil {
+			h.logger.Error("Failed to reset search feed", zap.Int64("user_id", userID), zap.Error(err))
+		}
 		_, err = b.SendMessage(chatID, "Анкет не найдено. Попробуйте изменить настройки поиска", nil)
 		return err
 	}
 
-	return h.callback.sendProfile(b, chatID, userID, profiles, currentIndex%50)
+	return h.callback.sendProfile(b, chatID, userID, profile)
 }
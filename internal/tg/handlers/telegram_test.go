@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const testToken = "123456:test"
+
+// apiCall — один запрос бота к Bot API.
+type apiCall struct {
+	Method string
+	Params map[string]string
+	Files  map[string][]byte
+}
+
+// fakeTelegram — поддельный Bot API: записывает запросы бота и отдаёт
+// заранее положенные файлы.
+type fakeTelegram struct {
+	t      *testing.T
+	server *httptest.Server
+	bot    *gotgbot.Bot
+
+	mu    sync.Mutex
+	calls []apiCall
+	files map[string][]byte
+	// failing — методы, на которые Bot API отвечает ошибкой
+	failing map[string]bool
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	t.Helper()
+	f := &fakeTelegram{t: t, files: make(map[string][]byte), failing: make(map[string]bool)}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+
+	bot, err := gotgbot.NewBot(testToken, &gotgbot.BotOpts{
+		DisableTokenCheck: true,
+		BotClient: &gotgbot.BaseBotClient{
+			DefaultRequestOpts: &gotgbot.RequestOpts{APIURL: f.server.URL},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	f.bot = bot
+	return f
+}
+
+// addFile кладёт файл, который бот сможет получить через getFile.
+func (f *fakeTelegram) addFile(path string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = data
+}
+
+// fail заставляет Bot API отвечать ошибкой на запросы с методом method.
+func (f *fakeTelegram) fail(method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing[method] = true
+}
+
+func (f *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	if path, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+testToken+"/"); ok {
+		f.mu.Lock()
+		data, found := f.files[path]
+		f.mu.Unlock()
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/bot"+testToken+"/")
+	call := apiCall{Method: method, Params: map[string]string{}, Files: map[string][]byte{}}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			f.t.Errorf("failed to parse multipart request: %v", err)
+		}
+		for k, v := range r.MultipartForm.Value {
+			call.Params[k] = v[0]
+		}
+		for k, headers := range r.MultipartForm.File {
+			file, err := headers[0].Open()
+			if err != nil {
+				f.t.Errorf("failed to open uploaded file: %v", err)
+				continue
+			}
+			call.Files[k], _ = io.ReadAll(file)
+			file.Close()
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&call.Params); err != nil {
+		f.t.Errorf("failed to decode request: %v", err)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	messageID := len(f.calls)
+	failing := f.failing[method]
+	f.mu.Unlock()
+
+	if failing {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": http.StatusBadRequest, "description": "Bad Request: " + method + " failed"})
+		return
+	}
+
+	var result any = true
+	switch method {
+	case "getFile":
+		path := "photos/" + call.Params["file_id"] + ".jpg"
+		f.mu.Lock()
+		size := len(f.files[path])
+		f.mu.Unlock()
+		result = gotgbot.File{FileId: call.Params["file_id"], FilePath: path, FileSize: int64(size)}
+	case "sendMessage", "sendPhoto", "sendInvoice", "editMessageText", "editMessageCaption", "editMessageReplyMarkup":
+		result = map[string]any{
+			"message_id": messageID,
+			"date":       1,
+			"chat":       map[string]any{"id": json.RawMessage(call.Params["chat_id"]), "type": "private"},
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// sent возвращает запросы с указанным методом.
+func (f *fakeTelegram) sent(method string) []apiCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []apiCall
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// texts возвращает тексты отправленных сообщений и подписи к фото.
+func (f *fakeTelegram) texts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, c := range f.calls {
+		switch c.Method {
+		case "sendMessage", "editMessageText":
+			texts = append(texts, c.Params["text"])
+		case "sendPhoto", "editMessageCaption":
+			texts = append(texts, c.Params["caption"])
+		}
+	}
+	return texts
+}
+
+// lastText возвращает текст последнего отправленного сообщения.
+func (f *fakeTelegram) lastText() string {
+	texts := f.texts()
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[len(texts)-1]
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	"github.com/agent-yandex/dating-bot/internal/tg/feed"
+	"go.uber.org/zap"
+)
+
+// likeAction определяет, можно ли отменить лайк. Лайк, образовавший пару,
+// не отменяется: собеседники уже получили контакты друг друга.
+func likeAction(result matching.Result) feed.Action {
+	if result == matching.Liked {
+		return feed.ActionLike
+	}
+	return feed.ActionNone
+}
+
+const matchedUndoText = "Этот лайк уже нельзя отменить: симпатия оказалась взаимной 💕"
+
+// undoKeyboard добавляет к клавиатуре карточки кнопку отмены, если у
+// пользователя есть свайп, который ещё можно отменить.
+func (h *CallbackHandler) undoKeyboard(userID int64, keyboard [][]gotgbot.InlineKeyboardButton) gotgbot.InlineKeyboardMarkup {
+	canUndo, err := h.feed.CanUndo(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to read swipe history",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+	if canUndo {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: "↩️ Отменить", CallbackData: "undo"},
+		})
+	}
+	if keyboard == nil {
+		keyboard = [][]gotgbot.InlineKeyboardButton{}
+	}
+	return gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// handleUndo отменяет последний свайп: удаляет лайк или пропуск и снова
+// показывает предыдущую анкету.
+func (h *CallbackHandler) handleUndo(b *gotgbot.Bot, ctx *ext.Context, userID int64) error {
+	chatID := ctx.CallbackQuery.Message.GetChat().Id
+	messageID := ctx.CallbackQuery.Message.GetMessageId()
+
+	swipe, err := h.feed.PopSwipe(context.Background(), userID)
+	if err != nil {
+		h.logger.Error("Failed to pop swipe",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при отмене действия.", nil)
+		return err
+	}
+	if swipe == nil {
+		_, err := b.SendMessage(chatID, "Отменять нечего: время на отмену истекло.", nil)
+		return err
+	}
+	profileID := swipe.Profile.ID
+
+	switch swipe.Action {
+	case feed.ActionLike:
+		withdrawn, err := h.matching.Withdraw(context.Background(), userID, profileID)
+		if err != nil {
+			_, _ = b.SendMessage(chatID, "Произошла ошибка при отмене лайка.", nil)
+			return err
+		}
+		// Если за это время пользователь лайкнул в ответ, лайк уже превратился
+		// в пару: собеседники получили контакты, и отменять его нельзя
+		if !withdrawn {
+			_, err := b.SendMessage(chatID, matchedUndoText, nil)
+			return err
+		}
+		h.refundDailyLike(userID)
+		h.publish(events.LikeWithdrawn{FromUserID: userID, ToUserID: profileID})
+
+	case feed.ActionPass:
+		if err := h.db.Passes.Delete(context.Background(), userID, profileID); err != nil {
+			h.logger.Error("Failed to delete pass on undo",
+				zap.Int64("user_id", userID),
+				zap.Int64("profile_id", profileID),
+				zap.Error(err))
+			_, _ = b.SendMessage(chatID, "Произошла ошибка при отмене дизлайка.", nil)
+			return err
+		}
+	}
+
+	if err := h.feed.Rewind(context.Background(), userID, &swipe.Profile); err != nil {
+		h.logger.Error("Failed to rewind search feed",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		_, _ = b.SendMessage(chatID, "Произошла ошибка при загрузке предыдущей анкеты.", nil)
+		return err
+	}
+
+	h.logger.Info("Swipe undone",
+		zap.Int64("user_id", userID),
+		zap.Int64("profile_id", profileID),
+		zap.String("action", string(swipe.Action)))
+
+	_, err = b.DeleteMessage(chatID, messageID, nil)
+	if err != nil {
+		h.logger.Warn("Failed to delete message",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+	}
+
+	return h.sendCurrentProfile(b, chatID, userID)
+}
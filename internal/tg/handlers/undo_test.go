@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/events"
+	"github.com/agent-yandex/dating-bot/internal/matching"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const searcherID = 1
+
+// newSearchEnv создаёт ищущего пользователя и анкеты с указанными id.
+func newSearchEnv(t *testing.T, profileIDs ...int64) *testEnv {
+	t.Helper()
+	env := newTestEnv(t)
+	for _, id := range append([]int64{searcherID}, profileIDs...) {
+		if _, err := env.users.Insert(context.Background(), &db.User{ID: id, Gender: "f", Age: 25, IsActive: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return env
+}
+
+// lastKeyboard возвращает разметку клавиатуры последнего отправленного
+// сообщения.
+func (f *fakeTelegram) lastKeyboard() string {
+	calls := f.sent("sendMessage")
+	if len(calls) == 0 {
+		return ""
+	}
+	return calls[len(calls)-1].Params["reply_markup"]
+}
+
+// withdrawnLikes подписывается на отзывы лайков.
+func withdrawnLikes(env *testEnv) func() []events.LikeWithdrawn {
+	var mu sync.Mutex
+	var withdrawn []events.LikeWithdrawn
+	events.On(env.bus, func(_ context.Context, e events.LikeWithdrawn) error {
+		mu.Lock()
+		defer mu.Unlock()
+		withdrawn = append(withdrawn, e)
+		return nil
+	})
+	return func() []events.LikeWithdrawn {
+		mu.Lock()
+		defer mu.Unlock()
+		return withdrawn
+	}
+}
+
+// likeFromSearch показывает анкету из ленты и лайкает её.
+func likeFromSearch(t *testing.T, env *testEnv, profileID int64) {
+	t.Helper()
+	if err := env.callback.sendCurrentProfile(env.tg.bot, searcherID, searcherID); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(env.tg.lastKeyboard(), fmt.Sprintf(`"like:%d"`, profileID)) {
+		t.Fatalf("current card is not profile %d: %s", profileID, env.tg.lastKeyboard())
+	}
+	err := env.callback.handleLike(env.tg.bot, callbackUpdate(searcherID, fmt.Sprintf("like:%d", profileID)), searcherID, profileID)
+	if err != nil {
+		t.Fatalf("handleLike: %v", err)
+	}
+}
+
+func TestUndoLike(t *testing.T) {
+	env := newSearchEnv(t, 2)
+	withdrawn := withdrawnLikes(env)
+
+	likeFromSearch(t, env, 2)
+	if !env.matches.hasLike(searcherID, 2) {
+		t.Fatal("like was not saved")
+	}
+
+	if err := env.callback.handleUndo(env.tg.bot, callbackUpdate(searcherID, "undo"), searcherID); err != nil {
+		t.Fatalf("handleUndo: %v", err)
+	}
+	if env.matches.hasLike(searcherID, 2) {
+		t.Error("like was not withdrawn")
+	}
+	if got := withdrawn(); len(got) != 1 || got[0].ToUserID != 2 {
+		t.Errorf("withdrawn events = %v", got)
+	}
+	if !strings.Contains(env.tg.lastKeyboard(), `"like:2"`) {
+		t.Errorf("profile 2 was not shown again: %s", env.tg.lastKeyboard())
+	}
+}
+
+// notificationsOn включает уведомления о лайках всем пользователям.
+type notificationsOn struct{}
+
+func (notificationsOn) LikeNotificationsEnabled(context.Context, int64) (bool, error) {
+	return true, nil
+}
+
+// discardSender принимает уведомления и никуда их не отправляет.
+type discardSender struct{}
+
+func (discardSender) Send(context.Context, int64, notify.Message) error { return nil }
+
+func TestUndoLikeRefundsQuotaAndPendingNotification(t *testing.T) {
+	env := newSearchEnv(t, 2)
+	env.callback.limits.DailyLikes = 5
+	ctx := context.Background()
+
+	client := redis.NewClient(&redis.Options{Addr: env.redis.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := notify.NewRedisStore(client)
+	env.callback.notifier = notify.NewService(discardSender{}, store, notificationsOn{}, notify.QuietHours{Location: time.UTC}, time.Hour, zap.NewNop())
+	env.callback.SubscribeNotifications(env.bus)
+
+	// Получателю недавно уже писали: лайк ждёт дайджеста
+	if _, err := store.MarkSent(ctx, 2, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	likeFromSearch(t, env, 2)
+	if users, _ := store.PendingUsers(ctx); len(users) != 1 {
+		t.Fatalf("pending users = %v, want the like in the digest", users)
+	}
+
+	if err := env.callback.handleUndo(env.tg.bot, callbackUpdate(searcherID, "undo"), searcherID); err != nil {
+		t.Fatalf("handleUndo: %v", err)
+	}
+	if got := remaining(t, env, "like_daily", 5, dailyQuotaWindow); got != 5 {
+		t.Errorf("daily quota remaining = %d, want the withdrawn like refunded", got)
+	}
+	users, err := store.PendingUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("pending users = %v, want the withdrawn like out of the digest", users)
+	}
+}
+
+func TestUndoRejectsLikeThatBecameMatch(t *testing.T) {
+	env := newSearchEnv(t, 2)
+	withdrawn := withdrawnLikes(env)
+
+	likeFromSearch(t, env, 2)
+	res, err := env.callback.matching.Like(context.Background(), &db.Like{FromUserID: 2, ToUserID: searcherID})
+	if err != nil || res != matching.Matched {
+		t.Fatalf("reciprocal like = %v, %v; want Matched", res, err)
+	}
+
+	if err := env.callback.handleUndo(env.tg.bot, callbackUpdate(searcherID, "undo"), searcherID); err != nil {
+		t.Fatalf("handleUndo: %v", err)
+	}
+	if got := env.tg.lastText(); got != matchedUndoText {
+		t.Errorf("reply = %q, want %q", got, matchedUndoText)
+	}
+	if env.matches.matchCount() != 1 {
+		t.Error("match was removed by undo")
+	}
+	if got := withdrawn(); len(got) != 0 {
+		t.Errorf("withdrawn events = %v, want none", got)
+	}
+}
+
+func TestUndoButtonOnlyOnSearchCards(t *testing.T) {
+	env := newSearchEnv(t, 2, 3)
+
+	likeFromSearch(t, env, 2)
+	// Следующая карточка поиска позволяет отменить лайк
+	if keyboard := env.tg.lastKeyboard(); !strings.Contains(keyboard, `"like:3"`) || !strings.Contains(keyboard, `"undo"`) {
+		t.Fatalf("search card keyboard = %s, want profile 3 with undo", keyboard)
+	}
+
+	likes := []*db.LikeProfile{{User: db.User{ID: 4, Gender: "m", Age: 30}}}
+	if err := env.callback.sendLikeProfile(env.tg.bot, searcherID, searcherID, likes, 0); err != nil {
+		t.Fatal(err)
+	}
+	keyboard := env.tg.lastKeyboard()
+	if !strings.Contains(keyboard, `"like_like:4"`) {
+		t.Fatalf("likes card keyboard = %s", keyboard)
+	}
+	if strings.Contains(keyboard, `"undo"`) {
+		t.Errorf("likes card has an undo button: %s", keyboard)
+	}
+}
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"fmt"
+	"html"
+	"math"
+	"time"
 
 	"github.com/agent-yandex/dating-bot/internal/db"
 )
@@ -16,12 +19,12 @@ func FormatProfile(user *db.User, cityName string) string {
 
 	username := "Не указано"
 	if user.Username != nil {
-		username = *user.Username
+		username = html.EscapeString(*user.Username)
 	}
 
 	bio := "Не указано"
 	if user.Bio != nil {
-		bio = *user.Bio
+		bio = html.EscapeString(*user.Bio)
 	}
 
 	return fmt.Sprintf(
@@ -33,11 +36,43 @@ func FormatProfile(user *db.User, cityName string) string {
 		username,
 		gender,
 		user.Age,
-		cityName,
+		html.EscapeString(cityName),
 		bio,
 	)
 }
 
+// FormatDistance округляет расстояние до корзин ("<1 км", "~5 км", "~30 км"),
+// чтобы по карточке нельзя было вычислить точное местоположение.
+func FormatDistance(km float64) string {
+	var rounded float64
+	switch {
+	case km < 1:
+		return "<1 км"
+	case km < 10:
+		rounded = math.Ceil(km/5) * 5
+	case km < 100:
+		rounded = math.Ceil(km/10) * 10
+	default:
+		rounded = math.Round(km/50) * 50
+	}
+	return fmt.Sprintf("~%d км", int(rounded))
+}
+
+// FormatWait выводит оставшееся время ожидания: "30 сек", "5 мин", "3 ч 12 мин".
+func FormatWait(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d сек", int(math.Max(1, math.Ceil(d.Seconds()))))
+	}
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes < 60 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
 func FormatUserPref(preference *db.UserPreference) string {
 	gender := "Не указан"
 	if preference.GenderPref == "m" {
@@ -45,15 +80,27 @@ func FormatUserPref(preference *db.UserPreference) string {
 	} else if preference.GenderPref == "f" {
 		gender = "Женский"
 	}
+	mutual := "выключен"
+	if preference.MutualMatch {
+		mutual = "включён"
+	}
+	notifyLikes := "выключены"
+	if preference.NotifyLikes {
+		notifyLikes = "включены"
+	}
 	return fmt.Sprintf(
 		"Настройки поиска 🔎:\n"+
 			"Минимальный возраст: %d\n"+
 			"Максимальный возраст: %d\n"+
 			"Пол 👤: %s\n"+
-			"Область поиска 🌍: %d км",
+			"Область поиска 🌍: %d км\n"+
+			"Взаимный подбор 🔁: %s\n"+
+			"Уведомления о лайках 🔔: %s",
 		preference.MinAge,
 		preference.MaxAge,
 		gender,
 		preference.MaxDistance,
+		mutual,
+		notifyLikes,
 	)
 }
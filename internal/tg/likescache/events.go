@@ -0,0 +1,25 @@
+package likescache
+
+import (
+	"context"
+
+	"github.com/agent-yandex/dating-bot/internal/events"
+)
+
+// Subscribe сбрасывает закэшированные страницы, когда меняется состав
+// входящих лайков. Подписывать нужно на синхронную шину: обработчик сразу
+// после публикации перечитывает список.
+func (c *Cache) Subscribe(bus events.Bus) {
+	events.On(bus, func(ctx context.Context, e events.LikeCreated) error {
+		return c.Invalidate(ctx, e.ToUserID)
+	})
+	events.On(bus, func(ctx context.Context, e events.LikeWithdrawn) error {
+		return c.Invalidate(ctx, e.ToUserID)
+	})
+	events.On(bus, func(ctx context.Context, e events.MatchCreated) error {
+		return c.Invalidate(ctx, e.UserID, e.PartnerID)
+	})
+	events.On(bus, func(ctx context.Context, e events.UserBlocked) error {
+		return c.Invalidate(ctx, e.BlockerID, e.BlockedID)
+	})
+}
@@ -0,0 +1,68 @@
+package likescache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/cache"
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Cache хранит страницы списка «Кто меня лайкнул» по смещению.
+type Cache struct {
+	pages  *cache.Versioned
+	logger *zap.Logger
+}
+
+func New(redisClient *redis.Client, ttl time.Duration, logger *zap.Logger) *Cache {
+	return &Cache{
+		pages:  cache.NewVersioned(redisClient, "likes:v2", ttl),
+		logger: logger,
+	}
+}
+
+// Page возвращает закэшированную страницу; ok равен false, если её нет.
+func (c *Cache) Page(ctx context.Context, userID int64, offset uint64) ([]*db.LikeProfile, bool, error) {
+	raw, ok, err := c.pages.Get(ctx, userID, c.key(offset))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read likes cache: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	var profiles []*db.LikeProfile
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return nil, false, nil
+	}
+	return profiles, true, nil
+}
+
+// Store кэширует страницу на ttl.
+func (c *Cache) Store(ctx context.Context, userID int64, offset uint64, profiles []*db.LikeProfile) error {
+	raw, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("failed to encode likes page: %w", err)
+	}
+	if err := c.pages.Set(ctx, userID, c.key(offset), raw); err != nil {
+		return fmt.Errorf("failed to store likes page: %w", err)
+	}
+	return nil
+}
+
+// Invalidate сбрасывает все закэшированные страницы указанных пользователей.
+func (c *Cache) Invalidate(ctx context.Context, userIDs ...int64) error {
+	if err := c.pages.Invalidate(ctx, userIDs...); err != nil {
+		return fmt.Errorf("failed to invalidate likes cache: %w", err)
+	}
+	c.logger.Debug("Cleared likes cache", zap.Int64s("user_ids", userIDs))
+	return nil
+}
+
+func (c *Cache) key(offset uint64) string {
+	return strconv.FormatUint(offset, 10)
+}
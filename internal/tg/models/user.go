@@ -1,9 +1,13 @@
 package models
 
 type TempUserData struct {
-	Username *string
-	Gender   string
-	Age      int
-	CityID   *int64
-	Bio      *string
+	Username     *string
+	Gender       string
+	Age          int
+	CityID       *int64
+	Bio          *string
+	ProfilePhoto *string
+	Latitude     *float64
+	Longitude    *float64
+	IsEditing    bool
 }
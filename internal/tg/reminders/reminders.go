@@ -0,0 +1,82 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"go.uber.org/zap"
+)
+
+// LikeExpiry напоминает пользователям о входящих лайках, которые скоро
+// истекут. Напоминание о каждом лайке отправляется не больше одного раза:
+// лайк отмечается до отправки, а если отправить не удалось, отметка
+// снимается и о лайке напомнит следующий запуск.
+type LikeExpiry struct {
+	sender notify.Sender
+	likes  db.LikeQuery
+	window time.Duration
+	logger *zap.Logger
+}
+
+// NewLikeExpiry создаёт напоминание о лайках, истекающих в течение window.
+func NewLikeExpiry(sender notify.Sender, likes db.LikeQuery, window time.Duration, logger *zap.Logger) *LikeExpiry {
+	return &LikeExpiry{
+		sender: sender,
+		likes:  likes,
+		window: window,
+		logger: logger,
+	}
+}
+
+// Run отправляет напоминания всем, у кого появились истекающие лайки.
+func (r *LikeExpiry) Run(ctx context.Context) error {
+	expiring, err := r.likes.ClaimExpiring(ctx, r.window)
+	if err != nil {
+		return fmt.Errorf("failed to claim expiring likes: %w", err)
+	}
+
+	var sent int
+	for i, e := range expiring {
+		if ctx.Err() != nil {
+			for _, rest := range expiring[i:] {
+				r.release(rest)
+			}
+			return ctx.Err()
+		}
+		err := r.sender.Send(ctx, e.UserID, notify.Message{
+			Text:    expiringText(e.Count),
+			Buttons: [][]notify.Button{{{Text: "❤️ Посмотреть", CallbackData: notify.ViewLikesCallback}}},
+		})
+		if err != nil {
+			// Пользователь мог заблокировать бота — это не повод прерывать рассылку
+			r.logger.Warn("Failed to send like expiry reminder",
+				zap.Int64("user_id", e.UserID),
+				zap.Error(err))
+			r.release(e)
+			continue
+		}
+		sent++
+	}
+
+	r.logger.Info("Like expiry reminders sent",
+		zap.Int("recipients", len(expiring)),
+		zap.Int("sent", sent))
+	return nil
+}
+
+// release снимает отметку с лайков, напоминание о которых не ушло. Контекст
+// запуска к этому моменту может быть уже отменён, а отметку нужно снять.
+func (r *LikeExpiry) release(e *db.ExpiringLikes) {
+	if err := r.likes.ReleaseExpiring(context.Background(), e.UserID, e.ClaimedAt); err != nil {
+		r.logger.Error("Failed to release like expiry reminder",
+			zap.Int64("user_id", e.UserID),
+			zap.Error(err))
+	}
+}
+
+func expiringText(count int) string {
+	return fmt.Sprintf("У вас %d %s, которые скоро исчезнут ⏳\nОтветьте, пока не поздно!", count, notify.PluralRu(count, "лайк", "лайка", "лайков"))
+}
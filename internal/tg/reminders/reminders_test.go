@@ -0,0 +1,122 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/agent-yandex/dating-bot/internal/db"
+	"github.com/agent-yandex/dating-bot/internal/notify"
+	"go.uber.org/zap"
+)
+
+// fakeLikes хранит число истекающих лайков каждого получателя и отметки
+// ClaimExpiring. Получатель с отметкой в выборку больше не попадает.
+type fakeLikes struct {
+	db.LikeQuery
+	expiring map[int64]int
+	claimed  map[int64]time.Time
+	now      time.Time
+}
+
+func newFakeLikes(expiring map[int64]int) *fakeLikes {
+	return &fakeLikes{
+		expiring: expiring,
+		claimed:  make(map[int64]time.Time),
+		now:      time.Date(2025, 4, 23, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *fakeLikes) ClaimExpiring(_ context.Context, _ time.Duration) ([]*db.ExpiringLikes, error) {
+	f.now = f.now.Add(time.Minute)
+	var result []*db.ExpiringLikes
+	for userID, count := range f.expiring {
+		if _, ok := f.claimed[userID]; ok {
+			continue
+		}
+		f.claimed[userID] = f.now
+		result = append(result, &db.ExpiringLikes{UserID: userID, Count: count, ClaimedAt: f.now})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UserID < result[j].UserID })
+	return result, nil
+}
+
+func (f *fakeLikes) ReleaseExpiring(_ context.Context, toUserID int64, claimedAt time.Time) error {
+	if f.claimed[toUserID].Equal(claimedAt) {
+		delete(f.claimed, toUserID)
+	}
+	return nil
+}
+
+// capturingSender сохраняет получателей отправленных напоминаний. Отправка
+// пользователям из fail завершается ошибкой.
+type capturingSender struct {
+	sent []int64
+	fail map[int64]bool
+}
+
+func (s *capturingSender) Send(_ context.Context, userID int64, _ notify.Message) error {
+	if s.fail[userID] {
+		return errors.New("Forbidden: bot was blocked by the user")
+	}
+	s.sent = append(s.sent, userID)
+	return nil
+}
+
+func TestRunRemindsEachRecipientOnce(t *testing.T) {
+	likes := newFakeLikes(map[int64]int{1: 2, 2: 1})
+	sender := &capturingSender{}
+	r := NewLikeExpiry(sender, likes, 6*time.Hour, zap.NewNop())
+
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+
+	if len(sender.sent) != 2 || sender.sent[0] != 1 || sender.sent[1] != 2 {
+		t.Errorf("sent = %v, want [1 2]", sender.sent)
+	}
+}
+
+func TestRunReleasesClaimWhenSendFails(t *testing.T) {
+	likes := newFakeLikes(map[int64]int{1: 1, 2: 1})
+	sender := &capturingSender{fail: map[int64]bool{1: true}}
+	r := NewLikeExpiry(sender, likes, 6*time.Hour, zap.NewNop())
+
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, ok := likes.claimed[1]; ok {
+		t.Error("claim kept after failed send")
+	}
+	if _, ok := likes.claimed[2]; !ok {
+		t.Error("claim released after successful send")
+	}
+
+	sender.fail = nil
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if len(sender.sent) != 2 || sender.sent[0] != 2 || sender.sent[1] != 1 {
+		t.Errorf("sent = %v, want [2 1]", sender.sent)
+	}
+}
+
+func TestRunReleasesUnsentClaimsOnCancel(t *testing.T) {
+	likes := newFakeLikes(map[int64]int{1: 1, 2: 1})
+	sender := &capturingSender{}
+	r := NewLikeExpiry(sender, likes, 6*time.Hour, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run error = %v, want context.Canceled", err)
+	}
+	if len(likes.claimed) != 0 {
+		t.Errorf("claims kept after cancel: %v", likes.claimed)
+	}
+}
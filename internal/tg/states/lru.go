@@ -0,0 +1,110 @@
+package states
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	userID   int64
+	state    State
+	cachedAt time.Time
+}
+
+// LRUStore — ограниченный по размеру кэш перед другим Store. Записи живут
+// не дольше maxAge, чтобы состояние, изменённое другой репликой, не залипало.
+type LRUStore struct {
+	next    Store
+	size    int
+	maxAge  time.Duration
+	mu      sync.Mutex
+	order   *list.List
+	entries map[int64]*list.Element
+	now     func() time.Time
+}
+
+func NewLRUStore(next Store, size int, maxAge time.Duration) *LRUStore {
+	return &LRUStore{
+		next:    next,
+		size:    size,
+		maxAge:  maxAge,
+		order:   list.New(),
+		entries: make(map[int64]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (s *LRUStore) Get(ctx context.Context, userID int64) (State, bool, error) {
+	if state, ok := s.lookup(userID); ok {
+		return state, true, nil
+	}
+
+	state, found, err := s.next.Get(ctx, userID)
+	if err != nil {
+		return state, found, err
+	}
+	if found {
+		s.put(userID, state)
+	}
+	return state, found, nil
+}
+
+func (s *LRUStore) Set(ctx context.Context, userID int64, state State, ttl time.Duration) error {
+	if err := s.next.Set(ctx, userID, state, ttl); err != nil {
+		s.remove(userID)
+		return err
+	}
+	s.put(userID, state)
+	return nil
+}
+
+func (s *LRUStore) Delete(ctx context.Context, userID int64) error {
+	s.remove(userID)
+	return s.next.Delete(ctx, userID)
+}
+
+func (s *LRUStore) lookup(userID int64) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[userID]
+	if !ok {
+		return StateDefault, false
+	}
+	entry := el.Value.(*lruEntry)
+	if s.now().Sub(entry.cachedAt) > s.maxAge {
+		s.order.Remove(el)
+		delete(s.entries, userID)
+		return StateDefault, false
+	}
+	s.order.MoveToFront(el)
+	return entry.state, true
+}
+
+func (s *LRUStore) put(userID int64, state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[userID]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.state = state
+		entry.cachedAt = s.now()
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[userID] = s.order.PushFront(&lruEntry{userID: userID, state: state, cachedAt: s.now()})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).userID)
+	}
+}
+
+func (s *LRUStore) remove(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[userID]; ok {
+		s.order.Remove(el)
+		delete(s.entries, userID)
+	}
+}
@@ -3,67 +3,57 @@ package states
 import (
 	"context"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
 )
 
 type Manager struct {
-	states map[int64]State
+	store  Store
 	redis  *redis.Client
 	ctx    context.Context
 	ttl    time.Duration
-	mu     sync.Mutex
+	logger *zap.Logger
 }
 
-func NewManager(redisClient *redis.Client) *Manager {
+func NewManager(redisClient *redis.Client, store Store, logger *zap.Logger) *Manager {
 	return &Manager{
-		states: make(map[int64]State),
+		store:  store,
 		redis:  redisClient,
 		ctx:    context.Background(),
 		ttl:    24 * time.Hour,
+		logger: logger,
 	}
 }
 
+// Get возвращает состояние пользователя. Если хранилище недоступно,
+// пользователь попадает в StateDefault: ошибка только логируется.
 func (m *Manager) Get(userID int64) State {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if state, exists := m.states[userID]; exists {
-		return state
+	state, found, err := m.store.Get(m.ctx, userID)
+	if err != nil {
+		m.logger.Error("Failed to get state", zap.Int64("user_id", userID), zap.Error(err))
+		return StateDefault
 	}
-	return StateDefault
+	if !found {
+		return StateDefault
+	}
+	return state
 }
 
 func (m *Manager) Set(userID int64, state State) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.states[userID] = state
+	if err := m.store.Set(m.ctx, userID, state, m.ttl); err != nil {
+		m.logger.Error("Failed to set state",
+			zap.Int64("user_id", userID),
+			zap.String("state", string(state)),
+			zap.Error(err))
+	}
 }
 
 func (m *Manager) Reset(userID int64) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.states[userID] = StateDefault
-}
-
-func (m *Manager) GetCurrentIndex(userID int64) int {
-	indexStr, err := m.redis.Get(m.ctx, m.indexKey(userID, "search")).Result()
-	if err == redis.Nil {
-		return 0
-	} else if err != nil {
-		return 0
+	if err := m.store.Delete(m.ctx, userID); err != nil {
+		m.logger.Error("Failed to reset state", zap.Int64("user_id", userID), zap.Error(err))
 	}
-	index, _ := strconv.Atoi(indexStr)
-	return index
-}
-
-func (m *Manager) SetCurrentIndex(userID int64, index int) {
-	m.redis.Set(m.ctx, m.indexKey(userID, "search"), index, m.ttl)
-}
-
-func (m *Manager) ResetCurrentIndex(userID int64) {
-	m.redis.Set(m.ctx, m.indexKey(userID, "search"), 0, m.ttl)
 }
 
 func (m *Manager) GetLikesCurrentIndex(userID int64) int {
@@ -16,4 +16,7 @@ const (
 	StateSearching           State = "searching"
 	StateViewLikes           State = "view_likes"
 	StateEditPhoto           State = "editing_photo"
+	StateResumeProfile       State = "resume_profile"
+	StateResumePreferences   State = "resume_preferences"
+	StateEditLikeMessage     State = "editing_like_message"
 )
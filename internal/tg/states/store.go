@@ -0,0 +1,106 @@
+package states
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// stateKeyVersion меняется при несовместимом изменении формата хранимого
+// состояния, чтобы старые ключи просто истекли по TTL.
+const stateKeyVersion = "v1"
+
+// Store хранит текущее состояние диалога пользователя.
+// Отсутствие записи означает StateDefault.
+type Store interface {
+	Get(ctx context.Context, userID int64) (State, bool, error)
+	Set(ctx context.Context, userID int64, state State, ttl time.Duration) error
+	Delete(ctx context.Context, userID int64) error
+}
+
+type RedisStore struct {
+	redis *redis.Client
+}
+
+func NewRedisStore(redisClient *redis.Client) *RedisStore {
+	return &RedisStore{redis: redisClient}
+}
+
+func (s *RedisStore) Get(ctx context.Context, userID int64) (State, bool, error) {
+	value, err := s.redis.Get(ctx, stateKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return StateDefault, false, nil
+	}
+	if err != nil {
+		return StateDefault, false, err
+	}
+	return State(value), true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, userID int64, state State, ttl time.Duration) error {
+	return s.redis.Set(ctx, stateKey(userID), string(state), ttl).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, userID int64) error {
+	return s.redis.Del(ctx, stateKey(userID)).Err()
+}
+
+func stateKey(userID int64) string {
+	return "fsm:" + stateKeyVersion + ":state:user:" + strconv.FormatInt(userID, 10)
+}
+
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// MemoryStore хранит состояния в памяти процесса. Подходит для тестов и
+// запуска в одном экземпляре без Redis.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[int64]memoryEntry
+	now    func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		states: make(map[int64]memoryEntry),
+		now:    time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, userID int64) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.states[userID]
+	if !ok {
+		return StateDefault, false, nil
+	}
+	if !entry.expiresAt.IsZero() && s.now().After(entry.expiresAt) {
+		delete(s.states, userID)
+		return StateDefault, false, nil
+	}
+	return entry.state, true, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, userID int64, state State, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := memoryEntry{state: state}
+	if ttl > 0 {
+		entry.expiresAt = s.now().Add(ttl)
+	}
+	s.states[userID] = entry
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, userID)
+	return nil
+}
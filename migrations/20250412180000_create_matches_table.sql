@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE matches (
+    id bigserial PRIMARY KEY,
+    user1_id bigint NOT NULL,
+    user2_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    UNIQUE (user1_id, user2_id),
+    CHECK (user1_id < user2_id),
+    CONSTRAINT fk_user1 FOREIGN KEY (user1_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user2 FOREIGN KEY (user2_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS matches CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_matches_user1 ON matches(user1_id);
+CREATE INDEX CONCURRENTLY idx_matches_user2 ON matches(user2_id);
+CREATE INDEX CONCURRENTLY idx_matches_created_at ON matches(created_at);
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_matches_user1;
+DROP INDEX IF EXISTS idx_matches_user2;
+DROP INDEX IF EXISTS idx_matches_created_at;
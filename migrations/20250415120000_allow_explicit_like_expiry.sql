@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Срок жизни лайка задаётся приложением (LIKE_TTL); триггер подставляет
+-- значение по умолчанию, только если expires_at не передан явно.
+ALTER TABLE likes ALTER COLUMN expires_at DROP DEFAULT;
+
+CREATE OR REPLACE FUNCTION set_like_expires_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.expires_at := COALESCE(NEW.expires_at, NEW.created_at + interval '3 days');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION set_like_expires_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.expires_at := NEW.created_at + interval '3 days';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE likes ALTER COLUMN expires_at SET DEFAULT (now() + interval '3 days');
+-- +goose StatementEnd
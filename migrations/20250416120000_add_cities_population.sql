@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE cities ADD COLUMN population bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE temp_geonames (
+                               geonameid int,
+                               name varchar(200),
+                               asciiname varchar(200),
+                               alternatenames text,
+                               latitude float,
+                               longitude float,
+                               feature_class char(1),
+                               feature_code varchar(10),
+                               country_code char(2),
+                               cc2 varchar(200),
+                               admin1_code varchar(20),
+                               admin2_code varchar(80),
+                               admin3_code varchar(20),
+                               admin4_code varchar(20),
+                               population bigint,
+                               elevation int,
+                               dem int,
+                               timezone varchar(40),
+                               modification_date date
+);
+
+COPY temp_geonames (
+    geonameid, name, asciiname, alternatenames, latitude, longitude,
+    feature_class, feature_code, country_code, cc2, admin1_code,
+    admin2_code, admin3_code, admin4_code, population, elevation,
+    dem, timezone, modification_date
+    ) FROM '/RU.txt' DELIMITER E'\t' NULL '';
+
+UPDATE cities c
+SET population = COALESCE(t.population, 0)
+FROM temp_geonames t
+WHERE t.feature_class = 'P'
+  AND t.country_code = 'RU'
+  AND t.alternatenames IS NOT NULL
+  AND c.name = SPLIT_PART(lower(t.alternatenames), ',', -1)
+  AND c.location = ST_GeogFromText('POINT(' || t.longitude || ' ' || t.latitude || ')');
+
+DROP TABLE temp_geonames;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cities DROP COLUMN IF EXISTS population;
+DROP EXTENSION IF EXISTS pg_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_cities_name_trgm ON cities USING GIN (name gin_trgm_ops);
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_cities_name_trgm;
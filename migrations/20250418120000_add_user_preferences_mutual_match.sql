@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_preferences ADD COLUMN mutual_match boolean NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS mutual_match;
+-- +goose StatementEnd
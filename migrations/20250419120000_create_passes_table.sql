@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE passes (
+    id bigserial PRIMARY KEY,
+    from_user_id bigint NOT NULL,
+    to_user_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    expires_at timestamp with time zone NOT NULL,
+    CONSTRAINT fk_from_user FOREIGN KEY (from_user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_to_user FOREIGN KEY (to_user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (from_user_id, to_user_id)
+);
+
+CREATE OR REPLACE PROCEDURE clean_expired_passes()
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    deleted_rows bigint;
+BEGIN
+    WITH deleted AS (
+        DELETE FROM passes
+        WHERE expires_at < now()
+        RETURNING *
+    )
+    SELECT count(*) INTO deleted_rows FROM deleted;
+
+    RAISE NOTICE 'Total rows deleted: %', deleted_rows;
+    COMMIT;
+EXCEPTION
+    WHEN OTHERS THEN
+        RAISE NOTICE 'Error in clean_expired_passes: %', SQLERRM;
+        ROLLBACK;
+END;
+$$;
+
+SELECT cron.schedule(
+    'clean-expired-passes',
+    '30 2 * * *',
+    'CALL clean_expired_passes()'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT cron.unschedule('clean-expired-passes');
+DROP PROCEDURE IF EXISTS clean_expired_passes;
+DROP TABLE IF EXISTS passes CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_passes_to_user ON passes(to_user_id);
+CREATE INDEX CONCURRENTLY idx_passes_expires_at ON passes(expires_at);
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_passes_to_user;
+DROP INDEX IF EXISTS idx_passes_expires_at;
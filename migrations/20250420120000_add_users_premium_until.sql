@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN premium_until timestamp with time zone;
+
+-- is_premium остаётся денормализованным флагом для индекса и user_stats;
+-- источником истины служит premium_until
+CREATE OR REPLACE PROCEDURE expire_premium()
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    updated_rows bigint;
+BEGIN
+    WITH expired AS (
+        UPDATE users
+        SET is_premium = FALSE
+        WHERE is_premium
+          AND (premium_until IS NULL OR premium_until <= now())
+        RETURNING id
+    )
+    SELECT count(*) INTO updated_rows FROM expired;
+
+    RAISE NOTICE 'Total premium subscriptions expired: %', updated_rows;
+    COMMIT;
+EXCEPTION
+    WHEN OTHERS THEN
+        RAISE NOTICE 'Error in expire_premium: %', SQLERRM;
+        ROLLBACK;
+END;
+$$;
+
+SELECT cron.schedule(
+    'expire-premium',
+    '*/15 * * * *',
+    'CALL expire_premium()'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT cron.unschedule('expire-premium');
+DROP PROCEDURE IF EXISTS expire_premium;
+ALTER TABLE users DROP COLUMN IF EXISTS premium_until;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE payments (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    telegram_charge_id text NOT NULL UNIQUE,
+    provider_charge_id text,
+    currency varchar(3) NOT NULL,
+    amount bigint NOT NULL CHECK (amount > 0),
+    payload text NOT NULL,
+    premium_until timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payments CASCADE;
+-- +goose StatementEnd
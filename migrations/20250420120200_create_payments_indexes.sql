@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_payments_user_id ON payments(user_id);
+CREATE INDEX CONCURRENTLY idx_users_premium_until ON users(premium_until) WHERE premium_until IS NOT NULL;
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_payments_user_id;
+DROP INDEX IF EXISTS idx_users_premium_until;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN score integer NOT NULL DEFAULT 0;
+
+CREATE TABLE profile_stats (
+    user_id bigint PRIMARY KEY,
+    views bigint NOT NULL DEFAULT 0,
+    likes_received bigint NOT NULL DEFAULT 0,
+    updated_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS profile_stats CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS score;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_users_rating_id ON users(rating DESC, id) WHERE is_active = TRUE;
+CREATE INDEX CONCURRENTLY idx_users_score_id ON users(score DESC, id) WHERE is_active = TRUE;
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_users_rating_id;
+DROP INDEX IF EXISTS idx_users_score_id;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Обслуживание выполняет планировщик бота (internal/scheduler)
+SELECT cron.unschedule('clean-expired-likes');
+SELECT cron.unschedule('clean-expired-passes');
+SELECT cron.unschedule('refresh-user-stats');
+SELECT cron.unschedule('expire-premium');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT cron.schedule(
+    'clean-expired-likes',
+    '0 2 * * *',
+    'CALL clean_expired_likes()'
+);
+SELECT cron.schedule(
+    'clean-expired-passes',
+    '30 2 * * *',
+    'CALL clean_expired_passes()'
+);
+SELECT cron.schedule(
+    'refresh-user-stats',
+    '0 2 * * *',
+    'REFRESH MATERIALIZED VIEW CONCURRENTLY user_stats'
+);
+SELECT cron.schedule(
+    'expire-premium',
+    '*/15 * * * *',
+    'CALL expire_premium()'
+);
+-- +goose StatementEnd
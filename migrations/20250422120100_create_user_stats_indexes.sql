@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+-- REFRESH ... CONCURRENTLY требует уникального индекса
+CREATE UNIQUE INDEX CONCURRENTLY idx_user_stats_gender_age_group ON user_stats(gender, age_group);
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_user_stats_gender_age_group;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Параметры, которые приложение задаёт из своей конфигурации при старте,
+-- чтобы SQL-код пользовался теми же значениями
+CREATE TABLE app_settings (
+    key text PRIMARY KEY,
+    value text NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+INSERT INTO app_settings (key, value) VALUES ('like_ttl', '3 days');
+
+-- Если expires_at не передан явно, срок жизни берётся из app_settings
+-- (LIKE_TTL), а не зашивается в триггер
+CREATE OR REPLACE FUNCTION set_like_expires_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.expires_at := COALESCE(
+        NEW.expires_at,
+        NEW.created_at + COALESCE(
+            (SELECT value::interval FROM app_settings WHERE key = 'like_ttl'),
+            interval '3 days'
+        )
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE likes ADD COLUMN expiry_reminded_at timestamp with time zone;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE likes DROP COLUMN IF EXISTS expiry_reminded_at;
+
+CREATE OR REPLACE FUNCTION set_like_expires_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.expires_at := COALESCE(NEW.expires_at, NEW.created_at + interval '3 days');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS app_settings;
+-- +goose StatementEnd
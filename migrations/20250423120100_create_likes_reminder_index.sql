@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_likes_expires_at_unreminded ON likes(expires_at) WHERE expiry_reminded_at IS NULL;
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_likes_expires_at_unreminded;
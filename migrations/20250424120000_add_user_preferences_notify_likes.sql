@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_preferences ADD COLUMN notify_likes boolean NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS notify_likes;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reports (
+    id bigserial PRIMARY KEY,
+    reporter_id bigint NOT NULL,
+    reported_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    resolved_at timestamp with time zone,
+    CONSTRAINT fk_reporter FOREIGN KEY (reporter_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reported FOREIGN KEY (reported_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (reporter_id, reported_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reports CASCADE;
+-- +goose StatementEnd
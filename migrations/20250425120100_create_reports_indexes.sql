@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_reports_open ON reports(reported_id) WHERE resolved_at IS NULL;
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_reports_open;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Платёж сохраняется, даже если премиум выдать не удалось (например,
+-- пользователь удалил профиль до оплаты): такие платежи остаются без срока
+-- и без связи с users, чтобы их можно было вернуть.
+ALTER TABLE payments DROP CONSTRAINT fk_user;
+ALTER TABLE payments ALTER COLUMN premium_until DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM payments WHERE premium_until IS NULL;
+DELETE FROM payments p WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = p.user_id);
+ALTER TABLE payments ALTER COLUMN premium_until SET NOT NULL;
+ALTER TABLE payments ADD CONSTRAINT fk_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE;
+-- +goose StatementEnd
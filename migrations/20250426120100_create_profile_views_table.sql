@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Анкеты, которые пользователь уже пролистал в поиске. До expires_at они не
+-- попадают в выдачу; раньше это множество хранилось в Redis и целиком
+-- передавалось в запрос кандидатов.
+CREATE TABLE profile_views (
+    id bigserial PRIMARY KEY,
+    from_user_id bigint NOT NULL,
+    to_user_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    expires_at timestamp with time zone NOT NULL,
+    CONSTRAINT fk_from_user FOREIGN KEY (from_user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_to_user FOREIGN KEY (to_user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (from_user_id, to_user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS profile_views CASCADE;
+-- +goose StatementEnd
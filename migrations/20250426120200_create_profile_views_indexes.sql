@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+CREATE INDEX CONCURRENTLY idx_profile_views_to_user ON profile_views(to_user_id);
+CREATE INDEX CONCURRENTLY idx_profile_views_expires_at ON profile_views(expires_at);
+
+
+-- +goose Down
+-- +goose NO TRANSACTION
+DROP INDEX IF EXISTS idx_profile_views_to_user;
+DROP INDEX IF EXISTS idx_profile_views_expires_at;